@@ -0,0 +1,137 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/bundle"
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var (
+    applyCheck       bool
+    applyNoTranslate bool
+)
+
+var applyCmd = &cobra.Command{
+    Use:   "apply [bundle.yaml]",
+    Short: "Install the packages listed in a bundle file",
+    Long: `Install the packages listed in a bundle file, such as a team's
+onboarding list.
+
+penguinguide compares the bundle with what is already installed and
+only installs what is missing, so running apply again is safe. Use
+--check to report drift without changing anything. It exits with
+status 1 when packages are missing.
+
+A bundle looks like this:
+
+  packages:
+    - git
+    - build-essential
+  families:
+    debian:
+      add: [gh]
+      remove: [build-essential]
+    arch:
+      add: [github-cli]`,
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runApply(args[0])
+    },
+}
+
+func init() {
+    RootCmd.AddCommand(applyCmd)
+    applyCmd.Flags().BoolVar(&applyCheck, "check", false, "only report missing packages, exit with status 1 if there are any")
+    applyCmd.Flags().BoolVar(&applyNoTranslate, "no-translate", false, "use package names exactly as written in the bundle")
+}
+
+func runApply(path string) {
+    b, err := bundle.Load(path)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not read the bundle file"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Heading("Apply package bundle"))
+    fmt.Printf("  %s %s\n", ui.Key("Bundle       :"), ui.Value(path))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Println()
+
+    wanted := b.For(d)
+    if len(wanted) == 0 {
+        fmt.Println(ui.Warning("The bundle does not list any packages for " + string(d.Family)))
+        return
+    }
+    if !applyNoTranslate {
+        wanted = translateForFamily(d, wanted)
+    }
+
+    mgr := newManager(d)
+    lister, ok := mgr.(pkgmgr.Lister)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Listing installed packages is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    installed, err := lister.List(opts)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not list installed packages"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    names := make(map[string]bool, len(installed))
+    for _, p := range installed {
+        names[p.Name] = true
+    }
+    missing := bundle.Missing(wanted, names)
+
+    fmt.Println(ui.Heading("Plan"))
+    for _, name := range wanted {
+        if names[name] {
+            fmt.Printf("  %s %s\n", ui.Success("ok     "), name)
+        } else {
+            fmt.Printf("  %s %s\n", ui.Warning("install"), name)
+        }
+    }
+    fmt.Println()
+
+    if len(missing) == 0 {
+        fmt.Println(ui.Success(fmt.Sprintf("All %d packages in the bundle are installed", len(wanted))))
+        return
+    }
+
+    if applyCheck {
+        fmt.Println(ui.Warning(fmt.Sprintf("%d of %d packages in the bundle are missing", len(missing), len(wanted))))
+        fmt.Println(ui.Muted("Run penguinguide apply " + path + " to install them."))
+        os.Exit(1)
+    }
+
+    if err := mgr.Install(missing, opts); err != nil {
+        fmt.Fprintln(os.Stderr)
+        fmt.Fprintln(os.Stderr, ui.Error("Installing the missing packages did not complete successfully"))
+        fmt.Fprintln(os.Stderr, ui.Muted("Most of the time this means the package manager reported an error"))
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Success("Apply finished"))
+}
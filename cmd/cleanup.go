@@ -0,0 +1,118 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var cleanupCmd = &cobra.Command{
+    Use:   "cleanup",
+    Short: "Free disk space used by package caches and unused packages",
+    Long: `Free disk space used by package caches and unused packages.
+
+Package managers keep every package they download, and packages that
+were only installed as dependencies stay behind when the program that
+needed them is removed. cleanup measures both, shows what would go and
+how much space it frees, and asks before each step.`,
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        runCleanup()
+    },
+}
+
+func init() {
+    RootCmd.AddCommand(cleanupCmd)
+}
+
+// cleanupTitles gives each cleanup task a friendly heading.
+var cleanupTitles = map[string]string{
+    "cache":   "Package cache",
+    "orphans": "Unused dependencies",
+}
+
+func runCleanup() {
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    cleaner, ok := newManager(d).(pkgmgr.Cleaner)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Cleanup is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    fmt.Println(ui.Heading("Cleanup"))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Println()
+
+    tasks, err := cleaner.CleanupTasks(opts)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not measure what can be cleaned up"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    if len(tasks) == 0 {
+        fmt.Println(ui.Success("Nothing to clean up, the caches are empty and every package is needed"))
+        return
+    }
+
+    var total int64
+    for _, task := range tasks {
+        printCleanupTask(task)
+        total += task.Size
+    }
+    fmt.Printf("  %s %s\n", ui.Key("Total        :"), ui.Success(sizeOrUnknown(total)))
+    fmt.Println()
+
+    // Each step is confirmed here, so skip the second dry run prompt.
+    runOpts := opts
+    runOpts.DryRun = false
+    failed := false
+    for _, task := range tasks {
+        title := cleanupTitles[task.Name]
+        if !assumeYes && !confirm(fmt.Sprintf("Clean up the %s (%s)", strings.ToLower(title), sizeOrUnknown(task.Size))) {
+            fmt.Println(ui.Muted("Skipped."))
+            fmt.Println()
+            continue
+        }
+        fmt.Println()
+        if err := cleaner.Cleanup(task, runOpts); err != nil {
+            fmt.Fprintln(os.Stderr, ui.Error(title+" cleanup did not complete successfully"))
+            fmt.Fprintln(os.Stderr, ui.Muted("The package manager output above has the detail"))
+            failed = true
+        }
+        fmt.Println()
+    }
+
+    if failed {
+        os.Exit(1)
+    }
+    fmt.Println(ui.Success("Cleanup finished"))
+}
+
+func printCleanupTask(task pkgmgr.CleanupTask) {
+    fmt.Println(ui.Heading(cleanupTitles[task.Name]))
+    fmt.Println("  " + task.Description)
+    fmt.Printf("  %s %s\n", ui.Key("Frees        :"), ui.Value(sizeOrUnknown(task.Size)))
+    if len(task.Packages) > 0 {
+        fmt.Printf("  %s %s\n", ui.Key("Removes      :"), strings.Join(task.Packages, " "))
+    }
+    fmt.Printf("  %s %s\n", ui.Key("Command      :"), ui.Muted(task.Command))
+    fmt.Println()
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var (
+    depsDepth int
+    depsJSON  bool
+)
+
+var depsCmd = &cobra.Command{
+    Use:   "deps [package]",
+    Short: "Show what a package depends on",
+    Long: `Show the packages a package needs, as a tree.
+
+Each level lists what the packages above it need in turn. Packages
+that already appear higher up are marked instead of repeated. Use
+--depth to go further down, or --depth 0 for the whole tree.`,
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runDeps(args[0], false)
+    },
+}
+
+var rdepsCmd = &cobra.Command{
+    Use:   "rdeps [package]",
+    Short: "Show which installed packages depend on a package",
+    Long: `Show the installed packages that need a package, as a tree.
+
+These are the packages that removing it would break or remove along
+with it. Each level lists what needs the packages above it in turn.`,
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runDeps(args[0], true)
+    },
+}
+
+func init() {
+    for _, c := range []*cobra.Command{depsCmd, rdepsCmd} {
+        c.Flags().IntVar(&depsDepth, "depth", 2, "how many levels to show, 0 for no limit")
+        c.Flags().BoolVar(&depsJSON, "json", false, "print the tree as JSON for scripts")
+        RootCmd.AddCommand(c)
+    }
+}
+
+func runDeps(name string, reverse bool) {
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    finder, ok := newManager(d).(pkgmgr.DepsFinder)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Showing dependencies is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    lookup, title := finder.Deps, "Dependencies of "+name
+    if reverse {
+        lookup, title = finder.ReverseDeps, "Installed packages that depend on "+name
+    }
+    tree, err := pkgmgr.DepTree(name, depsDepth, lookup, opts)
+    if errors.Is(err, pkgmgr.ErrPackageNotFound) {
+        fmt.Fprintln(os.Stderr, ui.Error("No package named "+name+" was found"))
+        fmt.Fprintln(os.Stderr, ui.Muted("Check the spelling, or try penguinguide search "+name))
+        os.Exit(1)
+    }
+    exitOnProvidesError(err)
+
+    if depsJSON {
+        printJSON(tree)
+        return
+    }
+
+    fmt.Println(ui.Heading(title))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Println()
+
+    if len(tree.Dependencies) == 0 {
+        if reverse {
+            fmt.Println(ui.Success("No installed package depends on " + name))
+        } else {
+            fmt.Println(ui.Success(name + " does not depend on other packages"))
+        }
+        return
+    }
+    fmt.Println("  " + ui.Value(tree.Name))
+    printDepTree(tree.Dependencies, "  ")
+    fmt.Println()
+    if reverse {
+        fmt.Println(ui.Muted("Removing " + name + " also affects the packages above"))
+    } else {
+        fmt.Println(ui.Muted("Packages marked (see above) are expanded earlier in the tree"))
+    }
+}
+
+// printDepTree prints nodes one per line, drawing the branches with
+// plain ASCII so the tree survives copying into a bug report.
+func printDepTree(nodes []*pkgmgr.DepNode, indent string) {
+    for i, n := range nodes {
+        branch, next := "|-- ", "|   "
+        if i == len(nodes)-1 {
+            branch, next = "`-- ", "    "
+        }
+        line := indent + ui.Muted(branch) + n.Name
+        if n.Repeated {
+            line += " " + ui.Muted("(see above)")
+        }
+        fmt.Println(line)
+        printDepTree(n.Dependencies, indent+ui.Muted(next))
+    }
+}
+
+// warnReverseDeps tells a dry run of remove which installed packages
+// still need the packages being removed. Lookup failures only mean no
+// warning, since the dry run itself is what was asked for.
+func warnReverseDeps(mgr pkgmgr.Manager, pkgs []string, opts pkgmgr.Options) {
+    finder, ok := mgr.(pkgmgr.DepsFinder)
+    if !ok {
+        return
+    }
+    opts.DryRun = false
+    opts.Explain = false
+
+    removing := make(map[string]bool)
+    for _, p := range pkgs {
+        removing[p] = true
+    }
+    warned := false
+    for _, p := range pkgs {
+        rdeps, err := finder.ReverseDeps(p, opts)
+        if err != nil {
+            continue
+        }
+        var needed []string
+        for _, r := range rdeps {
+            if !removing[r] {
+                needed = append(needed, r)
+            }
+        }
+        if len(needed) == 0 {
+            continue
+        }
+        if !warned {
+            fmt.Println(ui.Warning("Other installed packages depend on what you are removing"))
+            warned = true
+        }
+        fmt.Printf("  %s is needed by %s\n", ui.Value(p), strings.Join(needed, ", "))
+    }
+    if warned {
+        fmt.Println(ui.Muted("The package manager may remove them too, or refuse. See the full tree with: penguinguide rdeps <package>"))
+        fmt.Println()
+    }
+}
@@ -18,12 +18,15 @@ var detectCmd = &cobra.Command{
     },
 }
 
+var detectRoot string
+
 func init() {
+    detectCmd.Flags().StringVar(&detectRoot, "root", "/", "describe the system installed under this directory, such as a mounted image")
     RootCmd.AddCommand(detectCmd)
 }
 
 func runDetect() {
-    d, err := distro.Detect()
+    d, err := distro.DetectRoot(detectRoot)
     if err != nil {
         fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
         fmt.Fprintln(os.Stderr, "  Error:", err)
@@ -36,6 +39,54 @@ func runDetect() {
     fmt.Printf("  %s %s\n", ui.Key("NAME      :"), ui.Value(d.Name))
     fmt.Printf("  %s %s\n", ui.Key("PRETTY    :"), ui.Value(d.PrettyName))
     fmt.Printf("  %s %s\n", ui.Key("VERSION   :"), ui.Value(d.VersionID))
+    if d.VersionCodename != "" {
+        fmt.Printf("  %s %s\n", ui.Key("CODENAME  :"), ui.Value(d.VersionCodename))
+    }
+    if d.VariantID != "" {
+        fmt.Printf("  %s %s\n", ui.Key("VARIANT   :"), ui.Value(d.VariantID))
+    }
+    if d.BuildID != "" {
+        fmt.Printf("  %s %s\n", ui.Key("BUILD     :"), ui.Value(d.BuildID))
+    }
     fmt.Printf("  %s %s\n", ui.Key("FAMILY    :"), ui.Value(string(d.Family)))
+    if d.Subfamily != "" {
+        fmt.Printf("  %s %s\n", ui.Key("SUBFAMILY :"), ui.Value(string(d.Subfamily)))
+    }
+    if explain {
+        explainFamily(d)
+    }
+    if d.Atomic != "" {
+        fmt.Printf("  %s %s\n", ui.Key("IMAGE     :"), ui.Value("read-only, packages go through "+string(d.Atomic)))
+    }
+
+    // How to become root only says something about the running system.
+    if detectRoot != "/" {
+        return
+    }
+    if el := defaultEnv().Elevation; el != nil {
+        tool := el.Tool
+        if tool == "" {
+            tool = "none, already root"
+        }
+        fmt.Printf("  %s %s\n", ui.Key("ROOT VIA  :"), ui.Value(tool))
+        if explain {
+            fmt.Println("  " + ui.Muted(el.Reason))
+        }
+    }
+}
+
+// explainFamily says which classification rule chose the family, and
+// where to add one when none did.
+func explainFamily(d *distro.Distro) {
+    if m := d.Match; m != nil {
+        fmt.Println("  " + ui.Muted(fmt.Sprintf("%s %s matched the %q rule (%s).", m.Field, m.Value, m.Rule.Name, m.Rule.Source)))
+        return
+    }
+    where := "families.json in your config directory"
+    if path, err := distro.RulesPath(); err == nil {
+        where = path
+    }
+    fmt.Println("  " + ui.Muted("No rule matched this ID or ID_LIKE, so the family is "+string(distro.FamilyOther)+"."))
+    fmt.Println("  " + ui.Muted("Add a rule to "+where+" to choose one."))
 }
 
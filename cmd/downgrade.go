@@ -0,0 +1,83 @@
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var downgradeCmd = &cobra.Command{
+    Use:   "downgrade [package] [version]",
+    Short: "Go back to an older version of a package",
+    Long: `Go back to an older version of a package, for example when an
+update broke something.
+
+Without a version, penguinguide picks the newest version older than
+the one installed. See every choice with penguinguide versions. The
+next update upgrades the package again, unless you hold it.`,
+    Args: cobra.RangeArgs(1, 2),
+    Run: func(cmd *cobra.Command, args []string) {
+        version := ""
+        if len(args) == 2 {
+            version = args[1]
+        }
+        runDowngrade(args[0], version)
+    },
+}
+
+func init() {
+    RootCmd.AddCommand(downgradeCmd)
+}
+
+func runDowngrade(name, version string) {
+    d, versioner := newVersioner()
+    versions := packageVersions(versioner, name)
+
+    installed := ""
+    for _, v := range versions {
+        if v.Installed {
+            installed = v.Version
+        }
+    }
+
+    if version == "" {
+        previous, err := pkgmgr.PreviousVersion(versions)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, ui.Error("Could not find an older version of "+name))
+            fmt.Fprintln(os.Stderr, "  Error:", err)
+            if errors.Is(err, pkgmgr.ErrVersionNotFound) {
+                fmt.Fprintln(os.Stderr, ui.Muted("See what is available with: penguinguide versions "+name))
+            }
+            os.Exit(1)
+        }
+        version = previous.Version
+    }
+
+    fmt.Println(ui.Heading("Downgrade package"))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Printf("  %s %s\n", ui.Key("Package      :"), ui.Value(name))
+    fmt.Printf("  %s %s\n", ui.Key("Installed    :"), ui.Value(orUnknown(installed)))
+    fmt.Printf("  %s %s\n", ui.Key("Going back to:"), ui.Value(version))
+    fmt.Println()
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    if err := versioner.Downgrade(name, version, opts); err != nil {
+        fmt.Fprintln(os.Stderr)
+        fmt.Fprintln(os.Stderr, ui.Error("Downgrade did not complete successfully"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Success("Downgrade finished"))
+    fmt.Println(ui.Muted("Keep this version through updates with: penguinguide hold " + name))
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/history"
+    "penguinguide/internal/ui"
+)
+
+var (
+    historyLimit int
+    historyJSON  bool
+)
+
+var historyCmd = &cobra.Command{
+    Use:   "history",
+    Short: "Show package changes penguinguide has made",
+    Long: `Show the package operations penguinguide has run on this machine,
+newest first, including the exact native command and whether it worked.
+
+Use the ID with penguinguide undo to reverse an install or removal.`,
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        runHistory()
+    },
+}
+
+func init() {
+    RootCmd.AddCommand(historyCmd)
+    historyCmd.Flags().IntVar(&historyLimit, "limit", 20, "how many entries to show, 0 for all")
+    historyCmd.Flags().BoolVar(&historyJSON, "json", false, "print entries as JSON for scripts")
+}
+
+func runHistory() {
+    store, err := historyStore()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not find the history file"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    entries, err := store.Entries()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not read the history file"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    // Newest first, which is what people usually want to see.
+    for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+        entries[i], entries[j] = entries[j], entries[i]
+    }
+    if historyLimit > 0 && len(entries) > historyLimit {
+        entries = entries[:historyLimit]
+    }
+
+    if historyJSON {
+        if entries == nil {
+            entries = []history.Entry{}
+        }
+        printJSON(entries)
+        return
+    }
+
+    fmt.Println(ui.Heading("Package history"))
+    fmt.Printf("  %s %s\n", ui.Key("History file :"), ui.Value(store.Path))
+    fmt.Println()
+
+    if len(entries) == 0 {
+        fmt.Println(ui.Muted("Nothing recorded yet. Installs, removals, and updates will show up here."))
+        return
+    }
+
+    for _, e := range entries {
+        status := ui.Success("ok")
+        if !e.Succeeded() {
+            status = ui.Error(fmt.Sprintf("failed (exit %d)", e.ExitCode))
+        }
+        fmt.Printf("  %s  %s  %s  %s\n",
+            ui.Value(ui.PadRight(fmt.Sprintf("#%d", e.ID), 5)),
+            ui.Muted(e.Time.Local().Format("2006-01-02 15:04")),
+            ui.PadRight(e.Action, 8),
+            status)
+        if len(e.Packages) > 0 {
+            fmt.Printf("        %s %s\n", ui.Key("Packages:"), strings.Join(e.Packages, " "))
+        }
+        fmt.Printf("        %s %s\n", ui.Key("Command :"), e.Command)
+    }
+
+    fmt.Println()
+    fmt.Println(ui.Muted("Undo an install or removal with: penguinguide undo <id>"))
+}
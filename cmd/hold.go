@@ -0,0 +1,133 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var holdCmd = &cobra.Command{
+    Use:   "hold [packages...]",
+    Short: "Keep packages at their current version",
+    Long: `Keep packages at their current version, so updates skip them.
+
+This is useful when a newer version breaks something you rely on.
+Use unhold to allow upgrades again, and holds to see what is held.`,
+    Args: cobra.MinimumNArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runHold(args, true)
+    },
+}
+
+var unholdCmd = &cobra.Command{
+    Use:   "unhold [packages...]",
+    Short: "Allow held packages to be upgraded again",
+    Args:  cobra.MinimumNArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runHold(args, false)
+    },
+}
+
+var holdsCmd = &cobra.Command{
+    Use:   "holds",
+    Short: "List packages that are held at their current version",
+    Args:  cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        runHolds()
+    },
+}
+
+func init() {
+    RootCmd.AddCommand(holdCmd)
+    RootCmd.AddCommand(unholdCmd)
+    RootCmd.AddCommand(holdsCmd)
+}
+
+// newHolder returns the manager for this system if it supports holds,
+// and exits with an explanation otherwise.
+func newHolder() (*distro.Distro, pkgmgr.Holder) {
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    holder, ok := newManager(d).(pkgmgr.Holder)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Holding packages is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+    return d, holder
+}
+
+func runHold(pkgs []string, hold bool) {
+    d, holder := newHolder()
+
+    title, verb := "Hold packages", "Hold"
+    if !hold {
+        title, verb = "Unhold packages", "Unhold"
+    }
+    fmt.Println(ui.Heading(title))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Printf("  %s %v\n", ui.Key("Packages     :"), pkgs)
+    fmt.Println()
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    var err error
+    if hold {
+        err = holder.Hold(pkgs, opts)
+    } else {
+        err = holder.Unhold(pkgs, opts)
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr)
+        fmt.Fprintln(os.Stderr, ui.Error(verb+" did not complete successfully"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Success(verb + " finished"))
+    if hold {
+        fmt.Println(ui.Muted("Held packages stay at their current version until you run penguinguide unhold."))
+    }
+}
+
+func runHolds() {
+    d, holder := newHolder()
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    held, err := holder.Holds(opts)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not list held packages"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Heading("Held packages"))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Println()
+    if len(held) == 0 {
+        fmt.Println(ui.Muted("No packages are held. Every package is upgraded normally."))
+        return
+    }
+    for _, name := range held {
+        fmt.Println("  " + ui.Value(name))
+    }
+    fmt.Println()
+    fmt.Println(ui.Muted("Allow upgrades again with: penguinguide unhold <package>"))
+}
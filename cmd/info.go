@@ -1,8 +1,10 @@
 package cmd
 
 import (
+    "errors"
     "fmt"
     "os"
+    "strings"
 
     "github.com/spf13/cobra"
 
@@ -11,6 +13,11 @@ import (
     "penguinguide/internal/ui"
 )
 
+var (
+    infoRaw    bool
+    infoSource string
+)
+
 var infoCmd = &cobra.Command{
     Use:   "info [package]",
     Short: "Show detailed information for a package",
@@ -22,6 +29,8 @@ var infoCmd = &cobra.Command{
 
 func init() {
     RootCmd.AddCommand(infoCmd)
+    infoCmd.Flags().BoolVar(&infoRaw, "raw", false, "print the package manager output unchanged")
+    infoCmd.Flags().StringVar(&infoSource, "source", "", "only look in one source: native, flatpak, or snap")
 }
 
 func runInfo(name string) {
@@ -37,19 +46,99 @@ func runInfo(name string) {
     fmt.Printf("  %s %s\n", ui.Key("Package      :"), ui.Value(name))
     fmt.Println()
 
-    mgr := pkgmgr.New(d)
-
     opts := pkgmgr.Options{
         DryRun:    dryRun,
         AssumeYes: assumeYes,
         Explain:   explain,
     }
 
-    if err := mgr.Info(name, opts); err != nil {
-        fmt.Fprintln(os.Stderr)
-        fmt.Fprintln(os.Stderr, ui.Error("Package info request did not complete successfully"))
-        fmt.Fprintln(os.Stderr, ui.Muted("The package manager output above has the detail"))
+    var found []*pkgmgr.PackageInfo
+    for _, source := range sourcesToQuery(infoSource) {
+        mgr, src := newSourceManager(d, source)
+        info, err := mgr.Info(name, opts)
+        if errors.Is(err, pkgmgr.ErrPackageNotFound) {
+            continue
+        }
+        if err != nil && src == pkgmgr.SourceNative {
+            fmt.Fprintln(os.Stderr)
+            fmt.Fprintln(os.Stderr, ui.Error("Package info request did not complete successfully"))
+            fmt.Fprintln(os.Stderr, "  Error:", err)
+            os.Exit(1)
+        }
+        if err != nil {
+            fmt.Fprintln(os.Stderr, ui.Warning("Could not look in "+source+", skipping it"))
+            fmt.Fprintln(os.Stderr, "  Error:", err)
+            continue
+        }
+        info.Source = src
+        found = append(found, info)
+    }
+
+    if len(found) == 0 {
+        fmt.Fprintln(os.Stderr, ui.Error("No package named "+name+" was found"))
+        fmt.Fprintln(os.Stderr, ui.Muted("Try penguinguide search "+name+" to find the exact name"))
         os.Exit(1)
     }
+
+    for i, info := range found {
+        if i > 0 {
+            fmt.Println()
+        }
+        if infoRaw {
+            fmt.Print(info.Raw)
+            continue
+        }
+        printPackageInfo(info)
+    }
+}
+
+func printPackageInfo(info *pkgmgr.PackageInfo) {
+    status := ui.Muted("not installed")
+    if info.Installed {
+        status = ui.Success("installed")
+    }
+
+    fmt.Printf("  %s %s\n", ui.Key("Name          :"), ui.Value(info.Name))
+    fmt.Printf("  %s %s\n", ui.Key("Version       :"), ui.Value(orUnknown(info.Version)))
+    fmt.Printf("  %s %s\n", ui.Key("Status        :"), status)
+    fmt.Printf("  %s %s\n", ui.Key("Source        :"), ui.Value(string(info.Source)))
+    fmt.Printf("  %s %s\n", ui.Key("Repository    :"), ui.Value(orUnknown(info.Repository)))
+    fmt.Printf("  %s %s\n", ui.Key("Download size :"), ui.Value(sizeOrUnknown(info.Size)))
+    fmt.Printf("  %s %s\n", ui.Key("Installed size:"), ui.Value(sizeOrUnknown(info.InstalledSize)))
+    fmt.Printf("  %s %s\n", ui.Key("License       :"), ui.Value(orUnknown(info.License)))
+    fmt.Printf("  %s %s\n", ui.Key("Homepage      :"), ui.Value(orUnknown(info.Homepage)))
+
+    if len(info.Dependencies) == 0 {
+        fmt.Printf("  %s %s\n", ui.Key("Depends on    :"), ui.Muted("nothing listed"))
+    } else {
+        fmt.Printf("  %s %s\n", ui.Key("Depends on    :"), ui.Value(fmt.Sprintf("%d packages", len(info.Dependencies))))
+        for _, dep := range info.Dependencies {
+            fmt.Println("    " + dep)
+        }
+    }
+
+    if info.Description != "" {
+        fmt.Println()
+        fmt.Println(ui.Heading("Description"))
+        for _, line := range strings.Split(info.Description, "\n") {
+            fmt.Println("  " + line)
+        }
+    }
+
+    fmt.Println()
+    fmt.Println(ui.Muted("Use --raw to see the package manager output unchanged."))
 }
 
+func orUnknown(s string) string {
+    if s == "" {
+        return "unknown"
+    }
+    return s
+}
+
+func sizeOrUnknown(n int64) string {
+    if n == 0 {
+        return "unknown"
+    }
+    return pkgmgr.FormatSize(n)
+}
@@ -1,19 +1,34 @@
 package cmd
 
 import (
+    "errors"
     "fmt"
     "os"
+    "strings"
 
     "github.com/spf13/cobra"
 
     "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmap"
     "penguinguide/internal/pkgmgr"
     "penguinguide/internal/ui"
 )
 
+var (
+    installNoTranslate bool
+    installSource      string
+)
+
 var installCmd = &cobra.Command{
     Use:   "install [packages...]",
     Short: "Install packages",
+    Long: `Install packages with your distro's package manager.
+
+Add @version to a name, such as htop@3.2.2, to install that version.
+penguinguide versions htop shows which ones are available.
+
+Use --source flatpak or --source snap to install desktop apps from
+Flatpak or the Snap Store instead. Add --explain to see the trade-offs.`,
     Args:  cobra.MinimumNArgs(1),
     Run: func(cmd *cobra.Command, args []string) {
         runInstall(args)
@@ -22,6 +37,8 @@ var installCmd = &cobra.Command{
 
 func init() {
     RootCmd.AddCommand(installCmd)
+    installCmd.Flags().StringVar(&installSource, "source", "native", "where to install from: native, flatpak, or snap")
+    installCmd.Flags().BoolVar(&installNoTranslate, "no-translate", false, "use package names exactly as given, without translating them for this distro")
 }
 
 func runInstall(pkgs []string) {
@@ -34,10 +51,34 @@ func runInstall(pkgs []string) {
 
     fmt.Println(ui.Heading("Install packages"))
     fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Printf("  %s %s\n", ui.Key("Source       :"), ui.Value(installSource))
     fmt.Printf("  %s %v\n", ui.Key("Packages     :"), pkgs)
     fmt.Println()
 
-    mgr := pkgmgr.New(d)
+    mgr, src := newSourceManager(d, installSource)
+    if explain || src != pkgmgr.SourceNative {
+        printSourceNotes(src)
+    }
+
+    var plain, pinned []string
+    for _, p := range pkgs {
+        if _, version := pkgmgr.SplitVersion(p); version != "" {
+            pinned = append(pinned, p)
+        } else {
+            plain = append(plain, p)
+        }
+    }
+    if len(pinned) > 0 && src != pkgmgr.SourceNative {
+        fmt.Fprintln(os.Stderr, ui.Error("Versions can only be chosen for packages from your distro, not from "+string(src)))
+        os.Exit(1)
+    }
+
+    // Flatpak and Snap names are the same everywhere. Versions differ
+    // between distros, so names with a version are kept as typed.
+    if src == pkgmgr.SourceNative && !installNoTranslate {
+        pkgs = append(translateForFamily(d, plain), pinned...)
+    }
+
     opts := pkgmgr.Options{
         DryRun:    dryRun,
         AssumeYes: assumeYes,
@@ -46,6 +87,13 @@ func runInstall(pkgs []string) {
 
     if err := mgr.Install(pkgs, opts); err != nil {
         fmt.Fprintln(os.Stderr)
+        var nf *pkgmgr.NotFoundError
+        if errors.As(err, &nf) {
+            fmt.Fprintln(os.Stderr, ui.Error("Some packages were not found"))
+            fmt.Fprintln(os.Stderr)
+            suggestPackages(d, mgr, src, nf.Names, opts)
+            os.Exit(1)
+        }
         fmt.Fprintln(os.Stderr, ui.Error("Package install did not complete successfully"))
         fmt.Fprintln(os.Stderr, ui.Muted("Most of the time this means the package manager reported an error"))
         os.Exit(1)
@@ -54,3 +102,76 @@ func runInstall(pkgs []string) {
     fmt.Println(ui.Success("Install finished"))
 }
 
+// suggestPackages prints names close to each package that was not
+// found, from the package manager and from the translation table, so
+// a typo or a name from another distro is easy to fix.
+func suggestPackages(d *distro.Distro, mgr pkgmgr.Manager, src pkgmgr.Source, names []string, opts pkgmgr.Options) {
+    // Suggestions come from read-only queries, so they do not need to
+    // be printed instead of run.
+    opts.DryRun = false
+
+    var table *pkgmap.Table
+    if src == pkgmgr.SourceNative {
+        table, _ = pkgmap.Load()
+    }
+
+    first := ""
+    for _, name := range names {
+        fmt.Println(ui.Heading("No package named " + name))
+
+        suggestions, err := pkgmgr.Suggest(mgr, name, 5, opts)
+        if err != nil {
+            fmt.Println(ui.Muted("  Could not search for similar names: " + err.Error()))
+        }
+        if len(suggestions) > 0 {
+            fmt.Printf("  %s %s\n", ui.Key("Did you mean:"), ui.Value(strings.Join(suggestions, ", ")))
+            if first == "" {
+                first = suggestions[0]
+            }
+        }
+
+        translations := similarTranslations(table, name, d.Family)
+        if len(translations) > 0 {
+            fmt.Println("  " + ui.Key("Names from the translation table:"))
+            for _, tr := range translations {
+                fmt.Printf("    %s %s -> %s\n",
+                    ui.Value(tr.From),
+                    ui.Muted("("+string(tr.Source)+")"),
+                    ui.Success(strings.Join(tr.To, " ")))
+            }
+            if first == "" {
+                first = translations[0].To[0]
+            }
+        }
+
+        if len(suggestions) == 0 && len(translations) == 0 {
+            fmt.Println(ui.Muted("  No similar names found. Try penguinguide search " + name + " to find the exact name"))
+        }
+        fmt.Println()
+    }
+
+    if first != "" {
+        fmt.Println(ui.Muted("Install the closest match with: penguinguide install " + first))
+    }
+}
+
+// similarTranslations returns the table's entry for name when it was
+// written for another family, then entries with similar names.
+func similarTranslations(table *pkgmap.Table, name string, family distro.Family) []pkgmap.Translation {
+    if table == nil {
+        return nil
+    }
+    var out []pkgmap.Translation
+    seen := make(map[string]bool)
+    if tr := table.Translate(name, family); tr.Changed && !tr.NoMatch {
+        out = append(out, tr)
+        seen[tr.From] = true
+    }
+    for _, tr := range table.Similar(name, family, 3) {
+        if !seen[tr.From] && tr.From != name {
+            out = append(out, tr)
+            seen[tr.From] = true
+        }
+    }
+    return out
+}
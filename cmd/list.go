@@ -0,0 +1,142 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var (
+    listExplicit bool
+    listDeps     bool
+    listSort     string
+    listJSON     bool
+)
+
+var listCmd = &cobra.Command{
+    Use:   "list",
+    Short: "List installed packages",
+    Long: `List the packages installed on this system.
+
+Packages you asked for by name are marked as explicit. Everything
+else was pulled in as a dependency of another package.`,
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        runList()
+    },
+}
+
+func init() {
+    RootCmd.AddCommand(listCmd)
+    listCmd.Flags().BoolVar(&listExplicit, "explicit", false, "only show packages you installed by name")
+    listCmd.Flags().BoolVar(&listDeps, "deps", false, "only show packages installed as dependencies")
+    listCmd.Flags().StringVar(&listSort, "sort", "name", "sort by name, date, or size")
+    listCmd.Flags().BoolVar(&listJSON, "json", false, "print packages as JSON for scripts")
+}
+
+func runList() {
+    if listExplicit && listDeps {
+        fmt.Fprintln(os.Stderr, ui.Error("Choose either --explicit or --deps, not both"))
+        os.Exit(1)
+    }
+    if listSort != "name" && listSort != "date" && listSort != "size" {
+        fmt.Fprintln(os.Stderr, ui.Error("Unknown sort order "+listSort))
+        fmt.Fprintln(os.Stderr, ui.Muted("Use name, date, or size"))
+        os.Exit(1)
+    }
+
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    lister, ok := newManager(d).(pkgmgr.Lister)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Listing installed packages is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    pkgs, err := lister.List(opts)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not list installed packages"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    filter := pkgmgr.ListAll
+    if listExplicit {
+        filter = pkgmgr.ListExplicit
+    } else if listDeps {
+        filter = pkgmgr.ListDependencies
+    }
+    pkgs = pkgmgr.FilterInstalled(pkgs, filter)
+    pkgmgr.SortInstalled(pkgs, listSort)
+
+    if listJSON {
+        if pkgs == nil {
+            pkgs = []pkgmgr.InstalledPackage{}
+        }
+        printJSON(pkgs)
+        return
+    }
+
+    printInstalledPackages(pkgs)
+}
+
+func printInstalledPackages(pkgs []pkgmgr.InstalledPackage) {
+    if len(pkgs) == 0 {
+        fmt.Println(ui.Warning("No installed packages matched"))
+        return
+    }
+
+    nameW, versionW, sizeW := len("NAME"), len("VERSION"), len("SIZE")
+    for _, p := range pkgs {
+        nameW = max(nameW, len(p.Name))
+        versionW = max(versionW, len(p.Version))
+        sizeW = max(sizeW, len(sizeOrUnknown(p.Size)))
+    }
+
+    fmt.Printf("  %s  %s  %s  %s  %s\n",
+        ui.Key(ui.PadRight("NAME", nameW)),
+        ui.Key(ui.PadRight("VERSION", versionW)),
+        ui.Key(ui.PadRight("SIZE", sizeW)),
+        ui.Key(ui.PadRight("INSTALLED", 10)),
+        ui.Key("REASON"))
+
+    explicitCount := 0
+    for _, p := range pkgs {
+        date := "unknown"
+        if !p.InstallDate.IsZero() {
+            date = p.InstallDate.Format("2006-01-02")
+        }
+        reason := ui.Muted("dependency")
+        name := ui.PadRight(p.Name, nameW)
+        if p.Explicit {
+            reason = ui.Success("explicit")
+            name = ui.Value(name)
+            explicitCount++
+        }
+        fmt.Printf("  %s  %s  %s  %s  %s\n",
+            name,
+            ui.PadRight(p.Version, versionW),
+            ui.PadRight(sizeOrUnknown(p.Size), sizeW),
+            ui.Muted(ui.PadRight(date, 10)),
+            reason)
+    }
+
+    fmt.Println()
+    fmt.Printf("  %d packages, %s\n", len(pkgs), ui.Success(fmt.Sprintf("%d installed by name", explicitCount)))
+}
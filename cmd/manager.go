@@ -0,0 +1,86 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/history"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+// newManager returns the package manager for d. Changes it makes are
+// recorded in the history log so they can be reviewed and undone.
+func newManager(d *distro.Distro) pkgmgr.Manager {
+    return pkgmgr.NewWithEnv(d, recordingEnv(d, pkgmgr.SourceNative))
+}
+
+// newSourceManager is newManager for a package source given on the
+// command line. It exits with a beginner friendly message when the
+// source is unknown or not installed.
+func newSourceManager(d *distro.Distro, name string) (pkgmgr.Manager, pkgmgr.Source) {
+    src, err := pkgmgr.ParseSource(name)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Unknown package source"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    mgr, err := pkgmgr.NewSource(d, src, recordingEnv(d, src))
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error(string(src)+" is not available on this system"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    return mgr, src
+}
+
+// recordingEnv returns the default environment with a history
+// recorder for d and src attached, when the history file can be found.
+func recordingEnv(d *distro.Distro, src pkgmgr.Source) pkgmgr.Env {
+    env := defaultEnv()
+    if store, err := historyStore(); err == nil {
+        rec := history.Recorder{Store: store, Family: string(d.Family)}
+        if src != pkgmgr.SourceNative {
+            rec.Source = string(src)
+        }
+        env.Recorder = rec
+    }
+    return env
+}
+
+func historyStore() (*history.Store, error) {
+    path, err := history.DefaultPath()
+    if err != nil {
+        return nil, err
+    }
+    return &history.Store{Path: path}, nil
+}
+
+// sourceNotes explains what choosing each package source means,
+// so people can decide without reading up on packaging formats.
+var sourceNotes = map[pkgmgr.Source][]string{
+    pkgmgr.SourceNative: {
+        "Packages come from your distribution and share its libraries.",
+        "They get security fixes with your regular updates and integrate best with the system.",
+        "Versions can be older on stable distributions.",
+    },
+    pkgmgr.SourceFlatpak: {
+        "Apps come from Flatpak remotes such as Flathub and bring their own runtime.",
+        "They often have newer versions and run in a sandbox with limited access to your files.",
+        "They use more disk space, and are updated with flatpak update rather than your system updates.",
+    },
+    pkgmgr.SourceSnap: {
+        "Apps come from the Snap Store and bundle their own libraries.",
+        "Snaps update themselves in the background and run confined unless they are classic snaps.",
+        "They use more disk space and can start slower the first time.",
+    },
+}
+
+func printSourceNotes(src pkgmgr.Source) {
+    fmt.Println(ui.Heading("About " + string(src) + " packages"))
+    for _, line := range sourceNotes[src] {
+        fmt.Println("  " + line)
+    }
+    fmt.Println()
+}
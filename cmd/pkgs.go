@@ -0,0 +1,20 @@
+package cmd
+
+import (
+    "github.com/spf13/cobra"
+)
+
+var pkgsCmd = &cobra.Command{
+    Use:   "pkgs",
+    Short: "Save and restore the packages you installed",
+    Long: `Save the packages you installed by name to a file, and install them
+again later, for example after reinstalling your computer.
+
+The file remembers which distro it came from, so it can also be
+imported on a different distro family. Package names are translated
+where penguinguide knows the equivalent.`,
+}
+
+func init() {
+    RootCmd.AddCommand(pkgsCmd)
+}
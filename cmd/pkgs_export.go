@@ -0,0 +1,100 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/pkgset"
+    "penguinguide/internal/ui"
+)
+
+var pkgsExportCmd = &cobra.Command{
+    Use:   "export [file]",
+    Short: "Write the packages you installed by name to a file",
+    Long: `Write the packages you installed by name to a file.
+
+Packages pulled in as dependencies are left out, because they come
+back on their own. Without a file name the list is printed, so you
+can redirect it wherever you like.`,
+    Args: cobra.MaximumNArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        path := ""
+        if len(args) == 1 {
+            path = args[0]
+        }
+        runPkgsExport(path)
+    },
+}
+
+func init() {
+    pkgsCmd.AddCommand(pkgsExportCmd)
+}
+
+func runPkgsExport(path string) {
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    lister, ok := newManager(d).(pkgmgr.Lister)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Listing installed packages is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    pkgs, err := lister.List(opts)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not list installed packages"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    set := pkgset.New(d, pkgs, time.Now())
+
+    if path == "" {
+        if err := set.Write(os.Stdout); err != nil {
+            fmt.Fprintln(os.Stderr, ui.Error("Could not write the package list"))
+            fmt.Fprintln(os.Stderr, "  Error:", err)
+            os.Exit(1)
+        }
+        return
+    }
+
+    f, err := os.Create(path)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not create "+path))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    if err := set.Write(f); err != nil {
+        f.Close()
+        fmt.Fprintln(os.Stderr, ui.Error("Could not write the package list"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    if err := f.Close(); err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not write the package list"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Heading("Package export"))
+    fmt.Printf("  %s %s\n", ui.Key("Distro       :"), ui.Value(d.ID+" "+d.VersionID))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Printf("  %s %d\n", ui.Key("Packages     :"), len(set.Packages))
+    fmt.Printf("  %s %s\n", ui.Key("Written to   :"), ui.Value(path))
+    fmt.Println()
+    fmt.Println(ui.Muted("Restore them later with: penguinguide pkgs import " + path))
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/bundle"
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmap"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/pkgset"
+    "penguinguide/internal/ui"
+)
+
+var pkgsImportCmd = &cobra.Command{
+    Use:   "import [file]",
+    Short: "Install the packages from an export file",
+    Long: `Install the packages listed in a file written by pkgs export.
+
+Packages that are already installed are skipped. When the file comes
+from a different distro family, names are translated where possible,
+and packages with no equivalent here are listed so you can look for
+an alternative.`,
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runPkgsImport(args[0])
+    },
+}
+
+func init() {
+    pkgsCmd.AddCommand(pkgsImportCmd)
+}
+
+func runPkgsImport(path string) {
+    f, err := os.Open(path)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not open "+path))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    set, err := pkgset.Read(f)
+    f.Close()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not read the package list"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    table, err := pkgmap.Load()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not load the package name table"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Heading("Package import"))
+    fmt.Printf("  %s %s (%s)\n", ui.Key("Exported from:"), ui.Value(strings.TrimSpace(set.DistroID+" "+set.DistroVersion)), set.Family)
+    fmt.Printf("  %s %s (%s)\n", ui.Key("Importing on :"), ui.Value(strings.TrimSpace(d.ID+" "+d.VersionID)), d.Family)
+    fmt.Printf("  %s %d\n", ui.Key("Packages     :"), len(set.Packages))
+    fmt.Println()
+
+    mgr := newManager(d)
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    res := set.Resolve(table, d.Family)
+    noEquivalent := res.NoEquivalent
+    install := res.Install
+
+    // Names the table does not know are often the same everywhere,
+    // but one missing name makes most package managers refuse the
+    // whole install, so check each of them first.
+    if len(res.Unverified) > 0 {
+        fmt.Println(ui.Info(fmt.Sprintf("Checking %d package names that have no known translation", len(res.Unverified))))
+        for _, name := range res.Unverified {
+            _, err := mgr.Info(name, opts)
+            if errors.Is(err, pkgmgr.ErrPackageNotFound) {
+                noEquivalent = append(noEquivalent, name)
+                continue
+            }
+            if err != nil {
+                fmt.Fprintln(os.Stderr, ui.Warning("Could not check "+name+", leaving it out"))
+                fmt.Fprintln(os.Stderr, "  Error:", err)
+                noEquivalent = append(noEquivalent, name)
+                continue
+            }
+            install = append(install, name)
+        }
+        fmt.Println()
+    }
+
+    if len(res.Translated) > 0 {
+        fmt.Println(ui.Heading("Translated names"))
+        for _, tr := range res.Translated {
+            fmt.Printf("  %s -> %s\n", ui.Value(tr.From), ui.Success(strings.Join(tr.To, " ")))
+        }
+        fmt.Println()
+    }
+
+    if len(noEquivalent) > 0 {
+        fmt.Println(ui.Heading("No equivalent on " + string(d.Family)))
+        for _, name := range noEquivalent {
+            fmt.Println("  " + ui.Warning(name))
+        }
+        fmt.Println(ui.Muted("  Try penguinguide search with a word from the name to find an alternative."))
+        fmt.Println()
+    }
+
+    if lister, ok := mgr.(pkgmgr.Lister); ok {
+        installed, err := lister.List(opts)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, ui.Error("Could not list installed packages"))
+            fmt.Fprintln(os.Stderr, "  Error:", err)
+            os.Exit(1)
+        }
+        names := make(map[string]bool, len(installed))
+        for _, p := range installed {
+            names[p.Name] = true
+        }
+        install = bundle.Missing(install, names)
+    }
+
+    if len(install) == 0 {
+        fmt.Println(ui.Success("Everything that can be installed is already installed"))
+        return
+    }
+
+    fmt.Printf("  %s %s\n", ui.Key("To install   :"), strings.Join(install, " "))
+    fmt.Println()
+
+    if err := mgr.Install(install, opts); err != nil {
+        fmt.Fprintln(os.Stderr)
+        fmt.Fprintln(os.Stderr, ui.Error("Package import did not complete successfully"))
+        fmt.Fprintln(os.Stderr, ui.Muted("Most of the time this means the package manager reported an error"))
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Success("Import finished"))
+}
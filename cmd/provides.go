@@ -0,0 +1,148 @@
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var providesAvailable bool
+
+var providesCmd = &cobra.Command{
+    Use:   "provides [command or path]",
+    Short: "Find which package owns a file or provides a command",
+    Long: `Find which package a file or command comes from.
+
+Give a path such as /usr/bin/htop to see which installed package owns it.
+Give a command name such as ifconfig to see which installed package it
+comes from, or, if it is not installed, which package you can install
+to get it.`,
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runProvides(args[0])
+    },
+}
+
+func init() {
+    RootCmd.AddCommand(providesCmd)
+    providesCmd.Flags().BoolVar(&providesAvailable, "available", false, "search available packages even if the command is installed")
+}
+
+func runProvides(target string) {
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Heading("Find the package behind a file"))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Printf("  %s %s\n", ui.Key("Looking for  :"), ui.Value(target))
+    fmt.Println()
+
+    finder, ok := newManager(d).(pkgmgr.ProvidesFinder)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Finding file owners is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    if strings.Contains(target, "/") {
+        providers, err := finder.Owner(target, opts)
+        exitOnProvidesError(err)
+        if len(providers) == 0 {
+            fmt.Println(ui.Warning("No installed package owns " + target))
+            fmt.Println(ui.Muted("It may have been created by hand, by a script, or by another tool such as pip"))
+            return
+        }
+        printProviders("Installed package that owns this file", providers)
+        return
+    }
+
+    if path, err := exec.LookPath(target); err == nil && !providesAvailable {
+        providers, err := ownerOfCommand(finder, path, opts)
+        exitOnProvidesError(err)
+        if len(providers) > 0 {
+            fmt.Printf("  %s is already installed at %s\n", ui.Value(target), ui.Value(path))
+            fmt.Println()
+            printProviders("Installed package that provides it", providers)
+            return
+        }
+        fmt.Printf("  %s is installed at %s, but no package owns it\n", ui.Value(target), ui.Value(path))
+        fmt.Println(ui.Muted("  Searching available packages instead"))
+        fmt.Println()
+    }
+
+    providers, err := finder.Provides(target, opts)
+    exitOnProvidesError(err)
+    if len(providers) == 0 {
+        fmt.Println(ui.Warning("No available package provides a command named " + target))
+        fmt.Println(ui.Muted("Check the spelling, or try penguinguide search " + target))
+        if d.Family == distro.FamilyArch {
+            fmt.Println(ui.Muted("pacman keeps a separate files database, refresh it with: "+asRoot("pacman -Fy")))
+        }
+        return
+    }
+    printProviders("Packages you can install to get "+target, providers)
+    fmt.Println()
+    fmt.Printf("  Install one with: %s\n", ui.Value("penguinguide install "+providers[0].Package))
+}
+
+// ownerOfCommand looks up the owner of path, and of the file it links to,
+// because commands are often symlinks managed by alternatives.
+func ownerOfCommand(finder pkgmgr.ProvidesFinder, path string, opts pkgmgr.Options) ([]pkgmgr.Provider, error) {
+    providers, err := finder.Owner(path, opts)
+    if err != nil || len(providers) > 0 {
+        return providers, err
+    }
+    resolved, err := filepath.EvalSymlinks(path)
+    if err != nil || resolved == path {
+        return nil, nil
+    }
+    return finder.Owner(resolved, opts)
+}
+
+func exitOnProvidesError(err error) {
+    if err == nil {
+        return
+    }
+    var missing *pkgmgr.MissingToolError
+    if errors.As(err, &missing) {
+        fmt.Fprintln(os.Stderr, ui.Warning("This search needs "+missing.Tool+", which is not installed"))
+        fmt.Fprintln(os.Stderr, "  Install it with:")
+        fmt.Fprintln(os.Stderr, "    "+ui.Value(missing.Install))
+        os.Exit(1)
+    }
+    fmt.Fprintln(os.Stderr, ui.Error("Package lookup did not complete successfully"))
+    fmt.Fprintln(os.Stderr, "  Error:", err)
+    os.Exit(1)
+}
+
+func printProviders(title string, providers []pkgmgr.Provider) {
+    fmt.Println(ui.Heading(title))
+    for _, p := range providers {
+        line := "  " + ui.Success(p.Package)
+        if p.Repository != "" {
+            line += " " + ui.Muted("("+p.Repository+")")
+        }
+        if p.Path != "" {
+            line += "  " + p.Path
+        }
+        fmt.Println(line)
+    }
+}
@@ -9,6 +9,7 @@ import (
     "github.com/spf13/cobra"
 
     "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
     "penguinguide/internal/ui"
 )
 
@@ -185,20 +186,52 @@ func runQuickstartScript() {
 
     switch family {
     case distro.FamilyDebian:
-        updateCmd = "sudo apt update && sudo apt upgrade"
-        installCmd = "sudo apt install htop"
+        updateCmd = asRoot("apt update") + " && " + asRoot("apt upgrade")
+        installCmd = asRoot("apt install htop")
     case distro.FamilyRHEL:
-        updateCmd = "sudo dnf upgrade"
-        installCmd = "sudo dnf install htop"
+        updateCmd = asRoot("dnf upgrade")
+        installCmd = asRoot("dnf install htop")
     case distro.FamilyArch:
-        updateCmd = "sudo pacman -Syu"
-        installCmd = "sudo pacman -S htop"
+        updateCmd = asRoot("pacman -Syu")
+        installCmd = asRoot("pacman -S htop")
     case distro.FamilyAlpine:
-        updateCmd = "sudo apk update && sudo apk upgrade"
-        installCmd = "sudo apk add htop"
+        updateCmd = asRoot("apk update") + " && " + asRoot("apk upgrade")
+        installCmd = asRoot("apk add htop")
+    case distro.FamilySUSE:
+        if pkgmgr.IsRollingSUSE(d) {
+            updateCmd = asRoot("zypper refresh") + " && " + asRoot("zypper dist-upgrade")
+        } else {
+            updateCmd = asRoot("zypper refresh") + " && " + asRoot("zypper update")
+        }
+        installCmd = asRoot("zypper install htop")
     default:
-        updateCmd = "# update packages (unknown family, edit for your system)"
-        installCmd = "# install htop (unknown family, edit for your system)"
+        switch d.Subfamily {
+        case distro.SubfamilyVoid:
+            updateCmd = asRoot("xbps-install -Su")
+            installCmd = asRoot("xbps-install -S htop")
+        case distro.SubfamilyGentoo:
+            updateCmd = asRoot("emaint --auto sync") + " && " + asRoot("emerge --ask --update --deep --newuse @world")
+            installCmd = asRoot("emerge --ask htop")
+        case distro.SubfamilyNixOS:
+            updateCmd = asRoot("nixos-rebuild switch --upgrade")
+            installCmd = "# add htop to environment.systemPackages in /etc/nixos/configuration.nix, then: " + asRoot("nixos-rebuild switch")
+        case distro.SubfamilySolus:
+            updateCmd = asRoot("eopkg upgrade")
+            installCmd = asRoot("eopkg install htop")
+        default:
+            updateCmd = "# update packages (unknown family, edit for your system)"
+            installCmd = "# install htop (unknown family, edit for your system)"
+        }
+    }
+
+    // Image-based systems cannot change their root with the usual tools.
+    switch d.Atomic {
+    case distro.AtomicOSTree:
+        updateCmd = "rpm-ostree upgrade"
+        installCmd = "rpm-ostree install htop  # takes effect after a reboot"
+    case distro.AtomicTransactional:
+        updateCmd = asRoot("transactional-update dup")
+        installCmd = asRoot("transactional-update pkg install htop") + "  # takes effect after a reboot"
     }
 
     fmt.Println("# Quickstart native commands generated by penguinguide")
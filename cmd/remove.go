@@ -11,6 +11,8 @@ import (
     "penguinguide/internal/ui"
 )
 
+var removeSource string
+
 var removeCmd = &cobra.Command{
     Use:   "remove [packages...]",
     Short: "Remove packages",
@@ -22,6 +24,7 @@ var removeCmd = &cobra.Command{
 
 func init() {
     RootCmd.AddCommand(removeCmd)
+    removeCmd.Flags().StringVar(&removeSource, "source", "native", "where the packages came from: native, flatpak, or snap")
 }
 
 func runRemove(pkgs []string) {
@@ -34,16 +37,21 @@ func runRemove(pkgs []string) {
 
     fmt.Println(ui.Heading("Remove packages"))
     fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Printf("  %s %s\n", ui.Key("Source       :"), ui.Value(removeSource))
     fmt.Printf("  %s %v\n", ui.Key("Packages     :"), pkgs)
     fmt.Println()
 
-    mgr := pkgmgr.New(d)
+    mgr, _ := newSourceManager(d, removeSource)
     opts := pkgmgr.Options{
         DryRun:    dryRun,
         AssumeYes: assumeYes,
         Explain:   explain,
     }
 
+    if dryRun {
+        warnReverseDeps(mgr, pkgs, opts)
+    }
+
     if err := mgr.Remove(pkgs, opts); err != nil {
         fmt.Fprintln(os.Stderr)
         fmt.Fprintln(os.Stderr, ui.Error("Package removal did not complete successfully"))
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var repoCmd = &cobra.Command{
+    Use:   "repo",
+    Short: "List, add, and remove package repositories",
+    Long: `List, add, and remove the repositories your packages come from.
+
+Every repository you add can install and update software as root, so
+only add ones from projects you trust. penguinguide shows the files it
+changes and handles the repository's signing key, so packages from it
+are checked before they are installed.`,
+}
+
+func init() {
+    RootCmd.AddCommand(repoCmd)
+}
+
+// repoFiles describes where each family keeps its repository configuration.
+var repoFiles = map[distro.Family]string{
+    distro.FamilyDebian: "/etc/apt/sources.list and /etc/apt/sources.list.d/",
+    distro.FamilyRHEL:   "/etc/yum.repos.d/",
+    distro.FamilyArch:   "/etc/pacman.conf",
+    distro.FamilySUSE:   "/etc/zypp/repos.d/",
+    distro.FamilyAlpine: "/etc/apk/repositories",
+}
+
+// newRepoManager returns the manager for this system if it can manage
+// repositories, and exits with an explanation otherwise.
+func newRepoManager() (*distro.Distro, pkgmgr.RepoManager) {
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    rm, ok := newManager(d).(pkgmgr.RepoManager)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Managing repositories is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+    return d, rm
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var (
+    repoAddName       string
+    repoAddKey        string
+    repoAddSuite      string
+    repoAddComponents string
+)
+
+var repoAddCmd = &cobra.Command{
+    Use:   "add [url]",
+    Short: "Add a package repository and its signing key",
+    Long: `Add a package repository and its signing key.
+
+The url is the address of the repository, or a shorthand your
+package manager knows:
+
+  ppa:user/name           Ubuntu PPAs, through add-apt-repository
+  copr:user/project       Fedora COPR projects
+  rpmfusion-free          RPM Fusion on Fedora and Enterprise Linux
+  rpmfusion-nonfree
+
+Other repositories need a --name, which becomes the file or section
+name, and should come with a --key. On Arch the key is a fingerprint,
+everywhere else it is the address of the key file.`,
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runRepoAdd(pkgmgr.RepoSpec{
+            Name:       repoAddName,
+            URL:        args[0],
+            Key:        repoAddKey,
+            Suite:      repoAddSuite,
+            Components: repoAddComponents,
+        })
+    },
+}
+
+func init() {
+    repoAddCmd.Flags().StringVar(&repoAddName, "name", "", "name for the repository file or section")
+    repoAddCmd.Flags().StringVar(&repoAddKey, "key", "", "signing key URL, or key fingerprint on Arch")
+    repoAddCmd.Flags().StringVar(&repoAddSuite, "suite", "", "apt suite, such as the release codename (default stable)")
+    repoAddCmd.Flags().StringVar(&repoAddComponents, "components", "", "apt components (default main)")
+    repoCmd.AddCommand(repoAddCmd)
+}
+
+func runRepoAdd(spec pkgmgr.RepoSpec) {
+    d, rm := newRepoManager()
+
+    fmt.Println(ui.Heading("Add repository"))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Printf("  %s %s\n", ui.Key("Repository   :"), ui.Value(spec.URL))
+    if spec.Key != "" {
+        fmt.Printf("  %s %s\n", ui.Key("Signing key  :"), ui.Value(spec.Key))
+    }
+    fmt.Println()
+
+    printRepoTrustWarning(spec)
+
+    // With --dry-run the commands are shown and confirmed anyway, so the
+    // trust question is only asked when they would run straight away.
+    if !dryRun && !assumeYes && !confirm("Do you trust this repository") {
+        fmt.Println(ui.Muted("Nothing was changed."))
+        return
+    }
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    if err := rm.AddRepo(spec, opts); err != nil {
+        fmt.Fprintln(os.Stderr)
+        fmt.Fprintln(os.Stderr, ui.Error("Adding the repository did not complete successfully"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Success("Repository added"))
+    fmt.Println(ui.Muted("See it with penguinguide repo list, and remove it with penguinguide repo remove <id>."))
+}
+
+func printRepoTrustWarning(spec pkgmgr.RepoSpec) {
+    fmt.Println(ui.Warning("Only add repositories you trust"))
+    fmt.Println("  Packages from a repository are installed as root and updated with the")
+    fmt.Println("  rest of your system, so whoever runs it can change anything on this computer.")
+    fmt.Println("  They are not checked by your distribution.")
+    if spec.Key == "" {
+        fmt.Println()
+        fmt.Println(ui.Warning("No signing key was given"))
+        fmt.Println("  PPAs, COPR, and RPM Fusion bring their own keys. For other repositories,")
+        fmt.Println("  pass the key with --key, so packages that were tampered with are refused.")
+    }
+    fmt.Println()
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var repoRemoveCmd = &cobra.Command{
+    Use:   "remove [id]",
+    Short: "Remove a repository and the key added with it",
+    Long: `Remove a repository and the key added with it.
+
+Packages already installed from it stay installed, but they no longer
+get updates. The id is the one shown by penguinguide repo list.`,
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runRepoEdit(args[0], "remove")
+    },
+}
+
+var repoEnableCmd = &cobra.Command{
+    Use:   "enable [id]",
+    Short: "Turn a disabled repository back on",
+    Args:  cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runRepoEdit(args[0], "enable")
+    },
+}
+
+var repoDisableCmd = &cobra.Command{
+    Use:   "disable [id]",
+    Short: "Stop using a repository without deleting it",
+    Args:  cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runRepoEdit(args[0], "disable")
+    },
+}
+
+func init() {
+    repoCmd.AddCommand(repoRemoveCmd)
+    repoCmd.AddCommand(repoEnableCmd)
+    repoCmd.AddCommand(repoDisableCmd)
+}
+
+func runRepoEdit(id, action string) {
+    d, rm := newRepoManager()
+
+    titles := map[string]string{
+        "remove":  "Remove repository",
+        "enable":  "Enable repository",
+        "disable": "Disable repository",
+    }
+    fmt.Println(ui.Heading(titles[action]))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Printf("  %s %s\n", ui.Key("Repository   :"), ui.Value(id))
+    fmt.Println()
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    var err error
+    switch action {
+    case "remove":
+        err = rm.RemoveRepo(id, opts)
+    case "enable":
+        err = rm.EnableRepo(id, opts)
+    case "disable":
+        err = rm.DisableRepo(id, opts)
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr)
+        fmt.Fprintln(os.Stderr, ui.Error(titles[action]+" did not complete successfully"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Success(titles[action] + " finished"))
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var repoListJSON bool
+
+var repoListCmd = &cobra.Command{
+    Use:   "list",
+    Short: "Show configured repositories and the files they are in",
+    Args:  cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        runRepoList()
+    },
+}
+
+func init() {
+    repoListCmd.Flags().BoolVar(&repoListJSON, "json", false, "print repositories as JSON for scripts")
+    repoCmd.AddCommand(repoListCmd)
+}
+
+func runRepoList() {
+    d, rm := newRepoManager()
+
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    repos, err := rm.Repos(opts)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not list repositories"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    if repoListJSON {
+        if repos == nil {
+            repos = []pkgmgr.Repo{}
+        }
+        printJSON(repos)
+        return
+    }
+
+    fmt.Println(ui.Heading("Repositories"))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    if files, ok := repoFiles[d.Family]; ok {
+        fmt.Printf("  %s %s\n", ui.Key("Configured in:"), ui.Value(files))
+    }
+    fmt.Println()
+
+    if len(repos) == 0 {
+        fmt.Println(ui.Warning("No repositories are configured"))
+        return
+    }
+
+    idW, stateW, urlW := len("ID"), len("disabled"), len("URL")
+    for _, r := range repos {
+        idW = max(idW, len(r.ID))
+        urlW = max(urlW, len(r.URL))
+    }
+
+    fmt.Printf("  %s  %s  %s  %s\n",
+        ui.Key(ui.PadRight("ID", idW)),
+        ui.Key(ui.PadRight("STATE", stateW)),
+        ui.Key(ui.PadRight("URL", urlW)),
+        ui.Key("FILE"))
+
+    for _, r := range repos {
+        state := ui.Success(ui.PadRight("enabled", stateW))
+        if !r.Enabled {
+            state = ui.Muted(ui.PadRight("disabled", stateW))
+        }
+        fmt.Printf("  %s  %s  %s  %s\n",
+            ui.Value(ui.PadRight(r.ID, idW)),
+            state,
+            ui.PadRight(r.URL, urlW),
+            ui.Muted(r.File))
+    }
+
+    fmt.Println()
+    fmt.Println(ui.Muted("Turn one off without deleting it with: penguinguide repo disable <id>"))
+}
@@ -3,16 +3,20 @@ package cmd
 import (
     "fmt"
     "os"
+    "slices"
+    "strings"
 
     "github.com/spf13/cobra"
 
+    "penguinguide/internal/pkgmgr"
     "penguinguide/internal/ui"
 )
 
 var (
-    dryRun    bool
-    assumeYes bool
-    explain   bool
+    dryRun      bool
+    assumeYes   bool
+    explain     bool
+    elevateWith string
 )
 
 var RootCmd = &cobra.Command{
@@ -20,6 +24,12 @@ var RootCmd = &cobra.Command{
     Short: "Friendly helper for Linux newcomers",
     Long: `penguinguide explains what your system is doing
 and shows the native commands behind each action.`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        if elevateWith != "" && elevateWith != "auto" && !slices.Contains(pkgmgr.ElevationTools, elevateWith) {
+            return fmt.Errorf("--elevate must be auto or one of %s, not %q", strings.Join(pkgmgr.ElevationTools, ", "), elevateWith)
+        }
+        return nil
+    },
 }
 
 func Execute() {
@@ -33,5 +43,30 @@ func init() {
     RootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", true, "show commands before running them and ask for confirmation")
     RootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "assume yes for package operations")
     RootCmd.PersistentFlags().BoolVar(&explain, "explain", false, "explain what penguinguide is doing and show native commands")
+    RootCmd.PersistentFlags().StringVar(&elevateWith, "elevate", "", "run commands as root with sudo, doas, run0, or pkexec (default from "+pkgmgr.ElevateEnv+", or the first one installed)")
 }
 
+// defaultEnv returns the environment for real commands on this
+// terminal, getting root the way --elevate asks when it is given.
+func defaultEnv() pkgmgr.Env {
+    env := pkgmgr.DefaultEnv()
+    if elevateWith != "" {
+        env = env.WithElevation(elevateWith)
+    }
+    return env
+}
+
+// asRoot returns command prefixed the way this system runs commands as
+// root, for commands that are printed rather than run.
+func asRoot(command string) string {
+    el := defaultEnv().Elevation
+    if el == nil || el.Tool == "" {
+        return command
+    }
+    return el.Tool + " " + command
+}
+
+// confirm asks a yes or no question on the terminal.
+func confirm(question string) bool {
+    return pkgmgr.DefaultEnv().Prompter.Confirm(question)
+}
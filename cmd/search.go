@@ -1,6 +1,7 @@
 package cmd
 
 import (
+    "encoding/json"
     "fmt"
     "os"
     "strings"
@@ -12,9 +13,18 @@ import (
     "penguinguide/internal/ui"
 )
 
+var (
+    searchJSON   bool
+    searchSource string
+)
+
 var searchCmd = &cobra.Command{
     Use:   "search [query...]",
     Short: "Search for packages by name or description",
+    Long: `Search for packages by name or description.
+
+Flatpak and Snap are searched too when they are installed, and the
+SOURCE column shows where each result comes from.`,
     Args:  cobra.MinimumNArgs(1),
     Run: func(cmd *cobra.Command, args []string) {
         runSearch(args)
@@ -23,6 +33,8 @@ var searchCmd = &cobra.Command{
 
 func init() {
     RootCmd.AddCommand(searchCmd)
+    searchCmd.Flags().BoolVar(&searchJSON, "json", false, "print results as JSON for scripts")
+    searchCmd.Flags().StringVar(&searchSource, "source", "", "only search one source: native, flatpak, or snap")
 }
 
 func runSearch(args []string) {
@@ -35,12 +47,12 @@ func runSearch(args []string) {
         os.Exit(1)
     }
 
-    fmt.Println(ui.Heading("Package search"))
-    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
-    fmt.Printf("  %s %q\n", ui.Key("Query        :"), query)
-    fmt.Println()
-
-    mgr := pkgmgr.New(d)
+    if !searchJSON {
+        fmt.Println(ui.Heading("Package search"))
+        fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+        fmt.Printf("  %s %q\n", ui.Key("Query        :"), query)
+        fmt.Println()
+    }
 
     opts := pkgmgr.Options{
         DryRun:    dryRun,
@@ -48,11 +60,111 @@ func runSearch(args []string) {
         Explain:   explain,
     }
 
-    if err := mgr.Search(query, opts); err != nil {
-        fmt.Fprintln(os.Stderr)
-        fmt.Fprintln(os.Stderr, ui.Error("Package search did not complete successfully"))
-        fmt.Fprintln(os.Stderr, ui.Muted("The package manager output above has the detail"))
-        os.Exit(1)
+    var results []pkgmgr.SearchResult
+    for _, name := range sourcesToQuery(searchSource) {
+        mgr, src := newSourceManager(d, name)
+        found, err := mgr.Search(query, opts)
+        if err != nil && src == pkgmgr.SourceNative {
+            fmt.Fprintln(os.Stderr)
+            fmt.Fprintln(os.Stderr, ui.Error("Package search did not complete successfully"))
+            fmt.Fprintln(os.Stderr, "  Error:", err)
+            os.Exit(1)
+        }
+        // Flatpak and Snap are extras, so a problem with them should
+        // not hide the native results.
+        if err != nil {
+            fmt.Fprintln(os.Stderr, ui.Warning("Could not search "+name+", skipping it"))
+            fmt.Fprintln(os.Stderr, "  Error:", err)
+            continue
+        }
+        for i := range found {
+            found[i].Source = src
+        }
+        results = append(results, found...)
     }
+
+    if searchJSON {
+        if results == nil {
+            results = []pkgmgr.SearchResult{}
+        }
+        printJSON(results)
+        return
+    }
+
+    printSearchResults(results)
 }
 
+func printSearchResults(results []pkgmgr.SearchResult) {
+    if len(results) == 0 {
+        fmt.Println(ui.Warning("No packages matched your search"))
+        fmt.Println(ui.Muted("Try a shorter word or part of the program name"))
+        return
+    }
+
+    nameW, versionW, repoW, sourceW := len("NAME"), len("VERSION"), len("REPOSITORY"), len("SOURCE")
+    for _, r := range results {
+        nameW = max(nameW, len(r.Name))
+        versionW = max(versionW, len(r.Version))
+        repoW = max(repoW, len(r.Repository))
+        sourceW = max(sourceW, len(r.Source))
+    }
+
+    fmt.Printf("  %s  %s  %s  %s  %s\n",
+        ui.Key(ui.PadRight("NAME", nameW)),
+        ui.Key(ui.PadRight("VERSION", versionW)),
+        ui.Key(ui.PadRight("SOURCE", sourceW)),
+        ui.Key(ui.PadRight("REPOSITORY", repoW)),
+        ui.Key("SUMMARY"))
+
+    installed, aur := 0, 0
+    for _, r := range results {
+        name := ui.Value(ui.PadRight(r.Name, nameW))
+        if r.Installed {
+            name = ui.Success(ui.PadRight(r.Name, nameW))
+            installed++
+        }
+        repo := ui.Muted(ui.PadRight(r.Repository, repoW))
+        if r.Repository == "aur" {
+            repo = ui.Warning(ui.PadRight(r.Repository, repoW))
+            aur++
+        }
+        fmt.Printf("  %s  %s  %s  %s  %s\n",
+            name,
+            ui.PadRight(r.Version, versionW),
+            ui.PadRight(string(r.Source), sourceW),
+            repo,
+            r.Summary)
+    }
+
+    fmt.Println()
+    fmt.Printf("  %d packages found, %s\n", len(results), ui.Success(fmt.Sprintf("%d installed", installed)))
+    if aur > 0 {
+        fmt.Println()
+        fmt.Println(ui.Warning("Packages from aur are build scripts shared by other users, not checked by Arch."))
+        fmt.Println(ui.Muted("penguinguide shows each PKGBUILD for review before it installs one."))
+    }
+}
+
+// sourcesToQuery returns the sources a read only command should look
+// in: just the one given with --source, or every installed source.
+func sourcesToQuery(only string) []string {
+    if only != "" {
+        return []string{only}
+    }
+    var names []string
+    for _, src := range pkgmgr.Sources(pkgmgr.DefaultEnv()) {
+        names = append(names, string(src))
+    }
+    return names
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v interface{}) {
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetIndent("", "  ")
+    if err := enc.Encode(v); err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not write JSON output"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmap"
+    "penguinguide/internal/ui"
+)
+
+var translateCmd = &cobra.Command{
+    Use:   "translate [packages...]",
+    Short: "Show what a package is called on other distributions",
+    Long: `Show the equivalent package names for each distro family.
+
+Tutorials are often written for one distribution. This shows what the
+same packages are called elsewhere, using a built in table that you can
+extend with your own packages.json in the penguinguide config directory.`,
+    Args: cobra.MinimumNArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runTranslate(args)
+    },
+}
+
+func init() {
+    RootCmd.AddCommand(translateCmd)
+}
+
+func runTranslate(names []string) {
+    table, err := pkgmap.Load()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not load the package name table"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    // Highlighting the current family is a nice extra, so carry on without it.
+    var current distro.Family
+    if d, err := distro.Detect(); err == nil {
+        current = d.Family
+    }
+
+    fmt.Println(ui.Heading("Package names across distributions"))
+    for _, name := range names {
+        fmt.Println()
+        entry, source, ok := table.Lookup(name)
+        if !ok {
+            fmt.Printf("  %s\n", ui.Value(name))
+            fmt.Println("    " + ui.Muted("Not in the table, the name is probably the same everywhere"))
+            continue
+        }
+
+        fmt.Printf("  %s %s\n", ui.Value(name), ui.Muted("("+string(source)+" name)"))
+        for _, family := range pkgmap.Families {
+            label := ui.PadRight(string(family), 7)
+            names := ui.Muted("no equivalent")
+            if len(entry[family]) > 0 {
+                names = strings.Join(entry[family], " ")
+            }
+            if family == current {
+                fmt.Printf("  %s %s %s\n", ui.Success("*"), ui.Key(label+":"), ui.Success(names))
+                continue
+            }
+            fmt.Printf("    %s %s\n", ui.Key(label+":"), names)
+        }
+    }
+
+    if current != "" {
+        fmt.Println()
+        fmt.Println(ui.Muted("* marks the family of this system"))
+    }
+}
+
+// translateForFamily turns names written for any family into the names
+// d uses, and explains each change so nothing happens behind the
+// user's back. It returns the names unchanged if the table cannot load.
+func translateForFamily(d *distro.Distro, names []string) []string {
+    table, err := pkgmap.Load()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Warning("Could not load the package name table, using names as given"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        return names
+    }
+
+    pkgs, translations := table.TranslateAll(names, d.Family)
+
+    changed := false
+    for _, tr := range translations {
+        if !tr.Changed {
+            continue
+        }
+        if !changed {
+            fmt.Println(ui.Heading("Package name translation"))
+            changed = true
+        }
+        fmt.Printf("  %s %s -> %s\n",
+            ui.Value(tr.From),
+            ui.Muted("("+string(tr.Source)+")"),
+            ui.Success(strings.Join(tr.To, " ")))
+    }
+    if changed {
+        if explain {
+            fmt.Println("  Distributions name and split packages differently. These names")
+            fmt.Println("  were written for another family, so penguinguide uses the names")
+            fmt.Printf("  from its translation table for %s instead.\n", string(d.Family))
+            fmt.Println("  Use --no-translate to keep the names exactly as you typed them.")
+        }
+        fmt.Println()
+    }
+    return pkgs
+}
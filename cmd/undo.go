@@ -0,0 +1,113 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var undoCmd = &cobra.Command{
+    Use:   "undo [id]",
+    Short: "Reverse an install or removal from the history",
+    Long: `Reverse an install or removal recorded by penguinguide history.
+
+Undoing an install removes the same packages, and undoing a removal
+installs them again. Updates cannot be undone this way because the
+old versions are usually no longer available.`,
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runUndo(args[0])
+    },
+}
+
+func init() {
+    RootCmd.AddCommand(undoCmd)
+}
+
+func runUndo(arg string) {
+    id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("History IDs are numbers, see penguinguide history"))
+        os.Exit(1)
+    }
+
+    store, err := historyStore()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not find the history file"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    entry, err := store.Get(id)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not find that history entry"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    if entry.Family != string(d.Family) {
+        fmt.Fprintln(os.Stderr, ui.Error("This entry was recorded on a "+entry.Family+" system, but this one is "+string(d.Family)))
+        os.Exit(1)
+    }
+
+    var inverse string
+    switch entry.Action {
+    case "install":
+        inverse = "remove"
+    case "remove":
+        inverse = "install"
+    default:
+        fmt.Fprintln(os.Stderr, ui.Error(fmt.Sprintf("Only installs and removals can be undone, entry #%d is %s", entry.ID, orUnknown(entry.Action))))
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Heading("Undo history entry"))
+    fmt.Printf("  %s #%d %s %s\n", ui.Key("Entry        :"), entry.ID, entry.Action, strings.Join(entry.Packages, " "))
+    if entry.Source != "" {
+        fmt.Printf("  %s %s\n", ui.Key("Source       :"), entry.Source)
+    }
+    fmt.Printf("  %s %s\n", ui.Key("Original     :"), entry.Command)
+    fmt.Printf("  %s %s %s\n", ui.Key("Undo with    :"), inverse, strings.Join(entry.Packages, " "))
+    if !entry.Succeeded() {
+        fmt.Println()
+        fmt.Println(ui.Warning("The original command failed, so some of these packages may not have changed."))
+    }
+    fmt.Println()
+
+    source := entry.Source
+    if source == "" {
+        source = string(pkgmgr.SourceNative)
+    }
+    mgr, _ := newSourceManager(d, source)
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    if inverse == "remove" {
+        err = mgr.Remove(entry.Packages, opts)
+    } else {
+        err = mgr.Install(entry.Packages, opts)
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr)
+        fmt.Fprintln(os.Stderr, ui.Error("Undo did not complete successfully"))
+        fmt.Fprintln(os.Stderr, ui.Muted("The package manager output above has the detail"))
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Success("Undo finished"))
+}
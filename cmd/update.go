@@ -11,16 +11,28 @@ import (
     "penguinguide/internal/ui"
 )
 
+var updateCheck bool
+
 var updateCmd = &cobra.Command{
     Use:   "update",
     Short: "Update installed packages",
+    Long: `Update installed packages.
+
+With --check, penguinguide refreshes the package lists first and shows
+which packages would be upgraded, so you can decide before anything
+is installed.`,
     Run: func(cmd *cobra.Command, args []string) {
+        if updateCheck {
+            runUpdateCheck()
+            return
+        }
         runUpdate()
     },
 }
 
 func init() {
     RootCmd.AddCommand(updateCmd)
+    updateCmd.Flags().BoolVar(&updateCheck, "check", false, "list pending upgrades before deciding to install them")
 }
 
 func runUpdate() {
@@ -34,13 +46,80 @@ func runUpdate() {
     fmt.Println(ui.Heading("Update packages"))
     fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
 
-    mgr := pkgmgr.New(d)
+    mgr := newManager(d)
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+
+    if err := mgr.UpdateAll(opts); err != nil {
+        fmt.Fprintln(os.Stderr)
+        fmt.Fprintln(os.Stderr, ui.Error("Update did not complete successfully"))
+        fmt.Fprintln(os.Stderr, ui.Muted("The package manager output above has the detail"))
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Success("Update finished"))
+}
+
+func runUpdateCheck() {
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    fmt.Println(ui.Heading("Check for updates"))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Println()
+
+    mgr := newManager(d)
+    checker, ok := mgr.(pkgmgr.UpdateChecker)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Checking for updates is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+
     opts := pkgmgr.Options{
         DryRun:    dryRun,
         AssumeYes: assumeYes,
         Explain:   explain,
     }
 
+    upgrades, err := checker.CheckUpdates(opts)
+    if err != nil {
+        fmt.Fprintln(os.Stderr)
+        fmt.Fprintln(os.Stderr, ui.Error("Could not check for updates"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+
+    if holder, ok := mgr.(pkgmgr.Holder); ok {
+        // Holds only add a note, so carry on without them.
+        if held, err := holder.Holds(opts); err == nil {
+            pkgmgr.MarkHeld(upgrades, held)
+        }
+    }
+
+    fmt.Println()
+    if len(upgrades) == 0 {
+        fmt.Println(ui.Success("Everything is up to date"))
+        return
+    }
+
+    printUpgrades(upgrades)
+    fmt.Println()
+
+    if !assumeYes && !confirm("Do you want to install these updates now") {
+        fmt.Println(ui.Muted("No changes made. Run penguinguide update when you are ready."))
+        return
+    }
+
+    // The user has already reviewed the list, so skip the second dry run prompt.
+    opts.DryRun = false
+    fmt.Println()
     if err := mgr.UpdateAll(opts); err != nil {
         fmt.Fprintln(os.Stderr)
         fmt.Fprintln(os.Stderr, ui.Error("Update did not complete successfully"))
@@ -51,3 +130,60 @@ func runUpdate() {
     fmt.Println(ui.Success("Update finished"))
 }
 
+func printUpgrades(upgrades []pkgmgr.Upgrade) {
+    nameW, oldW, newW := len("NAME"), len("INSTALLED"), len("NEW")
+    var total int64
+    security, held := 0, 0
+    for _, u := range upgrades {
+        nameW = max(nameW, len(u.Name))
+        oldW = max(oldW, len(orUnknown(u.OldVersion)))
+        newW = max(newW, len(u.NewVersion))
+        total += u.DownloadSize
+        if u.Security {
+            security++
+        }
+        if u.Held {
+            held++
+        }
+    }
+
+    fmt.Printf("  %s  %s    %s  %s  %s\n",
+        ui.Key(ui.PadRight("NAME", nameW)),
+        ui.Key(ui.PadRight("INSTALLED", oldW)),
+        ui.Key(ui.PadRight("NEW", newW)),
+        ui.Key(ui.PadRight("SIZE", 10)),
+        ui.Key("NOTES"))
+
+    for _, u := range upgrades {
+        size := ""
+        if u.DownloadSize > 0 {
+            size = pkgmgr.FormatSize(u.DownloadSize)
+        }
+        notes := ui.Muted(u.Repository)
+        if u.Security {
+            notes = ui.Warning("security") + " " + notes
+        }
+        if u.Held {
+            notes = ui.Info("held") + " " + notes
+        }
+        fmt.Printf("  %s  %s -> %s  %s  %s\n",
+            ui.Value(ui.PadRight(u.Name, nameW)),
+            ui.Muted(ui.PadRight(orUnknown(u.OldVersion), oldW)),
+            ui.Success(ui.PadRight(u.NewVersion, newW)),
+            ui.PadRight(size, 10),
+            notes)
+    }
+
+    fmt.Println()
+    fmt.Printf("  %d upgrades pending", len(upgrades))
+    if total > 0 {
+        fmt.Printf(", about %s to download", pkgmgr.FormatSize(total))
+    }
+    fmt.Println()
+    if security > 0 {
+        fmt.Println("  " + ui.Warning(fmt.Sprintf("%d of them fix security problems, so it is a good idea to install them soon", security)))
+    }
+    if held > 0 {
+        fmt.Println("  " + ui.Info(fmt.Sprintf("%d of them are held and will be skipped. Use penguinguide unhold to allow them", held)))
+    }
+}
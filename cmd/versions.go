@@ -0,0 +1,114 @@
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/ui"
+)
+
+var versionsJSON bool
+
+var versionsCmd = &cobra.Command{
+    Use:   "versions [package]",
+    Short: "List the versions of a package you can install",
+    Long: `List the versions of a package you can install, newest first.
+
+This shows the installed version, the versions in your repositories,
+and, where the package manager keeps one, versions in the package cache.
+Install one with penguinguide install name@version, or go back to an
+older one with penguinguide downgrade.`,
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        runVersions(args[0])
+    },
+}
+
+func init() {
+    versionsCmd.Flags().BoolVar(&versionsJSON, "json", false, "print versions as JSON for scripts")
+    RootCmd.AddCommand(versionsCmd)
+}
+
+// newVersioner returns the manager for this system if it can list
+// versions, and exits with an explanation otherwise.
+func newVersioner() (*distro.Distro, pkgmgr.Versioner) {
+    d, err := distro.Detect()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not detect distribution"))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    versioner, ok := newManager(d).(pkgmgr.Versioner)
+    if !ok {
+        fmt.Fprintln(os.Stderr, ui.Error("Choosing package versions is not supported for distro family "+string(d.Family)))
+        os.Exit(1)
+    }
+    return d, versioner
+}
+
+// packageVersions lists the versions of name, and exits with a
+// friendly message when the package is unknown.
+func packageVersions(versioner pkgmgr.Versioner, name string) []pkgmgr.PackageVersion {
+    opts := pkgmgr.Options{
+        DryRun:    dryRun,
+        AssumeYes: assumeYes,
+        Explain:   explain,
+    }
+    versions, err := versioner.Versions(name, opts)
+    if errors.Is(err, pkgmgr.ErrPackageNotFound) {
+        fmt.Fprintln(os.Stderr, ui.Error("No package named "+name+" was found"))
+        fmt.Fprintln(os.Stderr, ui.Muted("Check the spelling, or try penguinguide search "+name))
+        os.Exit(1)
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr, ui.Error("Could not list versions of "+name))
+        fmt.Fprintln(os.Stderr, "  Error:", err)
+        os.Exit(1)
+    }
+    return versions
+}
+
+func runVersions(name string) {
+    d, versioner := newVersioner()
+    versions := packageVersions(versioner, name)
+
+    if versionsJSON {
+        printJSON(versions)
+        return
+    }
+
+    fmt.Println(ui.Heading("Versions of " + name))
+    fmt.Printf("  %s %s\n", ui.Key("Distro family:"), ui.Value(string(d.Family)))
+    fmt.Println()
+
+    versionW, repoW := len("VERSION"), len("REPOSITORY")
+    for _, v := range versions {
+        versionW = max(versionW, len(v.Version))
+        repoW = max(repoW, len(v.Repository))
+    }
+    fmt.Printf("  %s  %s  %s\n",
+        ui.Key(ui.PadRight("VERSION", versionW)),
+        ui.Key(ui.PadRight("REPOSITORY", repoW)),
+        ui.Key("STATUS"))
+
+    for _, v := range versions {
+        version := ui.Value(ui.PadRight(v.Version, versionW))
+        status := ""
+        switch {
+        case v.Installed:
+            version = ui.Success(ui.PadRight(v.Version, versionW))
+            status = ui.Success("installed")
+        case v.Cached:
+            status = ui.Muted("in package cache")
+        }
+        fmt.Printf("  %s  %s  %s\n", version, ui.Muted(ui.PadRight(v.Repository, repoW)), status)
+    }
+
+    fmt.Println()
+    fmt.Println(ui.Muted("Install a version with: penguinguide install " + name + "@<version>"))
+}
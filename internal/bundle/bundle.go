@@ -0,0 +1,115 @@
+// Package bundle reads package bundle files, which describe the
+// packages a machine should have, such as a team's onboarding list.
+//
+// A bundle lists packages by the names people know them by, and can
+// add or drop packages for one distro family or subfamily:
+//
+//	packages:
+//	  - git
+//	  - build-essential
+//	families:
+//	  arch:
+//	    add: [github-cli]
+//	  debian:
+//	    add: [gh]
+//	    remove: [build-essential]
+//	  void:
+//	    add: [github-cli]
+package bundle
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "os"
+
+    "go.yaml.in/yaml/v3"
+
+    "penguinguide/internal/distro"
+)
+
+// Override changes the package list for one family or subfamily.
+type Override struct {
+    Add    []string `yaml:"add"`
+    Remove []string `yaml:"remove"`
+}
+
+// Bundle is a parsed bundle file.
+type Bundle struct {
+    Packages []string            `yaml:"packages"`
+    Families map[string]Override `yaml:"families"`
+}
+
+// Parse reads a bundle. Unknown keys and families are errors, so a
+// typo does not silently leave packages out.
+func Parse(r io.Reader) (*Bundle, error) {
+    dec := yaml.NewDecoder(r)
+    dec.KnownFields(true)
+
+    var b Bundle
+    if err := dec.Decode(&b); err != nil {
+        if errors.Is(err, io.EOF) {
+            return &b, nil
+        }
+        return nil, err
+    }
+
+    for family := range b.Families {
+        if !distro.KnownFamily(distro.Family(family)) && !distro.KnownSubfamily(distro.Subfamily(family)) {
+            return nil, fmt.Errorf("unknown family %q under families", family)
+        }
+    }
+    return &b, nil
+}
+
+// Load reads the bundle file at path.
+func Load(path string) (*Bundle, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    b, err := Parse(f)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    return b, nil
+}
+
+// For returns the packages wanted on d, in file order and without
+// duplicates: the common list without the override's removals, followed
+// by the override's additions. The override for d's subfamily is used
+// when the bundle has one, and the one for its family otherwise.
+func (b *Bundle) For(d *distro.Distro) []string {
+    override, ok := b.Families[string(d.Subfamily)]
+    if !ok {
+        override = b.Families[string(d.Family)]
+    }
+    removed := make(map[string]bool, len(override.Remove))
+    for _, name := range override.Remove {
+        removed[name] = true
+    }
+
+    var pkgs []string
+    seen := make(map[string]bool)
+    for _, name := range append(append([]string(nil), b.Packages...), override.Add...) {
+        if name == "" || removed[name] || seen[name] {
+            continue
+        }
+        seen[name] = true
+        pkgs = append(pkgs, name)
+    }
+    return pkgs
+}
+
+// Missing returns the wanted packages that are not in installed.
+func Missing(wanted []string, installed map[string]bool) []string {
+    var missing []string
+    for _, name := range wanted {
+        if !installed[name] {
+            missing = append(missing, name)
+        }
+    }
+    return missing
+}
@@ -0,0 +1,83 @@
+package bundle
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+
+    "penguinguide/internal/distro"
+)
+
+const teamYAML = `
+packages:
+  - git
+  - build-essential
+  - curl
+  - git
+families:
+  arch:
+    add: [github-cli]
+  debian:
+    add: [gh]
+    remove: [build-essential]
+  void:
+    add: [github]
+`
+
+// This test checks that family and subfamily overrides add and drop
+// packages.
+func TestFor(t *testing.T) {
+    b, err := Parse(strings.NewReader(teamYAML))
+    if err != nil {
+        t.Fatalf("Parse error = %v", err)
+    }
+    tests := []struct {
+        d    distro.Distro
+        want []string
+    }{
+        {distro.Distro{Family: distro.FamilyArch}, []string{"git", "build-essential", "curl", "github-cli"}},
+        {distro.Distro{Family: distro.FamilyDebian}, []string{"git", "curl", "gh"}},
+        {distro.Distro{Family: distro.FamilyAlpine}, []string{"git", "build-essential", "curl"}},
+        {distro.Distro{Family: distro.FamilyOther, Subfamily: distro.SubfamilyVoid}, []string{"git", "build-essential", "curl", "github"}},
+        {distro.Distro{Family: distro.FamilyOther, Subfamily: distro.SubfamilyGentoo}, []string{"git", "build-essential", "curl"}},
+    }
+    for _, tt := range tests {
+        if got := b.For(&tt.d); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("For(%s/%s) = %v, want %v", tt.d.Family, tt.d.Subfamily, got, tt.want)
+        }
+    }
+}
+
+// This test checks that typos are reported instead of ignored.
+func TestParseRejectsTypos(t *testing.T) {
+    for _, in := range []string{
+        "pakages: [git]\n",
+        "families:\n  ubuntu:\n    add: [gh]\n",
+        "families:\n  arch:\n    adds: [gh]\n",
+        "families:\n  voidlinux:\n    add: [gh]\n",
+    } {
+        if _, err := Parse(strings.NewReader(in)); err == nil {
+            t.Errorf("Parse(%q) did not fail", in)
+        }
+    }
+}
+
+func TestLoadEmptyFile(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "empty.yaml")
+    if err := os.WriteFile(path, nil, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    b, err := Load(path)
+    if err != nil || len(b.For(&distro.Distro{Family: distro.FamilyArch})) != 0 {
+        t.Fatalf("Load(empty) = %+v, %v, want an empty bundle", b, err)
+    }
+}
+
+func TestMissing(t *testing.T) {
+    got := Missing([]string{"git", "curl", "gh"}, map[string]bool{"curl": true})
+    if want := []string{"git", "gh"}; !reflect.DeepEqual(got, want) {
+        t.Fatalf("Missing() = %v, want %v", got, want)
+    }
+}
@@ -0,0 +1,87 @@
+package distro
+
+import (
+    "io/fs"
+    "strings"
+)
+
+// Atomic says how an image-based system, whose root filesystem is
+// read-only, takes new packages. It is empty on ordinary systems.
+type Atomic string
+
+const (
+    // AtomicOSTree is Fedora Silverblue, Kinoite, and other systems
+    // that layer packages onto an OSTree image with rpm-ostree.
+    AtomicOSTree Atomic = "rpm-ostree"
+
+    // AtomicTransactional is openSUSE MicroOS, Aeon, Kalpa, and SLE
+    // Micro, which install packages into a new Btrfs snapshot with
+    // transactional-update.
+    AtomicTransactional Atomic = "transactional-update"
+)
+
+// ostreeVariants are Fedora VARIANT_IDs that are always OSTree images.
+// They also tell an image apart when it is not the running system,
+// where run/ostree-booted does not exist.
+var ostreeVariants = []string{"silverblue", "kinoite", "sericea", "onyx", "iot", "coreos"}
+
+// transactionalIDs are os-release IDs that always use
+// transactional-update.
+var transactionalIDs = []string{"opensuse-microos", "opensuse-aeon", "opensuse-kalpa", "sl-micro", "sle-micro"}
+
+// detectAtomic looks at the system whose root directory is fsys. Paths
+// are relative to it, the way fs.FS wants them.
+func detectAtomic(fsys fs.FS, d *Distro) Atomic {
+    // rpm-ostree itself checks this file to know it booted a deployment.
+    // Endless OS, GNOME OS, and other OSTree systems have it too, but no
+    // rpm-ostree, so it only counts for rpm-based systems.
+    if fileExists(fsys, "run/ostree-booted") && (d.Family == FamilyRHEL || fileExists(fsys, "usr/bin/rpm-ostree")) {
+        return AtomicOSTree
+    }
+    if d.Family == FamilyRHEL {
+        variant := strings.ToLower(d.VariantID)
+        for _, v := range ostreeVariants {
+            if variant == v {
+                return AtomicOSTree
+            }
+        }
+    }
+
+    id := strings.ToLower(d.ID)
+    for _, t := range transactionalIDs {
+        if id == t {
+            return AtomicTransactional
+        }
+    }
+    // transactional-update can be installed on a normal Tumbleweed too,
+    // so it only counts when the root filesystem is read-only.
+    if d.Family == FamilySUSE && fileExists(fsys, "usr/sbin/transactional-update") && rootReadOnly(fsys) {
+        return AtomicTransactional
+    }
+    return ""
+}
+
+// rootReadOnly reports whether / is mounted read-only.
+func rootReadOnly(fsys fs.FS) bool {
+    data, err := fs.ReadFile(fsys, "proc/mounts")
+    if err != nil {
+        return false
+    }
+    for _, line := range strings.Split(string(data), "\n") {
+        fields := strings.Fields(line)
+        if len(fields) < 4 || fields[1] != "/" {
+            continue
+        }
+        for _, opt := range strings.Split(fields[3], ",") {
+            if opt == "ro" {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+func fileExists(fsys fs.FS, name string) bool {
+    _, err := fs.Stat(fsys, name)
+    return err == nil
+}
@@ -2,8 +2,12 @@ package distro
 
 import (
     "bufio"
+    "bytes"
     "errors"
+    "fmt"
+    "io/fs"
     "os"
+    "path"
     "strings"
 )
 
@@ -18,50 +22,78 @@ const (
     FamilyOther  Family = "other"
 )
 
+// Subfamily tells apart distributions in FamilyOther that have a
+// package manager of their own, such as Void with xbps. It is empty
+// for the other families and for distributions penguinguide does not
+// know.
+type Subfamily string
+
+const (
+    SubfamilyVoid   Subfamily = "void"
+    SubfamilyGentoo Subfamily = "gentoo"
+    SubfamilyNixOS  Subfamily = "nixos"
+    SubfamilySolus  Subfamily = "solus"
+)
+
 type Distro struct {
-    ID         string
-    IDLike     []string
-    Name       string
-    PrettyName string
-    VersionID  string
-    Family     Family
+    ID              string
+    IDLike          []string
+    Name            string
+    PrettyName      string
+    VersionID       string
+    VersionCodename string
+    VariantID       string
+    BuildID         string
+    Family          Family
+    Subfamily       Subfamily
+    Atomic          Atomic
+
+    // Match is the rule that chose Family, or nil when none did.
+    Match *Match
 }
 
-// Detect reads /etc/os-release and returns a normalized Distro description.
+// osReleasePaths are where os-release(5) says to look, relative to the
+// root and in order. /usr/lib/os-release is the vendor's copy, which
+// images and containers sometimes ship without the /etc link.
+var osReleasePaths = []string{"etc/os-release", "usr/lib/os-release"}
+
+// Detect reads /etc/os-release, or /usr/lib/os-release when that is
+// missing, and returns a normalized Distro description.
 func Detect() (*Distro, error) {
-    f, err := os.Open("/etc/os-release")
+    return DetectRoot("/")
+}
+
+// DetectRoot describes the system installed under root, such as a
+// mounted disk image or a container's filesystem.
+func DetectRoot(root string) (*Distro, error) {
+    return DetectFS(os.DirFS(root))
+}
+
+// DetectFS describes the system whose root directory is fsys. Absolute
+// symlinks, such as etc/os-release pointing to /usr/lib/os-release,
+// are followed inside fsys rather than on the running system. The
+// family comes from the rules LoadRules returns.
+func DetectFS(fsys fs.FS) (*Distro, error) {
+    rules, err := LoadRules()
     if err != nil {
         return nil, err
     }
-    defer f.Close()
-
-    values := make(map[string]string)
-
-    scanner := bufio.NewScanner(f)
-    for scanner.Scan() {
-        line := strings.TrimSpace(scanner.Text())
-        if line == "" || strings.HasPrefix(line, "#") {
-            continue
-        }
-        parts := strings.SplitN(line, "=", 2)
-        if len(parts) != 2 {
-            continue
-        }
-        key := strings.ToUpper(strings.TrimSpace(parts[0]))
-        val := strings.TrimSpace(parts[1])
-
-        // remove surrounding quotes if present
-        val = strings.Trim(val, `"'`)
+    return detect(fsys, rules)
+}
 
-        values[key] = val
+func detect(fsys fs.FS, rules *Rules) (*Distro, error) {
+    data, name, err := readOSRelease(fsys)
+    if err != nil {
+        return nil, err
     }
-    if err := scanner.Err(); err != nil {
+    values, err := parseOSRelease(data)
+    if err != nil {
         return nil, err
     }
 
     id := values["ID"]
     if id == "" {
-        return nil, errors.New("could not find ID in /etc/os-release")
+        return nil, fmt.Errorf("could not find ID in /%s", name)
     }
 
     idLikeRaw := values["ID_LIKE"]
@@ -77,60 +109,93 @@ func Detect() (*Distro, error) {
     }
 
     d := &Distro{
-        ID:         id,
-        IDLike:     idLike,
-        Name:       values["NAME"],
-        PrettyName: values["PRETTY_NAME"],
-        VersionID:  values["VERSION_ID"],
+        ID:              id,
+        IDLike:          idLike,
+        Name:            values["NAME"],
+        PrettyName:      values["PRETTY_NAME"],
+        VersionID:       values["VERSION_ID"],
+        VersionCodename: values["VERSION_CODENAME"],
+        VariantID:       values["VARIANT_ID"],
+        BuildID:         values["BUILD_ID"],
     }
-    d.Family = classifyFamily(d)
+    d.Family = FamilyOther
+    if m := rules.Classify(d); m != nil {
+        d.Match = m
+        d.Family = m.Rule.Family
+        d.Subfamily = m.Rule.Subfamily
+    }
+    d.Atomic = detectAtomic(fsys, d)
 
     return d, nil
 }
 
-func classifyFamily(d *Distro) Family {
-    id := strings.ToLower(d.ID)
-    like := make([]string, 0, len(d.IDLike))
-    for _, v := range d.IDLike {
-        like = append(like, strings.ToLower(v))
+// readOSRelease returns the first os-release file found in fsys and
+// its path.
+func readOSRelease(fsys fs.FS) ([]byte, string, error) {
+    for _, name := range osReleasePaths {
+        data, err := readFileInRoot(fsys, name)
+        if err == nil {
+            return data, name, nil
+        }
+        if !errors.Is(err, fs.ErrNotExist) {
+            return nil, name, err
+        }
     }
+    return nil, "", fmt.Errorf("no os-release file in /%s: %w", strings.Join(osReleasePaths, " or /"), fs.ErrNotExist)
+}
 
-    hasLike := func(target string) bool {
-        for _, v := range like {
-            if v == target {
-                return true
-            }
+// readFileInRoot reads name from fsys, resolving symlinks itself so
+// that absolute targets stay inside fsys. os.DirFS would otherwise
+// follow them on the running system.
+func readFileInRoot(fsys fs.FS, name string) ([]byte, error) {
+    links, ok := fsys.(fs.ReadLinkFS)
+    if !ok {
+        return fs.ReadFile(fsys, name)
+    }
+    for range 8 {
+        target, err := links.ReadLink(name)
+        if err != nil {
+            // Not a symlink, or one fsys cannot read: open it as is.
+            return fs.ReadFile(fsys, name)
+        }
+        if path.IsAbs(target) {
+            name = path.Clean(target[1:])
+        } else {
+            name = path.Join(path.Dir(name), target)
+        }
+        if !fs.ValidPath(name) {
+            return nil, &fs.PathError{Op: "open", Path: target, Err: fs.ErrNotExist}
         }
-        return false
     }
+    return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("too many levels of symbolic links")}
+}
 
-    // Debian family
-    if id == "debian" || id == "ubuntu" || id == "linuxmint" || id == "raspbian" ||
-        hasLike("debian") {
-        return FamilyDebian
-    }
+// parseOSRelease reads the KEY=value lines of an os-release file.
+// Keys are upper-cased and surrounding quotes are removed.
+func parseOSRelease(data []byte) (map[string]string, error) {
+    values := make(map[string]string)
 
-    // RHEL family
-    if id == "rhel" || id == "centos" || id == "rocky" || id == "almalinux" || id == "fedora" ||
-        hasLike("rhel") || hasLike("fedora") {
-        return FamilyRHEL
-    }
+    scanner := bufio.NewScanner(bytes.NewReader(data))
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        parts := strings.SplitN(line, "=", 2)
+        if len(parts) != 2 {
+            continue
+        }
+        key := strings.ToUpper(strings.TrimSpace(parts[0]))
+        val := strings.TrimSpace(parts[1])
 
-    // Arch family
-    if id == "arch" || id == "manjaro" || hasLike("arch") {
-        return FamilyArch
-    }
+        // remove surrounding quotes if present
+        val = strings.Trim(val, `"'`)
 
-    // SUSE family
-    if strings.Contains(id, "suse") || hasLike("suse") {
-        return FamilySUSE
+        values[key] = val
     }
-
-    // Alpine
-    if id == "alpine" {
-        return FamilyAlpine
+    if err := scanner.Err(); err != nil {
+        return nil, err
     }
-
-    return FamilyOther
+    return values, nil
 }
 
@@ -1,6 +1,14 @@
 package distro
 
-import "testing"
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
 
 // This is a minimal sanity test for Detect.
 // It does not care which distro you are running on,
@@ -17,3 +25,273 @@ func TestDetectSetsFamily(t *testing.T) {
 		t.Fatalf("expected Family to be set, got empty. Info: %+v", info)
 	}
 }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		id        string
+		idLike    []string
+		family    Family
+		subfamily Subfamily
+		field     string
+	}{
+		{"pop", []string{"ubuntu", "debian"}, FamilyDebian, "", "ID"},
+		{"zorin", []string{"ubuntu"}, FamilyDebian, "", "ID"},
+		{"some-ubuntu-spin", []string{"ubuntu"}, FamilyDebian, "", "ID_LIKE"},
+		{"nobara", nil, FamilyRHEL, "", "ID"},
+		{"endeavouros", nil, FamilyArch, "", "ID"},
+		{"opensuse-slowroll", nil, FamilySUSE, "", "ID"},
+		{"postmarketos", nil, FamilyAlpine, "", "ID"},
+		{"void", nil, FamilyOther, SubfamilyVoid, "ID"},
+		{"calculate", []string{"gentoo"}, FamilyOther, SubfamilyGentoo, "ID_LIKE"},
+		{"NixOS", nil, FamilyOther, SubfamilyNixOS, "ID"},
+		{"solus", nil, FamilyOther, SubfamilySolus, "ID"},
+	}
+	rules := BuiltinRules()
+	for _, tt := range tests {
+		m := rules.Classify(&Distro{ID: tt.id, IDLike: tt.idLike})
+		if m == nil {
+			t.Errorf("Classify(%q) matched no rule", tt.id)
+			continue
+		}
+		if m.Rule.Family != tt.family || m.Rule.Subfamily != tt.subfamily || m.Field != tt.field {
+			t.Errorf("Classify(%q) = %q, %q by %s, want %q, %q by %s",
+				tt.id, m.Rule.Family, m.Rule.Subfamily, m.Field, tt.family, tt.subfamily, tt.field)
+		}
+		if m.Rule.Source != BuiltinSource {
+			t.Errorf("Classify(%q) rule source = %q, want %q", tt.id, m.Rule.Source, BuiltinSource)
+		}
+	}
+	if m := rules.Classify(&Distro{ID: "slackware"}); m != nil {
+		t.Errorf("Classify(slackware) matched %q, want no rule", m.Rule.Name)
+	}
+}
+
+// This test checks that user rules come before the built in ones and
+// that a broken rules file is reported with its path.
+func TestLoadUserRules(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, "penguinguide", "families.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	custom := `[{"name": "Work image", "id": ["acme-*"], "family": "rhel"},
+		{"name": "Ubuntu-based Arch", "id": ["zorin"], "family": "arch"}]`
+	if err := os.WriteFile(path, []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		t.Fatalf("LoadRules error = %v", err)
+	}
+	m := rules.Classify(&Distro{ID: "acme-desktop", IDLike: []string{"debian"}})
+	if m == nil || m.Rule.Family != FamilyRHEL || m.Rule.Source != path {
+		t.Errorf("Classify(acme-desktop) = %+v, want the user rule", m)
+	}
+	if m := rules.Classify(&Distro{ID: "zorin"}); m == nil || m.Rule.Family != FamilyArch {
+		t.Errorf("user rule should win over the built in one, got %+v", m)
+	}
+	if m := rules.Classify(&Distro{ID: "arch"}); m == nil || m.Rule.Source != BuiltinSource {
+		t.Errorf("built in rules missing after LoadRules, got %+v", m)
+	}
+
+	broken := []string{
+		`[{"name": "No IDs", "family": "arch"}]`,
+		`[{"name": "Typo", "id": ["x"], "family": "arhc"}]`,
+		`[{"name": "Bad subfamily", "id": ["x"], "family": "arch", "subfamily": "void"}]`,
+		`[{"id": ["x"], "family": "arch"}]`,
+		`[{"name": "Bad pattern", "id": ["x["], "family": "arch"}]`,
+	}
+	for _, b := range broken {
+		if err := os.WriteFile(path, []byte(b), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadRules(); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("LoadRules with %s returned %v, want an error naming the file", b, err)
+		}
+	}
+}
+
+func TestDetectAtomic(t *testing.T) {
+	tests := []struct {
+		name  string
+		d     Distro
+		files fstest.MapFS
+		want  Atomic
+	}{
+		{"silverblue", Distro{ID: "fedora", Family: FamilyRHEL}, fstest.MapFS{"run/ostree-booted": {}}, AtomicOSTree},
+		{"workstation", Distro{ID: "fedora", Family: FamilyRHEL}, fstest.MapFS{}, ""},
+		{"endless", Distro{ID: "endless", Family: FamilyDebian}, fstest.MapFS{"run/ostree-booted": {}}, ""},
+		{"rpm-ostree elsewhere", Distro{ID: "custom", Family: FamilyOther}, fstest.MapFS{
+			"run/ostree-booted":  {},
+			"usr/bin/rpm-ostree": {},
+		}, AtomicOSTree},
+		{"aeon", Distro{ID: "opensuse-aeon", Family: FamilySUSE}, fstest.MapFS{}, AtomicTransactional},
+		{"read-only tumbleweed", Distro{ID: "opensuse-tumbleweed", Family: FamilySUSE}, fstest.MapFS{
+			"usr/sbin/transactional-update": {},
+			"proc/mounts":                   {Data: []byte("/dev/vda3 / btrfs ro,relatime 0 0\n")},
+		}, AtomicTransactional},
+		{"tumbleweed", Distro{ID: "opensuse-tumbleweed", Family: FamilySUSE}, fstest.MapFS{
+			"usr/sbin/transactional-update": {},
+			"proc/mounts":                   {Data: []byte("/dev/vda3 / btrfs rw,relatime 0 0\n")},
+		}, ""},
+	}
+	for _, tt := range tests {
+		if got := detectAtomic(tt.files, &tt.d); got != tt.want {
+			t.Errorf("%s: detectAtomic() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestDetectOSReleaseCorpus runs the built in rules over os-release files copied
+// from real installs, kept in testdata/os-release.
+func TestDetectOSReleaseCorpus(t *testing.T) {
+	tests := []struct {
+		file      string
+		id        string
+		versionID string
+		family    Family
+		subfamily Subfamily
+		atomic    Atomic
+	}{
+		{"almalinux-9.4", "almalinux", "9.4", FamilyRHEL, "", ""},
+		{"alpine-3.20", "alpine", "3.20.2", FamilyAlpine, "", ""},
+		{"amzn-2023", "amzn", "2023", FamilyRHEL, "", ""},
+		{"arch", "arch", "", FamilyArch, "", ""},
+		{"centos-stream-9", "centos", "9", FamilyRHEL, "", ""},
+		{"clear-linux", "clear-linux-os", "41780", FamilyOther, "", ""},
+		{"debian-12", "debian", "12", FamilyDebian, "", ""},
+		{"elementary-7.1", "elementary", "7.1", FamilyDebian, "", ""},
+		{"endeavouros", "endeavouros", "", FamilyArch, "", ""},
+		{"fedora-40", "fedora", "40", FamilyRHEL, "", ""},
+		{"fedora-silverblue-40", "fedora", "40", FamilyRHEL, "", AtomicOSTree},
+		{"garuda", "garuda", "", FamilyArch, "", ""},
+		{"gentoo", "gentoo", "2.15", FamilyOther, SubfamilyGentoo, ""},
+		{"kali-2024.2", "kali", "2024.2", FamilyDebian, "", ""},
+		{"linuxmint-21.3", "linuxmint", "21.3", FamilyDebian, "", ""},
+		{"manjaro", "manjaro", "", FamilyArch, "", ""},
+		{"nixos-24.05", "nixos", "24.05", FamilyOther, SubfamilyNixOS, ""},
+		{"nobara-40", "nobara", "40", FamilyRHEL, "", ""},
+		{"ol-9.4", "ol", "9.4", FamilyRHEL, "", ""},
+		{"opensuse-leap-15.6", "opensuse-leap", "15.6", FamilySUSE, "", ""},
+		{"opensuse-microos", "opensuse-microos", "20240710", FamilySUSE, "", AtomicTransactional},
+		{"opensuse-tumbleweed", "opensuse-tumbleweed", "20240710", FamilySUSE, "", ""},
+		{"pop-22.04", "pop", "22.04", FamilyDebian, "", ""},
+		{"postmarketos-v24.06", "postmarketos", "v24.06", FamilyAlpine, "", ""},
+		{"raspbian-12", "raspbian", "12", FamilyDebian, "", ""},
+		{"rhel-9.4", "rhel", "9.4", FamilyRHEL, "", ""},
+		{"rocky-9.4", "rocky", "9.4", FamilyRHEL, "", ""},
+		{"slackware-15.0", "slackware", "15.0", FamilyOther, "", ""},
+		{"sles-15.6", "sles", "15.6", FamilySUSE, "", ""},
+		{"solus-4.5", "solus", "4.5", FamilyOther, SubfamilySolus, ""},
+		{"ubuntu-24.04", "ubuntu", "24.04", FamilyDebian, "", ""},
+		{"void", "void", "", FamilyOther, SubfamilyVoid, ""},
+		{"zorin-17", "zorin", "17", FamilyDebian, "", ""},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join("testdata", "os-release", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := detect(fstest.MapFS{"etc/os-release": {Data: data}}, BuiltinRules())
+		if err != nil {
+			t.Errorf("%s: detect returned error: %v", tt.file, err)
+			continue
+		}
+		if d.ID != tt.id || d.VersionID != tt.versionID {
+			t.Errorf("%s: ID, VersionID = %q, %q, want %q, %q", tt.file, d.ID, d.VersionID, tt.id, tt.versionID)
+		}
+		if d.Family != tt.family || d.Subfamily != tt.subfamily || d.Atomic != tt.atomic {
+			t.Errorf("%s: Family, Subfamily, Atomic = %q, %q, %q, want %q, %q, %q",
+				tt.file, d.Family, d.Subfamily, d.Atomic, tt.family, tt.subfamily, tt.atomic)
+		}
+		if (d.Match == nil) != (tt.family == FamilyOther && tt.subfamily == "") {
+			t.Errorf("%s: Match = %+v, want a rule exactly when the family is known", tt.file, d.Match)
+		}
+		if d.Name == "" || d.PrettyName == "" {
+			t.Errorf("%s: Name and PrettyName should be set, got %q and %q", tt.file, d.Name, d.PrettyName)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join("testdata", "os-release"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(tests) {
+		t.Errorf("testdata/os-release has %d files but the table covers %d", len(entries), len(tests))
+	}
+}
+
+func TestDetectOptionalFields(t *testing.T) {
+	tests := []struct {
+		file                       string
+		codename, variant, buildID string
+	}{
+		{"debian-12", "bookworm", "", ""},
+		{"fedora-40", "", "workstation", ""},
+		{"ol-9.4", "", "server", ""},
+		{"arch", "", "", "rolling"},
+		{"nixos-24.05", "uakari", "", "24.05.2944.b2852eb9365c"},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join("testdata", "os-release", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := detect(fstest.MapFS{"etc/os-release": {Data: data}}, BuiltinRules())
+		if err != nil {
+			t.Fatalf("%s: detect returned error: %v", tt.file, err)
+		}
+		if d.VersionCodename != tt.codename || d.VariantID != tt.variant || d.BuildID != tt.buildID {
+			t.Errorf("%s: codename, variant, build = %q, %q, %q, want %q, %q, %q",
+				tt.file, d.VersionCodename, d.VariantID, d.BuildID, tt.codename, tt.variant, tt.buildID)
+		}
+	}
+}
+
+func TestDetectFSFallsBackToUsrLib(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	fsys := fstest.MapFS{"usr/lib/os-release": {Data: []byte("ID=arch\nNAME=\"Arch Linux\"\n")}}
+	d, err := DetectFS(fsys)
+	if err != nil {
+		t.Fatalf("DetectFS returned error: %v", err)
+	}
+	if d.ID != "arch" || d.Family != FamilyArch {
+		t.Errorf("got ID %q and Family %q, want arch", d.ID, d.Family)
+	}
+
+	if _, err := DetectFS(fstest.MapFS{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("DetectFS on an empty root returned %v, want fs.ErrNotExist", err)
+	}
+	if _, err := DetectFS(fstest.MapFS{"etc/os-release": {Data: []byte("NAME=Nothing\n")}}); err == nil {
+		t.Error("DetectFS without an ID should fail")
+	}
+}
+
+// An image's /etc/os-release is usually an absolute symlink. DetectRoot
+// must follow it inside the image, not on the machine running the test.
+func TestDetectRootFollowsSymlinksInside(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "etc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "usr", "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "usr", "lib", "os-release"), []byte("ID=void\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/usr/lib/os-release", filepath.Join(root, "etc", "os-release")); err != nil {
+		t.Skipf("cannot create symlinks here: %v", err)
+	}
+
+	d, err := DetectRoot(root)
+	if err != nil {
+		t.Fatalf("DetectRoot returned error: %v", err)
+	}
+	if d.ID != "void" || d.Subfamily != SubfamilyVoid {
+		t.Errorf("got ID %q and Subfamily %q, want void", d.ID, d.Subfamily)
+	}
+}
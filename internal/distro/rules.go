@@ -0,0 +1,174 @@
+package distro
+
+import (
+    "bytes"
+    _ "embed"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+
+    "penguinguide/internal/xdg"
+)
+
+//go:embed families.json
+var builtinRules []byte
+
+// BuiltinSource is the Source of rules that ship with penguinguide.
+const BuiltinSource = "built in"
+
+// Rule assigns a family, and for some distributions a subfamily, to
+// every os-release ID it lists, or to anything whose ID_LIKE names
+// one of IDLike. IDs may use shell patterns such as opensuse-*.
+type Rule struct {
+    Name      string    `json:"name"`
+    IDs       []string  `json:"id,omitempty"`
+    IDLike    []string  `json:"id_like,omitempty"`
+    Family    Family    `json:"family"`
+    Subfamily Subfamily `json:"subfamily,omitempty"`
+
+    // Source is BuiltinSource or the path of the file the rule came
+    // from.
+    Source string `json:"-"`
+}
+
+// Match says which rule classified a distribution, and which
+// os-release field and value it matched.
+type Match struct {
+    Rule  Rule
+    Field string
+    Value string
+}
+
+// Rules is an ordered list of rules. The first one that matches wins.
+type Rules struct {
+    rules []Rule
+}
+
+var knownFamilies = map[Family]bool{
+    FamilyDebian: true,
+    FamilyRHEL:   true,
+    FamilyArch:   true,
+    FamilySUSE:   true,
+    FamilyAlpine: true,
+    FamilyOther:  true,
+}
+
+var knownSubfamilies = map[Subfamily]bool{
+    SubfamilyVoid:   true,
+    SubfamilyGentoo: true,
+    SubfamilyNixOS:  true,
+    SubfamilySolus:  true,
+}
+
+// KnownFamily reports whether f is a family penguinguide supports.
+func KnownFamily(f Family) bool {
+    return knownFamilies[f]
+}
+
+// KnownSubfamily reports whether s is a subfamily penguinguide supports.
+func KnownSubfamily(s Subfamily) bool {
+    return knownSubfamilies[s]
+}
+
+// ParseRules reads rules in the families.json format: a list of
+// objects with a name, id and id_like lists, a family, and an
+// optional subfamily. source is recorded in each rule.
+func ParseRules(r io.Reader, source string) (*Rules, error) {
+    var raw []Rule
+    if err := json.NewDecoder(r).Decode(&raw); err != nil {
+        return nil, err
+    }
+
+    rs := &Rules{}
+    for i, rule := range raw {
+        switch {
+        case rule.Name == "":
+            return nil, fmt.Errorf("rule %d: name is missing", i+1)
+        case len(rule.IDs) == 0 && len(rule.IDLike) == 0:
+            return nil, fmt.Errorf("rule %q: needs an id or id_like list", rule.Name)
+        case !knownFamilies[rule.Family]:
+            return nil, fmt.Errorf("rule %q: unknown family %q", rule.Name, rule.Family)
+        case rule.Subfamily != "" && !knownSubfamilies[rule.Subfamily]:
+            return nil, fmt.Errorf("rule %q: unknown subfamily %q", rule.Name, rule.Subfamily)
+        case rule.Subfamily != "" && rule.Family != FamilyOther:
+            return nil, fmt.Errorf("rule %q: a subfamily needs family %q", rule.Name, FamilyOther)
+        }
+        for _, pattern := range rule.IDs {
+            if _, err := path.Match(pattern, ""); err != nil {
+                return nil, fmt.Errorf("rule %q: bad id pattern %q", rule.Name, pattern)
+            }
+        }
+        rule.Source = source
+        rs.rules = append(rs.rules, rule)
+    }
+    return rs, nil
+}
+
+// BuiltinRules returns the rules that ship with penguinguide.
+func BuiltinRules() *Rules {
+    rs, err := ParseRules(bytes.NewReader(builtinRules), BuiltinSource)
+    if err != nil {
+        panic("distro: built in rules are invalid: " + err.Error())
+    }
+    return rs
+}
+
+// RulesPath returns where a user can put extra rules.
+func RulesPath() (string, error) {
+    dir, err := xdg.ConfigDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(dir, "families.json"), nil
+}
+
+// LoadRules returns the built in rules with the user's in front, so a
+// derivative penguinguide does not know yet can be added without
+// waiting for a release.
+func LoadRules() (*Rules, error) {
+    rs := BuiltinRules()
+    path, err := RulesPath()
+    if err != nil {
+        return rs, nil
+    }
+    f, err := os.Open(path)
+    if os.IsNotExist(err) {
+        return rs, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    user, err := ParseRules(f, path)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    user.rules = append(user.rules, rs.rules...)
+    return user, nil
+}
+
+// Classify returns the first rule that matches d, or nil when none
+// does and d belongs in FamilyOther.
+func (rs *Rules) Classify(d *Distro) *Match {
+    id := strings.ToLower(d.ID)
+    for _, rule := range rs.rules {
+        for _, pattern := range rule.IDs {
+            if ok, _ := path.Match(strings.ToLower(pattern), id); ok {
+                return &Match{Rule: rule, Field: "ID", Value: d.ID}
+            }
+        }
+        for _, like := range d.IDLike {
+            for _, want := range rule.IDLike {
+                if strings.EqualFold(like, want) {
+                    return &Match{Rule: rule, Field: "ID_LIKE", Value: like}
+                }
+            }
+        }
+    }
+    return nil
+}
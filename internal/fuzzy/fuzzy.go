@@ -0,0 +1,89 @@
+// Package fuzzy finds names that are close to a misspelled one, so
+// commands can suggest what the user probably meant.
+package fuzzy
+
+import (
+    "sort"
+    "strings"
+)
+
+// Distance returns how many single character edits turn a into b.
+// Swapping two neighbouring characters counts as one edit, since
+// that is the most common typo. Case is ignored.
+func Distance(a, b string) int {
+    ra, rb := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+    // d[i][j] is the distance between the first i runes of a and the first j of b.
+    d := make([][]int, len(ra)+1)
+    for i := range d {
+        d[i] = make([]int, len(rb)+1)
+        d[i][0] = i
+    }
+    for j := range d[0] {
+        d[0][j] = j
+    }
+    for i := 1; i <= len(ra); i++ {
+        for j := 1; j <= len(rb); j++ {
+            cost := 1
+            if ra[i-1] == rb[j-1] {
+                cost = 0
+            }
+            d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+            if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+                d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+            }
+        }
+    }
+    return d[len(ra)][len(rb)]
+}
+
+// maxDistance is how many edits still count as close for a query of
+// n characters. Short names allow fewer, or everything would match.
+func maxDistance(n int) int {
+    switch {
+    case n <= 4:
+        return 1
+    case n <= 8:
+        return 2
+    }
+    return 3
+}
+
+// Rank returns up to limit candidates that are close to query, closest
+// first. Candidates that only add a short suffix, such as htop-dev
+// for htop, also count as close.
+func Rank(query string, candidates []string, limit int) []string {
+    type match struct {
+        name     string
+        distance int
+    }
+    var matches []match
+    seen := make(map[string]bool)
+    threshold := maxDistance(len([]rune(query)))
+    lower := strings.ToLower(query)
+    for _, c := range candidates {
+        if seen[c] || strings.EqualFold(c, query) {
+            continue
+        }
+        seen[c] = true
+        d := Distance(query, c)
+        if d > threshold && !(strings.HasPrefix(strings.ToLower(c), lower) && len(c)-len(query) <= 6) {
+            continue
+        }
+        matches = append(matches, match{c, d})
+    }
+    sort.SliceStable(matches, func(i, j int) bool {
+        if matches[i].distance != matches[j].distance {
+            return matches[i].distance < matches[j].distance
+        }
+        return matches[i].name < matches[j].name
+    })
+
+    var out []string
+    for _, m := range matches {
+        if len(out) == limit {
+            break
+        }
+        out = append(out, m.name)
+    }
+    return out
+}
@@ -0,0 +1,42 @@
+package fuzzy
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestDistance(t *testing.T) {
+    tests := []struct {
+        a, b string
+        want int
+    }{
+        {"htop", "htop", 0},
+        {"htpo", "htop", 1},
+        {"firefx", "firefox", 1},
+        {"Firefox", "firefox", 0},
+        {"vim", "nano", 4},
+        {"", "git", 3},
+    }
+    for _, tt := range tests {
+        if got := Distance(tt.a, tt.b); got != tt.want {
+            t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+        }
+    }
+}
+
+func TestRank(t *testing.T) {
+    candidates := []string{"htop", "btop", "atop", "htop-dev", "htop", "python3-htmlmin", "htop"}
+    got := Rank("htpo", candidates, 3)
+    if want := []string{"htop"}; !reflect.DeepEqual(got, want) {
+        t.Fatalf("Rank(htpo) = %q, want %q", got, want)
+    }
+
+    got = Rank("htop", candidates, 5)
+    if want := []string{"atop", "btop", "htop-dev"}; !reflect.DeepEqual(got, want) {
+        t.Fatalf("Rank(htop) = %q, want %q", got, want)
+    }
+
+    if got := Rank("firefox", []string{"thunderbird", "chromium"}, 3); len(got) != 0 {
+        t.Fatalf("Rank(firefox) = %q, want nothing close", got)
+    }
+}
@@ -0,0 +1,148 @@
+// Package history keeps a log of the package operations penguinguide ran,
+// one JSON object per line, so they can be reviewed and undone later.
+package history
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+
+    "penguinguide/internal/pkgmgr"
+    "penguinguide/internal/xdg"
+)
+
+// Entry is one recorded operation.
+type Entry struct {
+    ID       int       `json:"id"`
+    Time     time.Time `json:"time"`
+    Family   string    `json:"family"`
+    Action   string    `json:"action"`
+    Packages []string  `json:"packages,omitempty"`
+    Command  string    `json:"command"`
+    ExitCode int       `json:"exit_code"`
+    Source   string    `json:"source,omitempty"`
+}
+
+// Succeeded reports whether the operation finished without an error.
+func (e Entry) Succeeded() bool {
+    return e.ExitCode == 0
+}
+
+// Store reads and appends entries in a JSONL file.
+type Store struct {
+    Path string
+}
+
+// DefaultPath returns the history file under the XDG state directory.
+func DefaultPath() (string, error) {
+    dir, err := xdg.StateDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// Entries returns every entry, oldest first. A missing file
+// means nothing has been recorded yet.
+func (s *Store) Entries() ([]Entry, error) {
+    f, err := os.Open(s.Path)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    var entries []Entry
+    scanner := bufio.NewScanner(f)
+    scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+    line := 0
+    for scanner.Scan() {
+        line++
+        if len(scanner.Bytes()) == 0 {
+            continue
+        }
+        var e Entry
+        if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+            return nil, fmt.Errorf("%s line %d: %w", s.Path, line, err)
+        }
+        entries = append(entries, e)
+    }
+    return entries, scanner.Err()
+}
+
+// Get returns the entry with the given ID.
+func (s *Store) Get(id int) (Entry, error) {
+    entries, err := s.Entries()
+    if err != nil {
+        return Entry{}, err
+    }
+    for _, e := range entries {
+        if e.ID == id {
+            return e, nil
+        }
+    }
+    return Entry{}, fmt.Errorf("no history entry with id %d", id)
+}
+
+// Append gives e the next ID, writes it, and returns it.
+func (s *Store) Append(e Entry) (Entry, error) {
+    entries, err := s.Entries()
+    if err != nil {
+        return Entry{}, err
+    }
+    e.ID = 1
+    if n := len(entries); n > 0 {
+        e.ID = entries[n-1].ID + 1
+    }
+    if e.Time.IsZero() {
+        e.Time = time.Now()
+    }
+
+    data, err := json.Marshal(e)
+    if err != nil {
+        return Entry{}, err
+    }
+    if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+        return Entry{}, err
+    }
+    f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+    if err != nil {
+        return Entry{}, err
+    }
+    defer f.Close()
+
+    if _, err := f.Write(append(data, '\n')); err != nil {
+        return Entry{}, err
+    }
+    return e, nil
+}
+
+// Recorder adapts a Store to pkgmgr.Recorder, adding the distro family.
+// Source is empty for the native package manager, or names a secondary
+// source such as flatpak so undo knows which manager to use.
+type Recorder struct {
+    Store  *Store
+    Family string
+    Source string
+}
+
+// Record appends the operation to the store. History is a convenience,
+// so a failure to write it is reported but does not stop anything.
+func (r Recorder) Record(op pkgmgr.Operation) {
+    _, err := r.Store.Append(Entry{
+        Family:   r.Family,
+        Action:   op.Action,
+        Packages: op.Packages,
+        Command:  op.Command,
+        ExitCode: op.ExitCode,
+        Source:   r.Source,
+    })
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "penguinguide: could not write history:", err)
+    }
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+    "path/filepath"
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/pkgmgr"
+)
+
+// This test checks that entries get increasing IDs and can be read back.
+func TestStoreAppendAndRead(t *testing.T) {
+    store := &Store{Path: filepath.Join(t.TempDir(), "state", "history.jsonl")}
+
+    entries, err := store.Entries()
+    if err != nil || len(entries) != 0 {
+        t.Fatalf("Entries() on a new store = %v, %v, want nothing", entries, err)
+    }
+
+    first, err := store.Append(Entry{Family: "arch", Action: "install", Packages: []string{"htop"}, Command: "sudo pacman -S htop"})
+    if err != nil {
+        t.Fatalf("Append error = %v", err)
+    }
+    second, err := store.Append(Entry{Family: "arch", Action: "remove", Packages: []string{"htop"}, Command: "sudo pacman -R htop", ExitCode: 1})
+    if err != nil {
+        t.Fatalf("Append error = %v", err)
+    }
+    if first.ID != 1 || second.ID != 2 {
+        t.Fatalf("IDs = %d, %d, want 1, 2", first.ID, second.ID)
+    }
+    if first.Time.IsZero() {
+        t.Fatalf("Append did not set the time")
+    }
+
+    got, err := store.Get(2)
+    if err != nil {
+        t.Fatalf("Get error = %v", err)
+    }
+    if got.Action != "remove" || got.Succeeded() {
+        t.Fatalf("Get(2) = %+v, want the failed removal", got)
+    }
+    if _, err := store.Get(3); err == nil {
+        t.Fatalf("Get(3) succeeded, want an error for a missing entry")
+    }
+}
+
+func TestRecorder(t *testing.T) {
+    store := &Store{Path: filepath.Join(t.TempDir(), "history.jsonl")}
+    rec := Recorder{Store: store, Family: "debian"}
+    rec.Record(pkgmgr.Operation{Action: "install", Packages: []string{"htop"}, Command: "sudo apt install htop"})
+    flatpak := Recorder{Store: store, Family: "debian", Source: "flatpak"}
+    flatpak.Record(pkgmgr.Operation{Action: "install", Packages: []string{"org.gimp.GIMP"}, Command: "flatpak install org.gimp.GIMP"})
+
+    entries, err := store.Entries()
+    if err != nil || len(entries) != 2 {
+        t.Fatalf("Entries() = %v, %v, want two entries", entries, err)
+    }
+    e := entries[0]
+    want := Entry{ID: 1, Time: e.Time, Family: "debian", Action: "install", Packages: []string{"htop"}, Command: "sudo apt install htop"}
+    if !reflect.DeepEqual(e, want) {
+        t.Fatalf("entry = %+v, want %+v", e, want)
+    }
+    if entries[1].Source != "flatpak" {
+        t.Fatalf("second entry source = %q, want flatpak", entries[1].Source)
+    }
+}
+
+func TestDefaultPathUsesXDGStateHome(t *testing.T) {
+    dir := t.TempDir()
+    t.Setenv("XDG_STATE_HOME", dir)
+    got, err := DefaultPath()
+    if err != nil {
+        t.Fatalf("DefaultPath error = %v", err)
+    }
+    if want := filepath.Join(dir, "penguinguide", "history.jsonl"); got != want {
+        t.Fatalf("DefaultPath() = %q, want %q", got, want)
+    }
+
+    home := t.TempDir()
+    t.Setenv("HOME", home)
+    t.Setenv("XDG_STATE_HOME", "relative/dir")
+    got, err = DefaultPath()
+    if err != nil {
+        t.Fatalf("DefaultPath error = %v", err)
+    }
+    if want := filepath.Join(home, ".local", "state", "penguinguide", "history.jsonl"); got != want {
+        t.Fatalf("DefaultPath() = %q, want %q with a relative XDG_STATE_HOME ignored", got, want)
+    }
+}
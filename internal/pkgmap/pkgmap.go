@@ -0,0 +1,242 @@
+// Package pkgmap translates package names between distro families.
+//
+// Distributions split and name packages differently, so a tutorial that
+// says "apt install build-essential" needs base-devel on Arch and a few
+// separate packages on Fedora. The built in table covers common cases
+// and can be extended with a packages.json file in the config directory.
+package pkgmap
+
+import (
+    "bytes"
+    _ "embed"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/fuzzy"
+    "penguinguide/internal/xdg"
+)
+
+//go:embed packages.json
+var builtin []byte
+
+// Families lists the families the table has names for, in display order.
+var Families = []distro.Family{
+    distro.FamilyDebian,
+    distro.FamilyRHEL,
+    distro.FamilyArch,
+    distro.FamilySUSE,
+    distro.FamilyAlpine,
+}
+
+// Entry holds the names one piece of software has in each family.
+// A family can need several packages, or be missing when there is
+// no equivalent.
+type Entry map[distro.Family][]string
+
+// Table is an ordered list of entries. Earlier entries win when a
+// name appears in more than one.
+type Table struct {
+    entries []Entry
+}
+
+// Translation describes what happened to one requested name.
+// Source is the family whose name was recognized. NoMatch is set when
+// the table has nothing for the target family.
+type Translation struct {
+    From    string
+    To      []string
+    Source  distro.Family
+    Changed bool
+    NoMatch bool
+}
+
+// Parse reads a table in the packages.json format: a list of objects
+// mapping family names to lists of package names.
+func Parse(r io.Reader) (*Table, error) {
+    var raw []map[string][]string
+    if err := json.NewDecoder(r).Decode(&raw); err != nil {
+        return nil, err
+    }
+
+    known := make(map[distro.Family]bool, len(Families))
+    for _, f := range Families {
+        known[f] = true
+    }
+
+    t := &Table{}
+    for i, obj := range raw {
+        entry := make(Entry, len(obj))
+        for family, names := range obj {
+            if !known[distro.Family(family)] {
+                return nil, fmt.Errorf("entry %d: unknown family %q", i+1, family)
+            }
+            entry[distro.Family(family)] = names
+        }
+        t.entries = append(t.entries, entry)
+    }
+    return t, nil
+}
+
+// Builtin returns the table that ships with penguinguide.
+func Builtin() *Table {
+    t, err := Parse(bytes.NewReader(builtin))
+    if err != nil {
+        panic("pkgmap: built in table is invalid: " + err.Error())
+    }
+    return t
+}
+
+// UserPath returns where a user can put extra entries.
+func UserPath() (string, error) {
+    dir, err := xdg.ConfigDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(dir, "packages.json"), nil
+}
+
+// Load returns the built in table with the user's entries in front,
+// so people can correct or extend it without waiting for a release.
+func Load() (*Table, error) {
+    t := Builtin()
+    path, err := UserPath()
+    if err != nil {
+        return t, nil
+    }
+    f, err := os.Open(path)
+    if os.IsNotExist(err) {
+        return t, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    user, err := Parse(f)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    user.entries = append(user.entries, t.entries...)
+    return user, nil
+}
+
+// Lookup returns the first entry that lists name for any family,
+// along with the family it was found under.
+func (t *Table) Lookup(name string) (Entry, distro.Family, bool) {
+    for _, entry := range t.entries {
+        for _, family := range Families {
+            for _, n := range entry[family] {
+                if n == name {
+                    return entry, family, true
+                }
+            }
+        }
+    }
+    return nil, "", false
+}
+
+// Translate maps one name to the names used by target. Names the table
+// already lists for target, and names it does not know, are kept as is.
+func (t *Table) Translate(name string, target distro.Family) Translation {
+    entry, source, ok := t.Lookup(name)
+    if !ok {
+        return Translation{From: name, To: []string{name}, NoMatch: true}
+    }
+    for _, n := range entry[target] {
+        if n == name {
+            return Translation{From: name, To: []string{name}, Source: target}
+        }
+    }
+    if len(entry[target]) == 0 {
+        return Translation{From: name, To: []string{name}, Source: source, NoMatch: true}
+    }
+    return Translation{From: name, To: entry[target], Source: source, Changed: true}
+}
+
+// TranslateFrom is Translate for a name known to come from source, as
+// in an exported package list. Only entries that list the name for
+// source are used, so a name that means something else on another
+// family is not mistaken for it.
+func (t *Table) TranslateFrom(name string, source, target distro.Family) Translation {
+    if source == target {
+        return Translation{From: name, To: []string{name}, Source: source}
+    }
+    for _, entry := range t.entries {
+        for _, n := range entry[source] {
+            if n != name {
+                continue
+            }
+            to := entry[target]
+            if len(to) == 0 {
+                return Translation{From: name, To: []string{name}, Source: source, NoMatch: true}
+            }
+            changed := len(to) != 1 || to[0] != name
+            return Translation{From: name, To: to, Source: source, Changed: changed}
+        }
+    }
+    return Translation{From: name, To: []string{name}, NoMatch: true}
+}
+
+// TranslateAll translates every name and returns the combined package
+// list, without duplicates, along with what happened to each name.
+func (t *Table) TranslateAll(names []string, target distro.Family) ([]string, []Translation) {
+    var pkgs []string
+    var translations []Translation
+    seen := make(map[string]bool)
+    for _, name := range names {
+        tr := t.Translate(name, target)
+        translations = append(translations, tr)
+        for _, n := range tr.To {
+            if !seen[n] {
+                seen[n] = true
+                pkgs = append(pkgs, n)
+            }
+        }
+    }
+    return pkgs, translations
+}
+
+// Similar returns translations for up to limit entries that list a
+// name close to name for any family, such as build-esential for
+// build-essential. Entries with nothing for target are skipped.
+func (t *Table) Similar(name string, target distro.Family, limit int) []Translation {
+    var names []string
+    source := make(map[string]distro.Family)
+    index := make(map[string]int)
+    for i, entry := range t.entries {
+        if len(entry[target]) == 0 {
+            continue
+        }
+        for _, family := range Families {
+            for _, n := range entry[family] {
+                if _, ok := index[n]; ok {
+                    continue
+                }
+                names = append(names, n)
+                source[n] = family
+                index[n] = i
+            }
+        }
+    }
+
+    // Several names of one entry can be close, such as python and
+    // python3, but the entry should only be suggested once.
+    var out []Translation
+    used := make(map[int]bool)
+    for _, n := range fuzzy.Rank(name, names, len(names)) {
+        if len(out) == limit {
+            break
+        }
+        if used[index[n]] {
+            continue
+        }
+        used[index[n]] = true
+        to := t.entries[index[n]][target]
+        out = append(out, Translation{From: n, To: to, Source: source[n], Changed: len(to) != 1 || to[0] != n})
+    }
+    return out
+}
@@ -0,0 +1,122 @@
+package pkgmap
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+
+    "penguinguide/internal/distro"
+)
+
+// This test checks that the shipped table parses.
+func TestBuiltinParses(t *testing.T) {
+    if _, err := Parse(strings.NewReader(string(builtin))); err != nil {
+        t.Fatalf("built in table does not parse: %v", err)
+    }
+}
+
+// This test checks that typos in family names are reported.
+func TestParseRejectsUnknownFamily(t *testing.T) {
+    _, err := Parse(strings.NewReader(`[{"debian": ["a"], "gentoo": ["b"]}]`))
+    if err == nil || !strings.Contains(err.Error(), "gentoo") {
+        t.Fatalf("Parse error = %v, want unknown family gentoo", err)
+    }
+}
+
+// This test checks the main translation cases.
+func TestTranslate(t *testing.T) {
+    table := Builtin()
+    tests := []struct {
+        name    string
+        target  distro.Family
+        want    []string
+        changed bool
+    }{
+        {"build-essential", distro.FamilyArch, []string{"base-devel"}, true},
+        {"build-essential", distro.FamilyRHEL, []string{"gcc", "gcc-c++", "make"}, true},
+        {"python", distro.FamilyDebian, []string{"python3"}, true},
+        {"gcc", distro.FamilyDebian, []string{"gcc"}, false},
+        {"some-unknown-tool", distro.FamilyArch, []string{"some-unknown-tool"}, false},
+    }
+    for _, tt := range tests {
+        got := table.Translate(tt.name, tt.target)
+        if !reflect.DeepEqual(got.To, tt.want) || got.Changed != tt.changed {
+            t.Errorf("Translate(%q, %s) = %v changed=%v, want %v changed=%v",
+                tt.name, tt.target, got.To, got.Changed, tt.want, tt.changed)
+        }
+    }
+}
+
+// This test checks that names expanding to the same packages are not repeated.
+func TestTranslateAllDedupes(t *testing.T) {
+    pkgs, translations := Builtin().TranslateAll([]string{"build-essential", "gcc", "make"}, distro.FamilyRHEL)
+    want := []string{"gcc", "gcc-c++", "make"}
+    if !reflect.DeepEqual(pkgs, want) {
+        t.Fatalf("TranslateAll = %v, want %v", pkgs, want)
+    }
+    if len(translations) != 3 {
+        t.Fatalf("got %d translations, want 3", len(translations))
+    }
+}
+
+// This test checks that user entries take priority over the built in ones.
+func TestLoadUserEntries(t *testing.T) {
+    dir := t.TempDir()
+    t.Setenv("XDG_CONFIG_HOME", dir)
+    path := filepath.Join(dir, "penguinguide", "packages.json")
+    if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+        t.Fatal(err)
+    }
+    custom := `[{"debian": ["build-essential"], "arch": ["base-devel", "git"]}]`
+    if err := os.WriteFile(path, []byte(custom), 0o644); err != nil {
+        t.Fatal(err)
+    }
+
+    table, err := Load()
+    if err != nil {
+        t.Fatalf("Load error = %v", err)
+    }
+    got := table.Translate("build-essential", distro.FamilyArch).To
+    if !reflect.DeepEqual(got, []string{"base-devel", "git"}) {
+        t.Fatalf("Translate with user entry = %v, want [base-devel git]", got)
+    }
+    if got := table.Translate("python", distro.FamilyDebian).To; !reflect.DeepEqual(got, []string{"python3"}) {
+        t.Fatalf("built in entries missing after Load, got %v", got)
+    }
+}
+
+// This test checks that the source family decides which entry is used.
+func TestTranslateFrom(t *testing.T) {
+    table := Builtin()
+    tests := []struct {
+        name           string
+        source, target distro.Family
+        want           []string
+        changed        bool
+        noMatch        bool
+    }{
+        {"base-devel", distro.FamilyArch, distro.FamilyDebian, []string{"build-essential"}, true, false},
+        {"python", distro.FamilyArch, distro.FamilyAlpine, []string{"python3"}, true, false},
+        {"python3", distro.FamilyDebian, distro.FamilyDebian, []string{"python3"}, false, false},
+        {"some-unknown-tool", distro.FamilyArch, distro.FamilyRHEL, []string{"some-unknown-tool"}, false, true},
+    }
+    for _, tt := range tests {
+        got := table.TranslateFrom(tt.name, tt.source, tt.target)
+        if !reflect.DeepEqual(got.To, tt.want) || got.Changed != tt.changed || got.NoMatch != tt.noMatch {
+            t.Errorf("TranslateFrom(%q, %s, %s) = %+v, want %v changed=%v noMatch=%v",
+                tt.name, tt.source, tt.target, got, tt.want, tt.changed, tt.noMatch)
+        }
+    }
+}
+
+func TestSimilar(t *testing.T) {
+    got := Builtin().Similar("build-esential", distro.FamilyArch, 3)
+    if len(got) == 0 || got[0].From != "build-essential" || got[0].To[0] != "base-devel" || got[0].Source != distro.FamilyDebian {
+        t.Fatalf("Similar(build-esential) = %+v, want build-essential translated to base-devel first", got)
+    }
+    if got := Builtin().Similar("zzzzzzzz", distro.FamilyArch, 3); len(got) != 0 {
+        t.Fatalf("Similar(zzzzzzzz) = %+v, want nothing", got)
+    }
+}
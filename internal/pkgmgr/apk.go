@@ -0,0 +1,534 @@
+package pkgmgr
+
+import (
+    "fmt"
+    "path"
+    "regexp"
+    "strings"
+)
+
+/********** APK **********/
+
+type apkManager struct {
+    env Env
+}
+
+func (m *apkManager) UpdateAll(opts Options) error {
+    plan := newPlan("Update all packages with apk",
+        rootStep("apk", "update"),
+        rootStep("apk", "upgrade"),
+    ).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *apkManager) Install(pkgs []string, opts Options) error {
+    args := append([]string{"apk", "add", "--"}, versionedArgs(pkgs, "=")...)
+    plan := newPlan("Install packages with apk", rootStep(args...)).forAction("install", packageNames(pkgs)...)
+    return notFound(m.env.runOrPrint(plan, opts), apkNotFound)
+}
+
+func (m *apkManager) Remove(pkgs []string, opts Options) error {
+    args := append([]string{"apk", "del", "--"}, pkgs...)
+    plan := newPlan("Remove packages with apk", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *apkManager) Search(query string, opts Options) ([]SearchResult, error) {
+    args := append([]string{"apk", "search", "-v"}, searchTerms(query)...)
+    out, err := m.env.query(newPlan("Search for packages with apk", userStep(args...)), opts)
+    if err != nil {
+        return nil, err
+    }
+    results := parseApkSearch(string(out))
+    if len(results) == 0 {
+        return nil, nil
+    }
+
+    installed, err := apkInstalled(m.env, opts)
+    if err != nil {
+        return nil, err
+    }
+    markInstalled(results, installed)
+    return results, nil
+}
+
+func (m *apkManager) Info(name string, opts Options) (*PackageInfo, error) {
+    out, err := m.env.query(newPlan("Show package details with apk", userStep("apk", "info", "-a", "--", name)), opts)
+    if err != nil {
+        return nil, err
+    }
+    info := parseApkInfo(string(out))
+    if info.Name == "" {
+        return nil, ErrPackageNotFound
+    }
+
+    // apk info -e exits with 1 when the package is not installed.
+    installed := userStep("apk", "info", "-e", "--", name).allowExit(1)
+    instOut, err := m.env.query(newPlan("Check whether the package is installed with apk", installed), opts)
+    if err != nil {
+        return nil, err
+    }
+    info.Installed = strings.TrimSpace(string(instOut)) != ""
+    return info, nil
+}
+
+// apkInstalled returns installed package names mapped to their versions.
+func apkInstalled(env Env, opts Options) (map[string]string, error) {
+    out, err := env.query(newPlan("List installed packages with apk", userStep("apk", "info", "-v")), opts)
+    if err != nil {
+        return nil, err
+    }
+    installed := make(map[string]string)
+    for _, line := range strings.Split(string(out), "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" {
+            continue
+        }
+        name, version := splitNameVersion(line)
+        installed[name] = version
+    }
+    return installed, nil
+}
+
+// parseApkSearch parses apk search -v output, which looks like:
+//
+//	htop-3.2.2-r1 - Interactive process viewer
+func parseApkSearch(out string) []SearchResult {
+    var results []SearchResult
+    for _, line := range strings.Split(out, "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" {
+            continue
+        }
+        pkg, summary, _ := strings.Cut(line, " - ")
+        name, version := splitNameVersion(strings.TrimSpace(pkg))
+        results = append(results, SearchResult{
+            Name:    name,
+            Version: version,
+            Summary: strings.TrimSpace(summary),
+        })
+    }
+    return results
+}
+
+// parseApkInfo parses apk info -a output, which is a list of sections:
+//
+//	htop-3.2.2-r1 description:
+//	Interactive process viewer
+//
+//	htop-3.2.2-r1 installed size:
+//	336 KiB
+func parseApkInfo(out string) *PackageInfo {
+    info := &PackageInfo{Raw: out}
+    section := ""
+    for _, line := range strings.Split(out, "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" {
+            section = ""
+            continue
+        }
+        if section == "" && strings.HasSuffix(line, ":") {
+            pkg, key, ok := strings.Cut(strings.TrimSuffix(line, ":"), " ")
+            if !ok {
+                continue
+            }
+            if info.Name == "" {
+                info.Name, info.Version = splitNameVersion(pkg)
+            }
+            section = key
+            continue
+        }
+
+        switch section {
+        case "description":
+            info.Description = strings.TrimSpace(info.Description + " " + line)
+        case "webpage":
+            info.Homepage = line
+        case "installed size":
+            info.InstalledSize = ParseSize(line)
+        case "size":
+            info.Size = ParseSize(line)
+        case "license":
+            info.License = line
+        case "depends on":
+            info.Dependencies = append(info.Dependencies, line)
+        }
+    }
+    return info
+}
+
+// List reports installed packages. apk does not record install dates,
+// and sizes would need one query per package, so both are left empty.
+func (m *apkManager) List(opts Options) ([]InstalledPackage, error) {
+    installed, err := apkInstalled(m.env, opts)
+    if err != nil {
+        return nil, err
+    }
+    world, err := m.env.query(newPlan("Read the packages you asked for from the apk world file", userStep("cat", "/etc/apk/world")), opts)
+    if err != nil {
+        return nil, err
+    }
+    explicit := parseApkWorld(string(world))
+
+    pkgs := make([]InstalledPackage, 0, len(installed))
+    for name, version := range installed {
+        pkgs = append(pkgs, InstalledPackage{Name: name, Version: version, Explicit: explicit[name]})
+    }
+    SortInstalled(pkgs, "name")
+    return pkgs, nil
+}
+
+// parseApkWorld reads /etc/apk/world, where entries can carry
+// constraints such as "foo>=1.2", "foo=1.2", or "foo@testing".
+func parseApkWorld(out string) map[string]bool {
+    names := make(map[string]bool)
+    for _, entry := range strings.Fields(out) {
+        if i := strings.IndexAny(entry, "<>=~@"); i > 0 {
+            entry = entry[:i]
+        }
+        names[entry] = true
+    }
+    return names
+}
+
+func (m *apkManager) CheckUpdates(opts Options) ([]Upgrade, error) {
+    refresh := newPlan("Refresh the package indexes with apk", rootStep("apk", "update")).forAction("refresh")
+    if err := m.env.runOrPrint(refresh, opts); err != nil {
+        return nil, err
+    }
+    step := userStep("apk", "version", "-l", "<")
+    out, err := m.env.query(newPlan("List packages with newer versions available with apk", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseApkVersion(string(out)), nil
+}
+
+// parseApkVersion parses apk version -l '<' output, which looks like:
+//
+//	Installed:                                Available:
+//	htop-3.2.1-r0                           < 3.2.2-r1
+func parseApkVersion(out string) []Upgrade {
+    var upgrades []Upgrade
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Fields(line)
+        if len(fields) != 3 || fields[1] != "<" {
+            continue
+        }
+        name, old := splitNameVersion(fields[0])
+        upgrades = append(upgrades, Upgrade{Name: name, OldVersion: old, NewVersion: fields[2]})
+    }
+    return upgrades
+}
+
+func (m *apkManager) Owner(path string, opts Options) ([]Provider, error) {
+    // apk exits with 1 when no package owns the path.
+    step := userStep("apk", "info", "--who-owns", "--", path).allowExit(1)
+    out, err := m.env.query(newPlan("Find the installed package that owns this file with apk", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseOwnedBy(string(out), true), nil
+}
+
+// Provides looks up the cmd: name every Alpine package declares
+// for the programs it installs.
+func (m *apkManager) Provides(command string, opts Options) ([]Provider, error) {
+    step := userStep("apk", "search", "--exact", "cmd:"+command)
+    out, err := m.env.query(newPlan("Search for packages that provide this command with apk", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    var providers []Provider
+    for _, line := range strings.Split(string(out), "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" {
+            continue
+        }
+        name, _ := splitNameVersion(line)
+        providers = append(providers, Provider{Package: name})
+    }
+    return providers, nil
+}
+
+// CleanupTasks only covers the package cache, which exists when
+// /etc/apk/cache is set up. apk removes dependencies that nothing
+// needs as part of every apk del, so there are no orphans to find.
+func (m *apkManager) CleanupTasks(opts Options) ([]CleanupTask, error) {
+    files := dirFiles(apkCacheDir)
+    if len(files) == 0 {
+        return nil, nil
+    }
+    // apk cache clean keeps the files of installed versions, so only the
+    // rest count.
+    installed, err := apkInstalled(m.env, opts)
+    if err != nil {
+        return nil, err
+    }
+    size := apkStaleCache(files, installed)
+    if size == 0 {
+        return nil, nil
+    }
+    clean := newPlan("Delete cached packages that are no longer needed with apk", rootStep("apk", "cache", "clean")).forAction("cleanup")
+    return []CleanupTask{m.env.newCleanupTask("cache", "Cached packages in "+apkCacheDir+" whose version is not installed", size, clean)}, nil
+}
+
+func (m *apkManager) Cleanup(task CleanupTask, opts Options) error {
+    return m.env.runOrPrint(task.plan, opts)
+}
+
+// Hold pins each package to its installed version in /etc/apk/world
+// by adding it again as name=version.
+func (m *apkManager) Hold(pkgs []string, opts Options) error {
+    installed, err := apkInstalled(m.env, opts)
+    if err != nil {
+        return err
+    }
+    args := []string{"apk", "add"}
+    for _, name := range pkgs {
+        version, ok := installed[name]
+        if !ok {
+            return fmt.Errorf("%s is not installed, so there is no version to pin", name)
+        }
+        args = append(args, name+"="+version)
+    }
+    plan := newPlan("Pin packages to their installed version in /etc/apk/world", rootStep(args...)).forAction("hold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// Unhold adds the packages again without a version, which replaces the pin.
+func (m *apkManager) Unhold(pkgs []string, opts Options) error {
+    args := append([]string{"apk", "add"}, pkgs...)
+    plan := newPlan("Remove the version pin from packages in /etc/apk/world", rootStep(args...)).forAction("unhold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *apkManager) Holds(opts Options) ([]string, error) {
+    world, err := m.env.query(newPlan("Read pinned packages from the apk world file", userStep("cat", "/etc/apk/world")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseApkPins(string(world)), nil
+}
+
+const (
+    apkRepositories = "/etc/apk/repositories"
+    apkKeysDir      = "/etc/apk/keys"
+)
+
+// Repos reads /etc/apk/repositories. Each line is one repository and
+// its URL is the ID. A leading @tag pins the repository, so packages
+// only come from it when asked for as name@tag.
+func (m *apkManager) Repos(opts Options) ([]Repo, error) {
+    out, err := m.env.query(newPlan("Read "+apkRepositories, userStep("cat", apkRepositories)), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseApkRepositories(string(out)), nil
+}
+
+// AddRepo saves the key under /etc/apk/keys, keeping its file name
+// because apk looks keys up by the name the packages were signed with,
+// and appends the repository. A name becomes the @tag.
+func (m *apkManager) AddRepo(spec RepoSpec, opts Options) error {
+    if spec.Name != "" {
+        if err := checkRepoName(spec.Name); err != nil {
+            return err
+        }
+    }
+    var steps []Step
+    explanation := "Append the repository to " + apkRepositories
+    if spec.Key != "" {
+        keyFile := path.Join(apkKeysDir, path.Base(spec.Key))
+        download, err := m.env.downloadStep(spec.Key, keyFile)
+        if err != nil {
+            return err
+        }
+        steps = append(steps, download)
+        explanation = "Save the signing key to " + keyFile + ", append the repository to " + apkRepositories
+    }
+    line := spec.URL
+    if spec.Name != "" {
+        line = "@" + spec.Name + " " + spec.URL
+        explanation += " tagged @" + spec.Name + ", so packages only come from it when installed as name@" + spec.Name + ","
+    }
+    steps = append(steps,
+        rootStep("tee", "-a", apkRepositories).withInput(line+"\n"),
+        rootStep("apk", "update"),
+    )
+    plan := newPlan(explanation+" and refresh the package index", steps...).forAction("repo-add", spec.URL)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *apkManager) RemoveRepo(id string, opts Options) error {
+    if _, err := m.apkRepo(id, opts); err != nil {
+        return err
+    }
+    expr := `\|^#?[[:space:]]*(@[^ ]+ +)?` + regexp.QuoteMeta(id) + `[[:space:]]*$|d`
+    plan := newPlan("Delete the repository line from "+apkRepositories,
+        rootStep("sed", "-i", "-E", expr, apkRepositories)).forAction("repo-remove", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *apkManager) EnableRepo(id string, opts Options) error {
+    if _, err := m.apkRepo(id, opts); err != nil {
+        return err
+    }
+    expr := `\|^#[[:space:]]*(@[^ ]+ +)?` + regexp.QuoteMeta(id) + `[[:space:]]*$| s|^#[[:space:]]*||`
+    plan := newPlan("Uncomment the repository line in "+apkRepositories+" and refresh the package index",
+        rootStep("sed", "-i", "-E", expr, apkRepositories), rootStep("apk", "update")).forAction("repo-enable", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *apkManager) DisableRepo(id string, opts Options) error {
+    if _, err := m.apkRepo(id, opts); err != nil {
+        return err
+    }
+    expr := `\|^(@[^ ]+ +)?` + regexp.QuoteMeta(id) + `[[:space:]]*$| s|^|#|`
+    plan := newPlan("Comment out the repository line in "+apkRepositories,
+        rootStep("sed", "-i", "-E", expr, apkRepositories)).forAction("repo-disable", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *apkManager) apkRepo(id string, opts Options) (Repo, error) {
+    repos, err := m.Repos(opts)
+    if err != nil {
+        return Repo{}, err
+    }
+    return findRepo(repos, id)
+}
+
+// parseApkRepositories parses /etc/apk/repositories:
+//
+//	https://dl-cdn.alpinelinux.org/alpine/v3.20/main
+//	#https://dl-cdn.alpinelinux.org/alpine/v3.20/community
+//	@testing https://dl-cdn.alpinelinux.org/alpine/edge/testing
+func parseApkRepositories(out string) []Repo {
+    var repos []Repo
+    for _, line := range strings.Split(out, "\n") {
+        line = strings.TrimSpace(line)
+        enabled := !strings.HasPrefix(line, "#")
+        fields := strings.Fields(strings.TrimPrefix(line, "#"))
+        tag := ""
+        if len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+            tag = fields[0]
+            fields = fields[1:]
+        }
+        // Commented lines that are not a single URL are just comments.
+        if len(fields) != 1 || !strings.Contains(fields[0], "/") {
+            continue
+        }
+        repos = append(repos, Repo{ID: fields[0], Name: tag, URL: fields[0], Enabled: enabled, File: apkRepositories})
+    }
+    return repos
+}
+
+func (m *apkManager) Versions(name string, opts Options) ([]PackageVersion, error) {
+    out, err := m.env.query(newPlan("List available versions with apk policy", userStep("apk", "policy", "--", name)), opts)
+    if err != nil {
+        return nil, err
+    }
+    versions := parseApkPolicy(string(out))
+    if len(versions) == 0 {
+        return nil, ErrPackageNotFound
+    }
+    return mergeVersions(versions), nil
+}
+
+func (m *apkManager) Downgrade(name, version string, opts Options) error {
+    plan := newPlan("Pin "+name+" to version "+version+" in /etc/apk/world with apk add, which also installs it. "+
+        "It stays at that version until you unhold it", rootStep("apk", "add", "--", name+"="+version)).forAction("downgrade", name)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// parseApkPolicy parses apk policy, which lists each version with the
+// repositories that have it. lib/apk/db/installed marks the installed one:
+//
+//	htop policy:
+//	  3.3.0-r0:
+//	    lib/apk/db/installed
+//	    https://dl-cdn.alpinelinux.org/alpine/v3.20/main
+//	  3.2.2-r1:
+//	    https://dl-cdn.alpinelinux.org/alpine/v3.19/main
+func parseApkPolicy(out string) []PackageVersion {
+    var versions []PackageVersion
+    for _, line := range strings.Split(out, "\n") {
+        trimmed := strings.TrimSpace(line)
+        if trimmed == "" || strings.HasSuffix(trimmed, " policy:") {
+            continue
+        }
+        if strings.HasSuffix(trimmed, ":") && !strings.Contains(trimmed, "/") {
+            versions = append(versions, PackageVersion{Version: strings.TrimSuffix(trimmed, ":")})
+            continue
+        }
+        if len(versions) == 0 {
+            continue
+        }
+        current := &versions[len(versions)-1]
+        switch {
+        case trimmed == "lib/apk/db/installed":
+            current.Installed = true
+        case strings.HasPrefix(trimmed, "/etc/apk/cache"):
+            current.Cached = true
+        case current.Repository == "":
+            current.Repository = trimmed
+        }
+    }
+    return versions
+}
+
+func (m *apkManager) PackageNames(opts Options) ([]string, error) {
+    out, err := m.env.query(newPlan("List every package in the repositories with apk", userStep("apk", "search", "-v")), opts)
+    if err != nil {
+        return nil, err
+    }
+    set := make(map[string]bool)
+    for _, r := range parseApkSearch(string(out)) {
+        set[r.Name] = true
+    }
+    return sortedNames(set), nil
+}
+
+func (m *apkManager) Deps(name string, opts Options) ([]string, error) {
+    out, err := m.env.query(newPlan("List what the package depends on with apk", userStep("apk", "info", "-R", "--", name)), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseApkDepends(name, string(out), false)
+}
+
+func (m *apkManager) ReverseDeps(name string, opts Options) ([]string, error) {
+    out, err := m.env.query(newPlan("List installed packages that depend on the package with apk", userStep("apk", "info", "-r", "--", name)), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseApkDepends(name, string(out), true)
+}
+
+// parseApkDepends parses apk info -R and -r output, which is a
+// heading and then one dependency per line. apk prints nothing for
+// packages it does not know. Dependencies are names with an optional
+// version constraint, or shared libraries such as
+// so:libc.musl-x86_64.so.1, which are kept as they are. Reverse
+// dependencies are name-version packages.
+func parseApkDepends(name, out string, reverse bool) ([]string, error) {
+    if strings.TrimSpace(out) == "" {
+        return nil, ErrPackageNotFound
+    }
+    set := make(map[string]bool)
+    for _, line := range strings.Split(out, "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasSuffix(line, ":") {
+            continue
+        }
+        if reverse {
+            line, _ = splitNameVersion(line)
+        } else if i := strings.IndexAny(line, "<>=~"); i > 0 {
+            line = line[:i]
+        }
+        set[line] = true
+    }
+    return depNames(set, name), nil
+}
+
+const apkCacheDir = "/etc/apk/cache"
@@ -0,0 +1,782 @@
+package pkgmgr
+
+import (
+    "fmt"
+    "path/filepath"
+    "regexp"
+    "slices"
+    "strconv"
+    "strings"
+)
+
+/********** APT **********/
+
+type aptManager struct {
+    env Env
+}
+
+func (m *aptManager) UpdateAll(opts Options) error {
+    upgrade := []string{"apt", "upgrade"}
+    if opts.AssumeYes {
+        upgrade = append(upgrade, "-y")
+    }
+    plan := newPlan("Update all packages with apt",
+        rootStep("apt", "update"),
+        rootStep(upgrade...),
+    ).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *aptManager) Install(pkgs []string, opts Options) error {
+    args := []string{"apt", "install"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(append(args, "--"), versionedArgs(pkgs, "=")...)
+    plan := newPlan("Install packages with apt", rootStep(args...)).forAction("install", packageNames(pkgs)...)
+    return notFound(m.env.runOrPrint(plan, opts), aptNotFound)
+}
+
+func (m *aptManager) Remove(pkgs []string, opts Options) error {
+    args := []string{"apt", "remove"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(append(args, "--"), pkgs...)
+    plan := newPlan("Remove packages with apt", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *aptManager) Search(query string, opts Options) ([]SearchResult, error) {
+    args := append([]string{"apt", "search"}, searchTerms(query)...)
+    out, err := m.env.query(newPlan("Search for packages with apt", userStep(args...)), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseAptSearch(string(out)), nil
+}
+
+func (m *aptManager) Info(name string, opts Options) (*PackageInfo, error) {
+    // apt exits with 100 when it does not know the package.
+    step := userStep("apt", "show", "--", name).allowExit(100)
+    out, err := m.env.query(newPlan("Show package details with apt", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    info := parseAptShow(string(out))
+    if info.Name == "" {
+        return nil, ErrPackageNotFound
+    }
+
+    // dpkg-query exits with 1 for packages it has never seen.
+    status := userStep("dpkg-query", "--show", "--showformat", "${Status}", "--", name).allowExit(1)
+    statusOut, err := m.env.query(newPlan("Check whether the package is installed with dpkg", status), opts)
+    if err != nil {
+        return nil, err
+    }
+    info.Installed = strings.HasSuffix(strings.TrimSpace(string(statusOut)), " installed")
+    return info, nil
+}
+
+// parseAptSearch parses apt search output, which looks like:
+//
+//	htop/jammy,now 3.0.5-7build2 amd64 [installed]
+//	  interactive processes viewer
+func parseAptSearch(out string) []SearchResult {
+    var results []SearchResult
+    for _, line := range strings.Split(out, "\n") {
+        if strings.TrimSpace(line) == "" {
+            continue
+        }
+        if strings.HasPrefix(line, " ") {
+            if n := len(results); n > 0 {
+                results[n-1].Summary = strings.TrimSpace(results[n-1].Summary + " " + strings.TrimSpace(line))
+            }
+            continue
+        }
+
+        fields := strings.Fields(line)
+        slash := strings.Index(fields[0], "/")
+        if slash < 0 || len(fields) < 2 {
+            // Progress lines such as "Sorting..." have no suite.
+            continue
+        }
+        suites := strings.Split(fields[0][slash+1:], ",")
+        r := SearchResult{
+            Name:       fields[0][:slash],
+            Version:    fields[1],
+            Repository: suites[0],
+        }
+        if i := strings.Index(line, "["); i >= 0 {
+            status := line[i:]
+            r.Installed = strings.Contains(status, "installed") || strings.Contains(status, "upgradable")
+        }
+        results = append(results, r)
+    }
+    return results
+}
+
+// parseAptShow parses the first stanza of apt show output.
+func parseAptShow(out string) *PackageInfo {
+    info := &PackageInfo{Raw: out}
+    last := ""
+    for _, line := range strings.Split(out, "\n") {
+        if strings.TrimSpace(line) == "" {
+            if info.Name != "" {
+                break
+            }
+            continue
+        }
+        if strings.HasPrefix(line, " ") {
+            if last == "description" {
+                text := strings.TrimSpace(line)
+                if text == "." {
+                    text = "\n"
+                }
+                info.Description = strings.TrimSpace(info.Description + "\n" + text)
+            }
+            continue
+        }
+
+        key, value, ok := strings.Cut(line, ":")
+        if !ok {
+            continue
+        }
+        last = strings.ToLower(key)
+        value = strings.TrimSpace(value)
+        switch last {
+        case "package":
+            info.Name = value
+        case "version":
+            info.Version = value
+        case "apt-sources":
+            info.Repository = value
+        case "installed-size":
+            info.InstalledSize = ParseSize(value)
+        case "download-size":
+            info.Size = ParseSize(value)
+        case "homepage":
+            info.Homepage = value
+        case "depends", "pre-depends":
+            info.Dependencies = append(info.Dependencies, parseDebDepends(value)...)
+        case "description":
+            info.Description = value
+        }
+    }
+    return info
+}
+
+// parseDebDepends turns "libc6 (>= 2.34), a | b" into "libc6", "a | b".
+func parseDebDepends(value string) []string {
+    var deps []string
+    for _, dep := range strings.Split(value, ",") {
+        var alts []string
+        for _, alt := range strings.Split(dep, "|") {
+            if i := strings.Index(alt, "("); i >= 0 {
+                alt = alt[:i]
+            }
+            if alt = strings.TrimSpace(alt); alt != "" {
+                alts = append(alts, alt)
+            }
+        }
+        if len(alts) > 0 {
+            deps = append(deps, strings.Join(alts, " | "))
+        }
+    }
+    return deps
+}
+
+func (m *aptManager) List(opts Options) ([]InstalledPackage, error) {
+    query := userStep("dpkg-query", "--show", "--showformat",
+        `${Package}\t${binary:Package}\t${Version}\t${Installed-Size}\t${db:Status-Abbrev}\n`)
+    out, err := m.env.query(newPlan("List installed packages with dpkg", query), opts)
+    if err != nil {
+        return nil, err
+    }
+    manualOut, err := m.env.query(newPlan("List packages you installed by name with apt-mark", userStep("apt-mark", "showmanual")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseDpkgList(string(out), nameSet(string(manualOut))), nil
+}
+
+// parseDpkgList parses the dpkg-query format used by List. dpkg keeps no
+// install date, so the time the package file list changed is used instead.
+func parseDpkgList(out string, manual map[string]bool) []InstalledPackage {
+    var pkgs []InstalledPackage
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Split(line, "\t")
+        if len(fields) < 5 || !strings.HasPrefix(fields[4], "ii") {
+            continue
+        }
+        kib, _ := strconv.ParseInt(fields[3], 10, 64)
+        pkgs = append(pkgs, InstalledPackage{
+            Name:        fields[0],
+            Version:     fields[2],
+            Explicit:    manual[fields[0]],
+            Size:        kib * 1024,
+            InstallDate: fileModTime("/var/lib/dpkg/info/" + fields[1] + ".list"),
+        })
+    }
+    return pkgs
+}
+
+func (m *aptManager) CheckUpdates(opts Options) ([]Upgrade, error) {
+    refresh := newPlan("Refresh the package lists with apt", rootStep("apt", "update")).forAction("refresh")
+    if err := m.env.runOrPrint(refresh, opts); err != nil {
+        return nil, err
+    }
+
+    out, err := m.env.query(newPlan("List upgradable packages with apt", userStep("apt", "list", "--upgradable")), opts)
+    if err != nil {
+        return nil, err
+    }
+    upgrades := parseAptUpgradable(string(out))
+    if len(upgrades) == 0 {
+        return nil, nil
+    }
+
+    // --print-uris only prints what would be downloaded, which includes
+    // the size of each file. Sizes are a nice extra, so a failure here
+    // does not stop the check.
+    uris := userStep("apt-get", "--print-uris", "--quiet", "--quiet", "dist-upgrade")
+    if uriOut, err := m.env.query(newPlan("Ask apt how much each upgrade downloads", uris), opts); err == nil {
+        sizes := parseAptPrintURIs(string(uriOut))
+        for i := range upgrades {
+            upgrades[i].DownloadSize = sizes[upgrades[i].Name]
+        }
+    }
+    return upgrades, nil
+}
+
+// parseAptUpgradable parses apt list --upgradable output, which looks like:
+//
+//	openssl/jammy-updates,jammy-security 3.0.2-0ubuntu1.15 amd64 [upgradable from: 3.0.2-0ubuntu1.14]
+func parseAptUpgradable(out string) []Upgrade {
+    var upgrades []Upgrade
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Fields(line)
+        if len(fields) < 2 {
+            continue
+        }
+        slash := strings.Index(fields[0], "/")
+        if slash < 0 {
+            continue
+        }
+        suites := fields[0][slash+1:]
+        u := Upgrade{
+            Name:       fields[0][:slash],
+            NewVersion: fields[1],
+            Repository: strings.Split(suites, ",")[0],
+            Security:   strings.Contains(suites, "-security"),
+        }
+        if i := strings.Index(line, "from: "); i >= 0 {
+            u.OldVersion = strings.TrimSuffix(strings.TrimSpace(line[i+len("from: "):]), "]")
+        }
+        upgrades = append(upgrades, u)
+    }
+    return upgrades
+}
+
+// parseAptPrintURIs maps package names to download sizes from lines like:
+//
+//	'http://archive.ubuntu.com/.../htop_3.0.5-7_amd64.deb' htop_3.0.5-7_amd64.deb 128000 SHA512:...
+func parseAptPrintURIs(out string) map[string]int64 {
+    sizes := make(map[string]int64)
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Fields(line)
+        if len(fields) < 3 || !strings.HasPrefix(fields[0], "'") {
+            continue
+        }
+        name, _, _ := strings.Cut(fields[1], "_")
+        size, err := strconv.ParseInt(fields[2], 10, 64)
+        if err == nil {
+            sizes[name] = size
+        }
+    }
+    return sizes
+}
+
+func (m *aptManager) Owner(path string, opts Options) ([]Provider, error) {
+    // dpkg exits with 1 when no package owns the path.
+    step := userStep("dpkg", "--search", "--", path).allowExit(1)
+    out, err := m.env.query(newPlan("Find the installed package that owns this file with dpkg", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseDebProviders(string(out)), nil
+}
+
+func (m *aptManager) Provides(command string, opts Options) ([]Provider, error) {
+    if !m.env.has("apt-file") {
+        // apt-file knows nothing until its index is downloaded as root.
+        update := m.env.elevate(newPlan("", rootStep("apt-file", "update"))).String()
+        return nil, &MissingToolError{Tool: "apt-file", Install: "penguinguide install apt-file && " + update}
+    }
+    // apt-file exits with 1 when nothing matched.
+    pattern := "^/(usr/)?s?bin/" + regexp.QuoteMeta(command) + "$"
+    step := userStep("apt-file", "search", "--regexp", pattern).allowExit(1)
+    out, err := m.env.query(newPlan("Search the contents of every available package with apt-file", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseDebProviders(string(out)), nil
+}
+
+// parseDebProviders parses "net-tools: /sbin/ifconfig" lines printed by
+// dpkg --search and apt-file. A line can list several packages.
+func parseDebProviders(out string) []Provider {
+    var providers []Provider
+    for _, line := range strings.Split(out, "\n") {
+        pkgs, path, ok := strings.Cut(line, ": ")
+        if !ok {
+            continue
+        }
+        for _, pkg := range strings.Split(pkgs, ",") {
+            pkg, _, _ = strings.Cut(strings.TrimSpace(pkg), ":")
+            providers = append(providers, Provider{Package: pkg, Path: strings.TrimSpace(path)})
+        }
+    }
+    return providers
+}
+
+func (m *aptManager) CleanupTasks(opts Options) ([]CleanupTask, error) {
+    var tasks []CleanupTask
+    clean := newPlan("Delete downloaded package files with apt", rootStep("apt", "clean")).forAction("cleanup")
+    if task, ok := m.env.cacheTask("/var/cache/apt/archives", clean); ok {
+        tasks = append(tasks, task)
+    }
+
+    step := userStep("apt-get", "--simulate", "autoremove")
+    out, err := m.env.query(newPlan("List what apt would autoremove, without removing anything", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    args := []string{"apt", "autoremove"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    remove := newPlan("Remove dependencies that no installed package needs with apt", rootStep(args...))
+    task, ok, err := m.env.orphanTask(m, parseAptAutoremove(string(out)), remove, opts)
+    if err != nil {
+        return nil, err
+    }
+    if ok {
+        tasks = append(tasks, task)
+    }
+    return tasks, nil
+}
+
+func (m *aptManager) Cleanup(task CleanupTask, opts Options) error {
+    return m.env.runOrPrint(task.plan, opts)
+}
+
+func (m *aptManager) Hold(pkgs []string, opts Options) error {
+    args := append([]string{"apt-mark", "hold"}, pkgs...)
+    plan := newPlan("Keep packages at their current version with apt-mark", rootStep(args...)).forAction("hold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *aptManager) Unhold(pkgs []string, opts Options) error {
+    args := append([]string{"apt-mark", "unhold"}, pkgs...)
+    plan := newPlan("Allow packages to be upgraded again with apt-mark", rootStep(args...)).forAction("unhold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *aptManager) Holds(opts Options) ([]string, error) {
+    out, err := m.env.query(newPlan("List held packages with apt-mark", userStep("apt-mark", "showhold")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return strings.Fields(string(out)), nil
+}
+
+const (
+    aptSourcesList = "/etc/apt/sources.list"
+    aptSourcesDir  = "/etc/apt/sources.list.d"
+    aptKeyringDir  = "/etc/apt/keyrings"
+)
+
+// Repos reads /etc/apt/sources.list and every file in sources.list.d.
+// Each file is one ID, named after the file without its extension.
+func (m *aptManager) Repos(opts Options) ([]Repo, error) {
+    files := []string{aptSourcesList}
+    for _, pattern := range []string{"*.list", "*.sources"} {
+        matches, err := globFiles(filepath.Join(aptSourcesDir, pattern))
+        if err != nil {
+            return nil, err
+        }
+        files = append(files, matches...)
+    }
+
+    var repos []Repo
+    for _, file := range files {
+        // cat exits with 1 when sources.list does not exist, as on newer releases.
+        out, err := m.env.query(newPlan("Read "+file, userStep("cat", file).allowExit(1)), opts)
+        if err != nil {
+            return nil, err
+        }
+        id := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+        if strings.HasSuffix(file, ".sources") {
+            repos = append(repos, parseDeb822Sources(id, file, string(out))...)
+        } else {
+            repos = append(repos, parseAptSourcesList(id, file, string(out))...)
+        }
+    }
+    return repos, nil
+}
+
+// AddRepo adds PPAs with add-apt-repository. Other repositories get a
+// deb822 file in sources.list.d that only trusts their own key, which
+// is downloaded to /etc/apt/keyrings.
+func (m *aptManager) AddRepo(spec RepoSpec, opts Options) error {
+    if strings.HasPrefix(spec.URL, "ppa:") {
+        if !m.env.has("add-apt-repository") {
+            return &MissingToolError{Tool: "add-apt-repository", Install: "penguinguide install software-properties-common"}
+        }
+        args := []string{"add-apt-repository"}
+        if opts.AssumeYes {
+            args = append(args, "-y")
+        }
+        args = append(args, spec.URL)
+        plan := newPlan("Add the PPA with add-apt-repository, which writes a file in "+aptSourcesDir+
+            " and fetches the PPA signing key from Launchpad", rootStep(args...)).forAction("repo-add", spec.URL)
+        return m.env.runOrPrint(plan, opts)
+    }
+
+    if err := checkRepoName(spec.Name); err != nil {
+        return err
+    }
+    file := filepath.Join(aptSourcesDir, spec.Name+".sources")
+    suite, components := spec.Suite, spec.Components
+    if suite == "" {
+        suite = "stable"
+    }
+    if components == "" {
+        components = "main"
+    }
+    content := "Types: deb\nURIs: " + spec.URL + "\nSuites: " + suite + "\nComponents: " + components + "\n"
+
+    explanation := "Write " + file
+    var steps []Step
+    if spec.Key != "" {
+        keyFile := filepath.Join(aptKeyringDir, keyFileName(spec.Name, spec.Key))
+        download, err := m.env.downloadStep(spec.Key, keyFile)
+        if err != nil {
+            return err
+        }
+        steps = append(steps, rootStep("install", "-d", "-m", "0755", aptKeyringDir), download)
+        content += "Signed-By: " + keyFile + "\n"
+        explanation = "Save the signing key to " + keyFile + ", write " + file + " so apt only trusts that key for this repository,"
+    }
+    steps = append(steps,
+        rootStep("tee", file).withInput(content),
+        rootStep("apt", "update"),
+    )
+    plan := newPlan(explanation+" and refresh the package lists", steps...).forAction("repo-add", spec.Name)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// RemoveRepo deletes the repository file and the key saved for it.
+func (m *aptManager) RemoveRepo(id string, opts Options) error {
+    repo, err := m.repoFile(id, opts)
+    if err != nil {
+        return err
+    }
+    files := []string{repo.File}
+    keys, err := globFiles(filepath.Join(aptKeyringDir, id+".*"))
+    if err != nil {
+        return err
+    }
+    files = append(files, keys...)
+
+    rm := append([]string{"rm", "-f"}, files...)
+    plan := newPlan("Delete "+strings.Join(files, " and ")+" and refresh the package lists",
+        rootStep(rm...), rootStep("apt", "update")).forAction("repo-remove", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *aptManager) EnableRepo(id string, opts Options) error {
+    return m.setRepoEnabled(id, true, opts)
+}
+
+func (m *aptManager) DisableRepo(id string, opts Options) error {
+    return m.setRepoEnabled(id, false, opts)
+}
+
+// setRepoEnabled sets Enabled in deb822 files, and comments or
+// uncomments the deb lines in one line style files.
+func (m *aptManager) setRepoEnabled(id string, enabled bool, opts Options) error {
+    repo, err := m.repoFile(id, opts)
+    if err != nil {
+        return err
+    }
+
+    var sed []string
+    switch {
+    case strings.HasSuffix(repo.File, ".sources") && enabled:
+        sed = []string{"sed", "-i", "/^Enabled:/d", repo.File}
+    case strings.HasSuffix(repo.File, ".sources"):
+        sed = []string{"sed", "-i", "-e", "/^Enabled:/d", "-e", "/^Types:/i Enabled: no", repo.File}
+    case enabled:
+        sed = []string{"sed", "-i", "-E", `s/^#[[:space:]]*(deb(-src)?[[:space:]])/\1/`, repo.File}
+    default:
+        sed = []string{"sed", "-i", "-E", `s/^(deb(-src)?[[:space:]])/# \1/`, repo.File}
+    }
+
+    action, verb := "repo-enable", "Enable"
+    if !enabled {
+        action, verb = "repo-disable", "Disable"
+    }
+    plan := newPlan(verb+" the repository in "+repo.File+" and refresh the package lists",
+        rootStep(sed...), rootStep("apt", "update")).forAction(action, id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// aptDistroSources are the deb822 files Debian and Ubuntu installers
+// write for the distribution's own repositories. No package owns them,
+// so dpkg cannot tell.
+var aptDistroSources = []string{"debian.sources", "ubuntu.sources"}
+
+// repoFile finds the file in sources.list.d behind id. The main
+// sources.list, the distribution's own .sources file, and files that
+// come with a package hold repositories penguinguide did not add, so
+// they are left for people to edit by hand.
+func (m *aptManager) repoFile(id string, opts Options) (Repo, error) {
+    repos, err := m.Repos(opts)
+    if err != nil {
+        return Repo{}, err
+    }
+    repo, err := findRepo(repos, id)
+    if err != nil {
+        return Repo{}, err
+    }
+    if repo.File == aptSourcesList || slices.Contains(aptDistroSources, filepath.Base(repo.File)) {
+        return Repo{}, fmt.Errorf("%s holds the distribution's own repositories, edit it by hand if you need to", repo.File)
+    }
+
+    // dpkg exits with 1 when no package owns the file.
+    step := userStep("dpkg", "--search", repo.File).allowExit(1)
+    out, err := m.env.query(newPlan("Check whether a package owns the repository file with dpkg", step), opts)
+    if err != nil {
+        return Repo{}, err
+    }
+    if owners := parseDebProviders(string(out)); len(owners) > 0 {
+        return Repo{}, fmt.Errorf("%s comes with the %s package, which would put it back or break on its next update, edit it by hand if you need to", repo.File, owners[0].Package)
+    }
+    return repo, nil
+}
+
+// parseAptSourcesList parses one line style sources file:
+//
+//	deb [arch=amd64 signed-by=/etc/apt/keyrings/docker.asc] https://download.docker.com/linux/ubuntu jammy stable
+//	# deb http://archive.canonical.com/ubuntu jammy partner
+func parseAptSourcesList(id, file, out string) []Repo {
+    var repos []Repo
+    for _, line := range strings.Split(out, "\n") {
+        line = strings.TrimSpace(line)
+        enabled := true
+        if strings.HasPrefix(line, "#") {
+            line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+            enabled = false
+        }
+        fields := strings.Fields(line)
+        if len(fields) < 3 || fields[0] != "deb" {
+            continue
+        }
+        fields = fields[1:]
+        if strings.HasPrefix(fields[0], "[") {
+            for len(fields) > 0 && !strings.HasSuffix(fields[0], "]") {
+                fields = fields[1:]
+            }
+            if len(fields) > 0 {
+                fields = fields[1:]
+            }
+        }
+        if len(fields) < 2 {
+            continue
+        }
+        repos = append(repos, Repo{
+            ID:      id,
+            Name:    strings.Join(fields[1:], " "),
+            URL:     fields[0],
+            Enabled: enabled,
+            File:    file,
+        })
+    }
+    return repos
+}
+
+// parseDeb822Sources parses a deb822 style .sources file, where each
+// repository is a block of "Key: value" lines.
+func parseDeb822Sources(id, file, out string) []Repo {
+    var repos []Repo
+    for _, block := range strings.Split(out, "\n\n") {
+        fields := make(map[string]string)
+        for _, line := range strings.Split(block, "\n") {
+            if strings.HasPrefix(line, "#") {
+                continue
+            }
+            key, value, ok := strings.Cut(line, ":")
+            if ok && !strings.HasPrefix(line, " ") {
+                fields[strings.ToLower(key)] = strings.TrimSpace(value)
+            }
+        }
+        if fields["uris"] == "" || !strings.Contains(fields["types"], "deb") {
+            continue
+        }
+        repos = append(repos, Repo{
+            ID:      id,
+            Name:    strings.TrimSpace(fields["suites"] + " " + fields["components"]),
+            URL:     strings.Fields(fields["uris"])[0],
+            Enabled: strings.ToLower(fields["enabled"]) != "no",
+            File:    file,
+        })
+    }
+    return repos
+}
+
+// Versions lists what apt-cache policy reports, which is every version
+// in the configured repositories plus the installed one.
+func (m *aptManager) Versions(name string, opts Options) ([]PackageVersion, error) {
+    out, err := m.env.query(newPlan("List available versions with apt-cache policy", userStep("apt-cache", "policy", "--", name)), opts)
+    if err != nil {
+        return nil, err
+    }
+    versions := parseAptPolicy(string(out))
+    if len(versions) == 0 {
+        return nil, ErrPackageNotFound
+    }
+    return mergeVersions(versions), nil
+}
+
+func (m *aptManager) Downgrade(name, version string, opts Options) error {
+    args := []string{"apt", "install", "--allow-downgrades"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(args, "--", name+"="+version)
+    plan := newPlan("Install "+name+" "+version+" with apt, allowing it to replace a newer version. "+
+        "The next upgrade replaces it again, unless you hold the package", rootStep(args...)).forAction("downgrade", name)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// parseAptPolicy parses the version table from apt-cache policy. The
+// installed version is marked with ***, and /var/lib/dpkg/status is
+// not a repository:
+//
+//	htop:
+//	  Installed: 3.2.2-2
+//	  Candidate: 3.2.2-2
+//	  Version table:
+//	 *** 3.2.2-2 500
+//	        500 http://deb.debian.org/debian bookworm/main amd64 Packages
+//	        100 /var/lib/dpkg/status
+//	     3.0.5-7 500
+//	        500 http://deb.debian.org/debian bullseye/main amd64 Packages
+func parseAptPolicy(out string) []PackageVersion {
+    var versions []PackageVersion
+    inTable := false
+    for _, line := range strings.Split(out, "\n") {
+        trimmed := strings.TrimSpace(line)
+        if trimmed == "Version table:" {
+            inTable = true
+            continue
+        }
+        if !inTable || trimmed == "" {
+            continue
+        }
+        fields := strings.Fields(trimmed)
+        installed := fields[0] == "***"
+        if installed {
+            fields = fields[1:]
+        }
+        // Version lines are indented less than the sources under them.
+        if installed || len(line)-len(strings.TrimLeft(line, " ")) <= 5 {
+            if len(fields) == 2 {
+                versions = append(versions, PackageVersion{Version: fields[0], Installed: installed})
+            }
+            continue
+        }
+        if len(versions) == 0 || len(fields) < 3 || strings.HasPrefix(fields[1], "/var/lib/dpkg") {
+            continue
+        }
+        current := &versions[len(versions)-1]
+        if current.Repository == "" {
+            current.Repository = fields[2]
+        }
+    }
+    return versions
+}
+
+func (m *aptManager) PackageNames(opts Options) ([]string, error) {
+    out, err := m.env.query(newPlan("List every package name apt knows", userStep("apt-cache", "pkgnames")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return sortedNames(nameSet(string(out))), nil
+}
+
+// aptDepsFlags keeps apt-cache to hard dependencies. Recommends are
+// installed by default too, but removing them breaks nothing.
+var aptDepsFlags = []string{"--no-recommends", "--no-suggests", "--no-conflicts", "--no-breaks", "--no-replaces", "--no-enhances"}
+
+func (m *aptManager) Deps(name string, opts Options) ([]string, error) {
+    // apt-cache exits with 100 when it does not know the package.
+    args := append([]string{"apt-cache", "depends"}, aptDepsFlags...)
+    step := userStep(append(args, "--", name)...).allowExit(100)
+    out, err := m.env.query(newPlan("List what the package depends on with apt-cache", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    deps, ok := parseAptDepends(name, string(out))
+    if !ok {
+        return nil, ErrPackageNotFound
+    }
+    return deps, nil
+}
+
+func (m *aptManager) ReverseDeps(name string, opts Options) ([]string, error) {
+    args := append([]string{"apt-cache", "rdepends", "--installed"}, aptDepsFlags...)
+    step := userStep(append(args, "--", name)...).allowExit(100)
+    out, err := m.env.query(newPlan("List installed packages that depend on the package with apt-cache", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    deps, ok := parseAptDepends(name, string(out))
+    if !ok {
+        return nil, ErrPackageNotFound
+    }
+    return deps, nil
+}
+
+// parseAptDepends parses apt-cache depends and rdepends output. The
+// first line is the package itself, and ok is false when it is missing
+// because apt does not know the package. Alternatives start with |,
+// and virtual packages in <> are left out because only their
+// providers can be installed.
+func parseAptDepends(name, out string) ([]string, bool) {
+    lines := strings.Split(out, "\n")
+    if strings.TrimSpace(lines[0]) != name {
+        return nil, false
+    }
+    set := make(map[string]bool)
+    for _, line := range lines[1:] {
+        line = strings.TrimLeft(line, " |")
+        if line == "" || line == "Reverse Depends:" {
+            continue
+        }
+        if i := strings.Index(line, ": "); i > 0 {
+            if kind := line[:i]; kind != "Depends" && kind != "PreDepends" {
+                continue
+            }
+            line = line[i+2:]
+        }
+        dep, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+        if dep == "" || strings.HasPrefix(dep, "<") {
+            continue
+        }
+        set[dep] = true
+    }
+    return depNames(set, name), true
+}
@@ -0,0 +1,182 @@
+package pkgmgr
+
+import (
+    "errors"
+    "fmt"
+
+    "penguinguide/internal/ui"
+)
+
+/********** Image-based systems **********/
+
+// explainAtomic tells people how package changes work on an
+// image-based system before they make one, and what to use instead
+// for apps and development tools.
+func explainAtomic(env Env, tool string) {
+    fmt.Fprintln(env.Out, ui.Warning("This is an image-based system, so packages go into a new image with "+tool+"."))
+    fmt.Fprintln(env.Out, "  The change only takes effect after you reboot, and every package added")
+    fmt.Fprintln(env.Out, "  this way makes updates slower. For desktop apps, Flatpak is the usual")
+    fmt.Fprintln(env.Out, "  choice: "+ui.Value("penguinguide install <app> --source flatpak")+". For development tools,")
+    fmt.Fprintln(env.Out, "  a toolbox or distrobox container gives you a normal, writable system.")
+    fmt.Fprintln(env.Out)
+}
+
+// rpmOstreeManager layers packages on Fedora Silverblue, Kinoite, and
+// other OSTree systems. rpm-ostree asks for permission through polkit
+// itself, so its steps do not need sudo. Searches and the other
+// read-only questions go through dnf, which can still read the
+// repositories and the rpm database. Holds, repositories, and cache
+// cleanup are left out, since dnf cannot change this system.
+type rpmOstreeManager struct {
+    env Env
+    dnf *dnfManager
+}
+
+func (m *rpmOstreeManager) UpdateAll(opts Options) error {
+    explainAtomic(m.env, "rpm-ostree")
+    plan := newPlan("Download the newest system image with rpm-ostree. It is used from the next boot on",
+        userStep("rpm-ostree", "upgrade"),
+    ).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *rpmOstreeManager) Install(pkgs []string, opts Options) error {
+    explainAtomic(m.env, "rpm-ostree")
+    args := append([]string{"rpm-ostree", "install"}, versionedArgs(pkgs, "-")...)
+    plan := newPlan("Layer packages onto the system image with rpm-ostree. They are available after a reboot", userStep(args...)).forAction("install", packageNames(pkgs)...)
+    return notFound(m.env.runOrPrint(plan, opts), rpmOstreeNotFound)
+}
+
+func (m *rpmOstreeManager) Remove(pkgs []string, opts Options) error {
+    args := append([]string{"rpm-ostree", "uninstall"}, pkgs...)
+    plan := newPlan("Remove layered packages with rpm-ostree. They are gone after a reboot. Packages that are part of the base image cannot be removed this way", userStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *rpmOstreeManager) Search(query string, opts Options) ([]SearchResult, error) {
+    return m.dnf.Search(query, opts)
+}
+
+func (m *rpmOstreeManager) Info(name string, opts Options) (*PackageInfo, error) {
+    return m.dnf.Info(name, opts)
+}
+
+func (m *rpmOstreeManager) List(opts Options) ([]InstalledPackage, error) {
+    return m.dnf.List(opts)
+}
+
+func (m *rpmOstreeManager) CheckUpdates(opts Options) ([]Upgrade, error) {
+    return m.dnf.CheckUpdates(opts)
+}
+
+func (m *rpmOstreeManager) Owner(path string, opts Options) ([]Provider, error) {
+    return m.dnf.Owner(path, opts)
+}
+
+func (m *rpmOstreeManager) Provides(command string, opts Options) ([]Provider, error) {
+    return m.dnf.Provides(command, opts)
+}
+
+func (m *rpmOstreeManager) PackageNames(opts Options) ([]string, error) {
+    return m.dnf.PackageNames(opts)
+}
+
+func (m *rpmOstreeManager) Deps(name string, opts Options) ([]string, error) {
+    return m.dnf.Deps(name, opts)
+}
+
+func (m *rpmOstreeManager) ReverseDeps(name string, opts Options) ([]string, error) {
+    return m.dnf.ReverseDeps(name, opts)
+}
+
+func (m *rpmOstreeManager) Versions(name string, opts Options) ([]PackageVersion, error) {
+    return m.dnf.Versions(name, opts)
+}
+
+// errOstreeDowngrade is returned by Downgrade, because rpm-ostree can
+// only go back a whole image at a time.
+var errOstreeDowngrade = errors.New("rpm-ostree cannot downgrade a single package, run rpm-ostree rollback to boot the previous image instead")
+
+func (m *rpmOstreeManager) Downgrade(name, version string, opts Options) error {
+    return errOstreeDowngrade
+}
+
+// transactionalManager installs packages on openSUSE MicroOS, Aeon,
+// and SLE Micro, where transactional-update runs zypper inside a new
+// snapshot. Searches and the other read-only questions go through
+// zypper directly.
+type transactionalManager struct {
+    env    Env
+    zypper *zypperManager
+}
+
+// transactionalArgs starts a transactional-update command. Without
+// --yes, zypper inside the snapshot asks before it changes anything.
+func transactionalArgs(opts Options, args ...string) []string {
+    argv := []string{"transactional-update"}
+    if opts.AssumeYes {
+        argv = append(argv, "--non-interactive")
+    }
+    return append(argv, args...)
+}
+
+func (m *transactionalManager) UpdateAll(opts Options) error {
+    explainAtomic(m.env, "transactional-update")
+    plan := newPlan("Update every package into a new snapshot with transactional-update. It is used from the next boot on",
+        rootStep(transactionalArgs(opts, "dup")...),
+    ).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *transactionalManager) Install(pkgs []string, opts Options) error {
+    explainAtomic(m.env, "transactional-update")
+    args := append(transactionalArgs(opts, "pkg", "install"), versionedArgs(pkgs, "=")...)
+    plan := newPlan("Install packages into a new snapshot with transactional-update. They are available after a reboot", rootStep(args...)).forAction("install", packageNames(pkgs)...)
+    return notFound(m.env.runOrPrint(plan, opts), zypperNotFound)
+}
+
+func (m *transactionalManager) Remove(pkgs []string, opts Options) error {
+    args := append(transactionalArgs(opts, "pkg", "remove"), pkgs...)
+    plan := newPlan("Remove packages in a new snapshot with transactional-update. They are gone after a reboot", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *transactionalManager) Search(query string, opts Options) ([]SearchResult, error) {
+    return m.zypper.Search(query, opts)
+}
+
+func (m *transactionalManager) Info(name string, opts Options) (*PackageInfo, error) {
+    return m.zypper.Info(name, opts)
+}
+
+func (m *transactionalManager) List(opts Options) ([]InstalledPackage, error) {
+    return m.zypper.List(opts)
+}
+
+func (m *transactionalManager) CheckUpdates(opts Options) ([]Upgrade, error) {
+    return m.zypper.CheckUpdates(opts)
+}
+
+func (m *transactionalManager) Owner(path string, opts Options) ([]Provider, error) {
+    return m.zypper.Owner(path, opts)
+}
+
+func (m *transactionalManager) Provides(command string, opts Options) ([]Provider, error) {
+    return m.zypper.Provides(command, opts)
+}
+
+func (m *transactionalManager) PackageNames(opts Options) ([]string, error) {
+    return m.zypper.PackageNames(opts)
+}
+
+func (m *transactionalManager) Versions(name string, opts Options) ([]PackageVersion, error) {
+    return m.zypper.Versions(name, opts)
+}
+
+func (m *transactionalManager) Downgrade(name, version string, opts Options) error {
+    explainAtomic(m.env, "transactional-update")
+    args := transactionalArgs(opts, "pkg", "install", "--oldpackage", name+"="+version)
+    plan := newPlan("Install "+name+" "+version+" into a new snapshot with transactional-update, allowing it to replace a newer version. "+
+        "It is used after a reboot, and the next update replaces it again", rootStep(args...)).forAction("downgrade", name)
+    return m.env.runOrPrint(plan, opts)
+}
@@ -0,0 +1,186 @@
+package pkgmgr
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "penguinguide/internal/ui"
+)
+
+/********** AUR **********/
+
+// aurHelpers are the AUR helpers penguinguide knows, in order of preference.
+var aurHelpers = []string{"paru", "yay"}
+
+// findAURHelper returns the first AUR helper that is installed, or "".
+func findAURHelper(env Env) string {
+    for _, helper := range aurHelpers {
+        if env.has(helper) {
+            return helper
+        }
+    }
+    return ""
+}
+
+// aurManager is pacmanManager with an AUR helper such as yay or paru.
+// The helper handles repo packages and AUR packages alike, and must run
+// as the normal user because makepkg refuses to build as root. It calls
+// sudo itself when it installs the built package. Everything that only
+// reads the local database is left to pacman.
+type aurManager struct {
+    *pacmanManager
+    helper string
+}
+
+func (m *aurManager) UpdateAll(opts Options) error {
+    args := []string{m.helper, "-Syu"}
+    if opts.AssumeYes {
+        args = append(args, "--noconfirm")
+    }
+    plan := newPlan("Update repo and AUR packages with "+m.helper+", which asks for sudo itself when it installs",
+        userStep(args...)).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+// Install shows the PKGBUILD of every requested AUR package and asks
+// the user to confirm they trust it before anything is built.
+func (m *aurManager) Install(pkgs []string, opts Options) error {
+    // Packages with a version come from the pacman cache, not the AUR.
+    var pinned, rest []string
+    for _, p := range pkgs {
+        if _, version := SplitVersion(p); version != "" {
+            pinned = append(pinned, p)
+        } else {
+            rest = append(rest, p)
+        }
+    }
+    if len(pinned) > 0 {
+        if err := m.pacmanManager.Install(pinned, opts); err != nil || len(rest) == 0 {
+            return err
+        }
+        pkgs = rest
+    }
+
+    aur, err := m.aurOnly(pkgs, opts)
+    if err != nil {
+        return err
+    }
+    if len(aur) > 0 {
+        ok, err := m.review(aur, opts)
+        if err != nil || !ok {
+            return err
+        }
+    }
+
+    args := []string{m.helper, "-S"}
+    if opts.AssumeYes {
+        args = append(args, "--noconfirm")
+    }
+    args = append(args, pkgs...)
+    explanation := "Install packages with " + m.helper
+    if len(aur) > 0 {
+        explanation += ". AUR packages are built as your user, and " + m.helper + " asks for sudo only to install the result"
+    }
+    plan := newPlan(explanation, userStep(args...)).forAction("install", pkgs...)
+    return notFound(m.env.runOrPrint(plan, opts), aurNotFound)
+}
+
+func (m *aurManager) Search(query string, opts Options) ([]SearchResult, error) {
+    args := append([]string{m.helper, "-Ss"}, searchTerms(query)...)
+    // Like pacman, the helpers exit with 1 when nothing matched.
+    step := userStep(args...).allowExit(1)
+    out, err := m.env.query(newPlan("Search the repos and the AUR with "+m.helper, step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parsePacmanSearch(string(out)), nil
+}
+
+// Info asks pacman first, and the AUR only for packages the repos
+// and the local database do not know.
+func (m *aurManager) Info(name string, opts Options) (*PackageInfo, error) {
+    info, err := m.pacmanManager.Info(name, opts)
+    if !errors.Is(err, ErrPackageNotFound) {
+        return info, err
+    }
+
+    step := userStep(m.helper, "-Si", "--aur", name).allowExit(1)
+    out, err := m.env.query(newPlan("Show AUR package details with "+m.helper, step), opts)
+    if err != nil {
+        return nil, err
+    }
+    info = parsePacmanInfo(string(out))
+    if info.Name == "" {
+        return nil, ErrPackageNotFound
+    }
+    if info.Repository == "" {
+        info.Repository = "aur"
+    }
+    return info, nil
+}
+
+// CheckUpdates adds pending AUR upgrades to the repo upgrades.
+func (m *aurManager) CheckUpdates(opts Options) ([]Upgrade, error) {
+    upgrades, err := m.pacmanManager.CheckUpdates(opts)
+    if err != nil {
+        return nil, err
+    }
+    // The helpers exit with 1 when no AUR package has an upgrade.
+    step := userStep(m.helper, "-Qua").allowExit(1)
+    out, err := m.env.query(newPlan("List AUR packages with newer versions with "+m.helper, step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return append(upgrades, parsePacmanUpgrades(string(out))...), nil
+}
+
+// aurOnly returns the packages that are not in the configured repos,
+// which the helper will fetch from the AUR.
+func (m *aurManager) aurOnly(pkgs []string, opts Options) ([]string, error) {
+    var aur []string
+    for _, name := range pkgs {
+        // pacman exits with 1 for packages the repos do not have.
+        step := userStep("pacman", "-Si", name).allowExit(1)
+        out, err := m.env.query(newPlan("Check whether the package is in the official repos", step), opts)
+        if err != nil {
+            return nil, err
+        }
+        if strings.TrimSpace(string(out)) == "" {
+            aur = append(aur, name)
+        }
+    }
+    return aur, nil
+}
+
+// review prints the warning and the PKGBUILD of each AUR package, and
+// asks whether to go on. With --yes the PKGBUILDs are still printed
+// but the question is skipped.
+func (m *aurManager) review(pkgs []string, opts Options) (bool, error) {
+    fmt.Fprintln(m.env.Out, ui.Warning("These packages come from the AUR: "+strings.Join(pkgs, " ")))
+    fmt.Fprintln(m.env.Out, "  The AUR is a collection of build scripts uploaded by other users.")
+    fmt.Fprintln(m.env.Out, "  Arch does not check them, and a PKGBUILD can run any command on your")
+    fmt.Fprintln(m.env.Out, "  computer while it builds. Read it and make sure it only downloads")
+    fmt.Fprintln(m.env.Out, "  from the project's own site before you continue.")
+    fmt.Fprintln(m.env.Out)
+
+    for _, name := range pkgs {
+        step := userStep(m.helper, "-Gp", name)
+        out, err := m.env.query(newPlan("Print the PKGBUILD that will build "+name, step), opts)
+        if err != nil {
+            return false, err
+        }
+        fmt.Fprintln(m.env.Out, ui.Heading("PKGBUILD for "+name))
+        fmt.Fprintln(m.env.Out, strings.TrimRight(string(out), "\n"))
+        fmt.Fprintln(m.env.Out)
+    }
+
+    if opts.AssumeYes {
+        return true, nil
+    }
+    if !m.env.Prompter.Confirm("Have you read the PKGBUILD and do you trust it") {
+        fmt.Fprintln(m.env.Out, ui.Muted("Skipped installing AUR packages."))
+        return false, nil
+    }
+    return true, nil
+}
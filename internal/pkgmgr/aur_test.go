@@ -0,0 +1,97 @@
+package pkgmgr
+
+import (
+    "bytes"
+    "reflect"
+    "strings"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func newAURTestManager(answers ...bool) (Manager, *pkgmgrtest.Runner, *pkgmgrtest.Prompter, *bytes.Buffer) {
+    runner := &pkgmgrtest.Runner{Installed: []string{"yay"}}
+    prompter := &pkgmgrtest.Prompter{Answers: answers}
+    var out bytes.Buffer
+    env := Env{Runner: runner, Prompter: prompter, Out: &out}
+    return NewWithEnv(&distro.Distro{Family: distro.FamilyArch, ID: "arch"}, env), runner, prompter, &out
+}
+
+// This test checks that an installed helper switches Arch to the AUR manager.
+func TestNewPicksAURHelper(t *testing.T) {
+    mgr, _, _, _ := newAURTestManager()
+    aur, ok := mgr.(*aurManager)
+    if !ok || aur.helper != "yay" {
+        t.Fatalf("NewWithEnv() = %#v, want aurManager using yay", mgr)
+    }
+}
+
+// This test checks that AUR installs show the PKGBUILD, ask for trust,
+// and run the helper without sudo.
+func TestAURInstallReview(t *testing.T) {
+    mgr, runner, prompter, out := newAURTestManager(true)
+    runner.Results = map[string]pkgmgrtest.Result{
+        "pacman -Si htop":                    {Output: "Repository : extra\nName : htop\n"},
+        "pacman -Si visual-studio-code-bin": {Err: &ExitError{Argv: []string{"pacman"}, Code: 1}},
+        "yay -Gp visual-studio-code-bin":     {Output: "pkgname=visual-studio-code-bin\nsource=(https://update.code.visualstudio.com/)\n"},
+    }
+
+    if err := mgr.Install([]string{"htop", "visual-studio-code-bin"}, Options{}); err != nil {
+        t.Fatalf("Install error = %v", err)
+    }
+    want := []string{
+        "pacman -Si htop",
+        "pacman -Si visual-studio-code-bin",
+        "yay -Gp visual-studio-code-bin",
+        "yay -S htop visual-studio-code-bin",
+    }
+    if got := runner.Commands(); !reflect.DeepEqual(got, want) {
+        t.Fatalf("commands = %q, want %q", got, want)
+    }
+    if len(prompter.Questions) != 1 || !strings.Contains(prompter.Questions[0], "PKGBUILD") {
+        t.Fatalf("questions = %q, want the PKGBUILD review", prompter.Questions)
+    }
+    if !strings.Contains(out.String(), "source=(https://update.code.visualstudio.com/)") {
+        t.Fatalf("PKGBUILD was not shown:\n%s", out.String())
+    }
+}
+
+// This test checks that declining the review builds nothing.
+func TestAURInstallDeclined(t *testing.T) {
+    mgr, runner, _, _ := newAURTestManager(false)
+    runner.Results = map[string]pkgmgrtest.Result{
+        "pacman -Si paru-bin": {Err: &ExitError{Argv: []string{"pacman"}, Code: 1}},
+    }
+    if err := mgr.Install([]string{"paru-bin"}, Options{}); err != nil {
+        t.Fatalf("Install error = %v", err)
+    }
+    for _, cmd := range runner.Commands() {
+        if strings.HasPrefix(cmd, "yay -S ") {
+            t.Fatalf("ran %q after the review was declined", cmd)
+        }
+    }
+}
+
+// This test checks that yay search output labels AUR results.
+func TestAURSearch(t *testing.T) {
+    mgr, runner, _, _ := newAURTestManager()
+    runner.Results = map[string]pkgmgrtest.Result{
+        "yay -Ss -- htop": {Output: `aur/htop-vim 3.3.0-1 (+7 0.00) 
+    Interactive process viewer with vim keybindings
+extra/htop 3.3.0-1 (174.5 KiB 440.8 KiB) (Installed)
+    Interactive process viewer
+`},
+    }
+    got, err := mgr.Search("htop", Options{})
+    if err != nil {
+        t.Fatalf("Search error = %v", err)
+    }
+    want := []SearchResult{
+        {Name: "htop-vim", Version: "3.3.0-1", Repository: "aur", Summary: "Interactive process viewer with vim keybindings"},
+        {Name: "htop", Version: "3.3.0-1", Repository: "extra", Summary: "Interactive process viewer", Installed: true},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("Search() = %+v, want %+v", got, want)
+    }
+}
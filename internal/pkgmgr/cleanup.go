@@ -0,0 +1,195 @@
+package pkgmgr
+
+import (
+    "io/fs"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// CleanupTask is one way to free disk space, such as clearing the
+// package cache or removing orphaned dependencies. Size is how many
+// bytes it would free, or zero when that cannot be measured. Packages
+// lists what would be removed, for tasks that remove packages.
+type CleanupTask struct {
+    Name        string   `json:"name"`
+    Description string   `json:"description"`
+    Size        int64    `json:"size"`
+    Packages    []string `json:"packages,omitempty"`
+    Command     string   `json:"command"`
+
+    plan Plan
+}
+
+// Cleaner is implemented by managers that can free disk space.
+type Cleaner interface {
+    // CleanupTasks measures what can be cleaned up without changing anything.
+    // Tasks with nothing to do are left out.
+    CleanupTasks(opts Options) ([]CleanupTask, error)
+
+    // Cleanup runs one task returned by CleanupTasks.
+    Cleanup(task CleanupTask, opts Options) error
+}
+
+func (e Env) newCleanupTask(name, description string, size int64, plan Plan) CleanupTask {
+    return CleanupTask{
+        Name:        name,
+        Description: description,
+        Size:        size,
+        Packages:    plan.Packages,
+        Command:     e.elevate(plan).String(),
+        plan:        plan,
+    }
+}
+
+// cacheTask returns a task that runs plan to clear the cache in dir,
+// or false when the cache is empty.
+func (e Env) cacheTask(dir string, plan Plan) (CleanupTask, bool) {
+    size := dirSize(dir)
+    if size == 0 {
+        return CleanupTask{}, false
+    }
+    return e.newCleanupTask("cache", "Downloaded package files kept in "+dir, size, plan), true
+}
+
+// orphanTask returns a task that runs plan to remove orphans, sized with
+// the installed sizes the lister reports, or false when there are none.
+func (e Env) orphanTask(lister Lister, orphans []string, plan Plan, opts Options) (CleanupTask, bool, error) {
+    if len(orphans) == 0 {
+        return CleanupTask{}, false, nil
+    }
+    installed, err := lister.List(opts)
+    if err != nil {
+        return CleanupTask{}, false, err
+    }
+    wanted := make(map[string]bool, len(orphans))
+    for _, name := range orphans {
+        wanted[name] = true
+    }
+    var size int64
+    for _, p := range installed {
+        if wanted[p.Name] {
+            size += p.Size
+        }
+    }
+    plan = plan.forAction("remove", orphans...)
+    task := e.newCleanupTask("orphans", "Dependencies that no installed package needs any more", size, plan)
+    return task, true, nil
+}
+
+// dirSize adds up the size of every file under dir. Files that cannot
+// be read are skipped. It is a variable so tests do not depend on the
+// local filesystem.
+var dirSize = func(dir string) int64 {
+    // Caches such as /etc/apk/cache are often symlinks.
+    if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+        dir = resolved
+    }
+    var size int64
+    filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return nil
+        }
+        if d.Type().IsRegular() {
+            if info, err := d.Info(); err == nil {
+                size += info.Size()
+            }
+        }
+        return nil
+    })
+    return size
+}
+
+// dirFiles returns the size of each regular file directly in dir, keyed
+// by file name. It is a variable for the same reason as dirSize.
+var dirFiles = func(dir string) map[string]int64 {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil
+    }
+    files := make(map[string]int64, len(entries))
+    for _, entry := range entries {
+        if !entry.Type().IsRegular() {
+            continue
+        }
+        if info, err := entry.Info(); err == nil {
+            files[entry.Name()] = info.Size()
+        }
+    }
+    return files
+}
+
+// parseAptAutoremove reads the packages apt-get --simulate autoremove
+// would remove from lines such as "Remv libfoo1 [1.2-3]".
+func parseAptAutoremove(out string) []string {
+    var pkgs []string
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Fields(line)
+        if len(fields) >= 2 && fields[0] == "Remv" {
+            pkgs = append(pkgs, fields[1])
+        }
+    }
+    return pkgs
+}
+
+var paccacheSaved = regexp.MustCompile(`disk space saved: ([0-9.]+ [KMGT]?i?B)`)
+
+// parsePaccacheDryRun reads the space paccache -d would free from its
+// summary line, "==> finished dry run: 4 candidates (disk space saved: 42.1 MiB)".
+func parsePaccacheDryRun(out string) int64 {
+    m := paccacheSaved.FindStringSubmatch(out)
+    if m == nil {
+        return 0
+    }
+    return ParseSize(m[1])
+}
+
+// pacmanStaleCache adds up the cache files pacman -Sc deletes, which are
+// those of package versions that are not installed. installed maps
+// names to versions as pacman -Q prints them. Files are named
+// name-version-release-arch.pkg.tar.zst, and a .sig goes with its
+// package.
+func pacmanStaleCache(files map[string]int64, installed map[string]string) int64 {
+    var size int64
+    for file, n := range files {
+        base, _, ok := strings.Cut(strings.TrimSuffix(file, ".sig"), ".pkg.tar")
+        if !ok {
+            continue
+        }
+        parts := strings.Split(base, "-")
+        if len(parts) < 4 {
+            continue
+        }
+        name := strings.Join(parts[:len(parts)-3], "-")
+        version := parts[len(parts)-3] + "-" + parts[len(parts)-2]
+        if installed[name] != version {
+            size += n
+        }
+    }
+    return size
+}
+
+// apkStaleCache adds up the cache files apk cache clean deletes, which
+// are those of package versions that are not installed. installed maps
+// names to versions as apkInstalled returns them. Files are named
+// name-version.checksum.apk, and the APKINDEX files are kept.
+func apkStaleCache(files map[string]int64, installed map[string]string) int64 {
+    var size int64
+    for file, n := range files {
+        base, ok := strings.CutSuffix(file, ".apk")
+        if !ok {
+            continue
+        }
+        if i := strings.LastIndex(base, "."); i > 0 {
+            if j := strings.LastIndex(base[:i], "-r"); j > 0 && isDigits(base[j+2:i]) {
+                base = base[:i]
+            }
+        }
+        name, version := splitNameVersion(base)
+        if installed[name] != version {
+            size += n
+        }
+    }
+    return size
+}
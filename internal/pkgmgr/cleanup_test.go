@@ -0,0 +1,146 @@
+package pkgmgr
+
+import (
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func stubDirSize(t *testing.T, sizes map[string]int64) {
+    old := dirSize
+    dirSize = func(dir string) int64 { return sizes[dir] }
+    t.Cleanup(func() { dirSize = old })
+}
+
+func stubDirFiles(t *testing.T, files map[string]map[string]int64) {
+    old := dirFiles
+    dirFiles = func(dir string) map[string]int64 { return files[dir] }
+    t.Cleanup(func() { dirFiles = old })
+}
+
+func TestParseAptAutoremove(t *testing.T) {
+    out := `NOTE: This is only a simulation!
+Reading package lists...
+The following packages will be REMOVED:
+  libfoo1 linux-image-6.1.0-17-amd64
+Remv libfoo1 [1.2-3]
+Remv linux-image-6.1.0-17-amd64 [6.1.69-1]
+`
+    want := []string{"libfoo1", "linux-image-6.1.0-17-amd64"}
+    if got := parseAptAutoremove(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseAptAutoremove() = %v, want %v", got, want)
+    }
+}
+
+func TestParsePaccacheDryRun(t *testing.T) {
+    out := "==> finished dry run: 4 candidates (disk space saved: 42.5 MiB)\n"
+    if got, want := parsePaccacheDryRun(out), int64(42.5*1024*1024); got != want {
+        t.Fatalf("parsePaccacheDryRun() = %d, want %d", got, want)
+    }
+    if got := parsePaccacheDryRun("==> no candidate packages found for pruning\n"); got != 0 {
+        t.Fatalf("parsePaccacheDryRun() with nothing to do = %d, want 0", got)
+    }
+}
+
+// This test checks that apt cleanup measures the cache and orphans,
+// and that running a task goes through the normal command flow.
+func TestAptCleanupTasks(t *testing.T) {
+    stubDirSize(t, map[string]int64{"/var/cache/apt/archives": 300 << 20})
+    mgr, runner, _ := newTestManager(distro.FamilyDebian, "debian", true)
+    runner.Results = map[string]pkgmgrtest.Result{
+        "apt-get --simulate autoremove": {Output: "Remv libfoo1 [1.2-3]\n"},
+        `dpkg-query --show --showformat ${Package}\t${binary:Package}\t${Version}\t${Installed-Size}\t${db:Status-Abbrev}\n`: {
+            Output: "libfoo1\tlibfoo1:amd64\t1.2-3\t2048\tii \nhtop\thtop:amd64\t3.3.0-4\t400\tii \n",
+        },
+    }
+
+    tasks, err := mgr.(Cleaner).CleanupTasks(Options{DryRun: true})
+    if err != nil {
+        t.Fatalf("CleanupTasks error = %v", err)
+    }
+    if len(tasks) != 2 {
+        t.Fatalf("got %d tasks, want cache and orphans: %+v", len(tasks), tasks)
+    }
+    if tasks[0].Name != "cache" || tasks[0].Size != 300<<20 || tasks[0].Command != "sudo apt clean" {
+        t.Fatalf("cache task = %+v", tasks[0])
+    }
+    orphans := tasks[1]
+    if orphans.Name != "orphans" || orphans.Size != 2048*1024 || !reflect.DeepEqual(orphans.Packages, []string{"libfoo1"}) {
+        t.Fatalf("orphan task = %+v", orphans)
+    }
+
+    runner.Calls = nil
+    if err := mgr.(Cleaner).Cleanup(orphans, Options{DryRun: true}); err != nil {
+        t.Fatalf("Cleanup error = %v", err)
+    }
+    if got, want := runner.Commands(), []string{"sudo apt autoremove"}; !reflect.DeepEqual(got, want) {
+        t.Fatalf("commands = %q, want %q", got, want)
+    }
+}
+
+// This test checks that without paccache the cache task only counts
+// what pacman -Sc deletes, and leaves the installed versions out.
+func TestPacmanCleanupWithoutPaccache(t *testing.T) {
+    stubDirFiles(t, map[string]map[string]int64{pacmanCacheDir: {
+        "htop-3.3.0-1-x86_64.pkg.tar.zst":            100,
+        "htop-3.3.0-1-x86_64.pkg.tar.zst.sig":        1,
+        "htop-3.2.2-2-x86_64.pkg.tar.zst":            90,
+        "vim-2:9.1.0-1-x86_64.pkg.tar.zst":           300,
+        "vim-2:9.0.2-1-x86_64.pkg.tar.zst":           280,
+        "vim-2:9.0.2-1-x86_64.pkg.tar.zst.sig":       1,
+        "lib32-gcc-libs-14.1.1-1-x86_64.pkg.tar.zst": 50,
+        "download-abc123":                            7,
+    }})
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "pacman -Q":    {Output: "htop 3.3.0-1\nvim 2:9.1.0-1\nlib32-gcc-libs 14.1.1-1\n"},
+        "pacman -Qdtq": {Err: &ExitError{Argv: []string{"pacman"}, Code: 1}},
+    }
+    tasks, err := mgr.(Cleaner).CleanupTasks(Options{})
+    if err != nil || len(tasks) != 1 {
+        t.Fatalf("CleanupTasks() = %+v, %v, want one cache task", tasks, err)
+    }
+    if tasks[0].Size != 90+280+1 || tasks[0].Command != "sudo pacman -Sc" {
+        t.Fatalf("cache task = %+v, want 371 bytes freed by sudo pacman -Sc", tasks[0])
+    }
+}
+
+// This test checks that an empty cache and no orphans mean no tasks.
+func TestPacmanCleanupNothingToDo(t *testing.T) {
+    stubDirSize(t, nil)
+    stubDirFiles(t, nil)
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "pacman -Qdtq": {Err: &ExitError{Argv: []string{"pacman"}, Code: 1}},
+    }
+    tasks, err := mgr.(Cleaner).CleanupTasks(Options{})
+    if err != nil || len(tasks) != 0 {
+        t.Fatalf("CleanupTasks() = %+v, %v, want nothing", tasks, err)
+    }
+}
+
+// This test checks that the apk cache task only counts what apk cache
+// clean deletes, and leaves the installed versions and indexes out.
+func TestApkCleanupTasks(t *testing.T) {
+    stubDirFiles(t, map[string]map[string]int64{apkCacheDir: {
+        "htop-3.3.0-r0.5d8a4f2e.apk":     100,
+        "htop-3.2.2-r1.0c1b9e77.apk":     90,
+        "py3-pip-24.0-r2.a1b2c3d4.apk":   40,
+        "py3-pip-23.3.1-r0.e5f6a7b8.apk": 35,
+        "APKINDEX.4a8c2e1f.tar.gz":       900,
+        "installed":                      3,
+    }})
+    mgr, runner, _ := newTestManager(distro.FamilyAlpine, "alpine")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "apk info -v": {Output: "htop-3.3.0-r0\npy3-pip-24.0-r2\n"},
+    }
+    tasks, err := mgr.(Cleaner).CleanupTasks(Options{})
+    if err != nil || len(tasks) != 1 {
+        t.Fatalf("CleanupTasks() = %+v, %v, want one cache task", tasks, err)
+    }
+    if tasks[0].Size != 90+35 || tasks[0].Command != "sudo apk cache clean" {
+        t.Fatalf("cache task = %+v, want 125 bytes freed by sudo apk cache clean", tasks[0])
+    }
+}
@@ -0,0 +1,71 @@
+package pkgmgr
+
+// DepsFinder is implemented by managers that can say what a package
+// needs and what needs it.
+type DepsFinder interface {
+    // Deps returns the packages name depends on directly.
+    Deps(name string, opts Options) ([]string, error)
+
+    // ReverseDeps returns the installed packages that depend on name
+    // directly, which are the ones removing it would break.
+    ReverseDeps(name string, opts Options) ([]string, error)
+}
+
+// DepNode is one package in a dependency tree. Repeated is set when
+// the package is already expanded earlier in the tree, at least as far
+// down as it could be here, so its own dependencies are not listed a
+// second time.
+type DepNode struct {
+    Name         string     `json:"name"`
+    Dependencies []*DepNode `json:"dependencies,omitempty"`
+    Repeated     bool       `json:"repeated,omitempty"`
+}
+
+// DepTree builds the tree below name by calling lookup for each
+// package, going at most depth levels down, or all the way when depth
+// is 0. Pass a DepsFinder's Deps or ReverseDeps as lookup.
+//
+// Only a failure for name itself is returned. Packages further down
+// that cannot be looked up, such as virtual packages, are shown
+// without dependencies.
+func DepTree(name string, depth int, lookup func(string, Options) ([]string, error), opts Options) (*DepNode, error) {
+    children, err := lookup(name, opts)
+    if err != nil {
+        return nil, err
+    }
+
+    // The explanation is the same for every package, so print it once.
+    opts.Explain = false
+    root := &DepNode{Name: name}
+    // expandedAt records the level each package was expanded at. With a
+    // depth limit, a package expanded deep in the tree shows fewer levels
+    // below it, so a shallower appearance later on expands it again.
+    expandedAt := map[string]int{name: 0}
+    var expand func(node *DepNode, children []string, level int)
+    expand = func(node *DepNode, children []string, level int) {
+        for _, child := range children {
+            n := &DepNode{Name: child}
+            node.Dependencies = append(node.Dependencies, n)
+            if at, ok := expandedAt[child]; ok && (depth == 0 || at <= level) {
+                n.Repeated = true
+                continue
+            }
+            if depth > 0 && level >= depth {
+                continue
+            }
+            expandedAt[child] = level
+            if more, err := lookup(child, opts); err == nil {
+                expand(n, more, level+1)
+            }
+        }
+    }
+    expand(root, children, 1)
+    return root, nil
+}
+
+// depNames turns the set of names a backend printed into a sorted
+// list, leaving out the package that was asked about.
+func depNames(set map[string]bool, name string) []string {
+    delete(set, name)
+    return sortedNames(set)
+}
@@ -0,0 +1,195 @@
+package pkgmgr
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func TestDepTree(t *testing.T) {
+    graph := map[string][]string{
+        "htop":    {"glibc", "ncurses"},
+        "ncurses": {"glibc", "gcc-libs"},
+        "glibc":   {"filesystem"},
+    }
+    var explained []string
+    lookup := func(name string, opts Options) ([]string, error) {
+        if opts.Explain {
+            explained = append(explained, name)
+        }
+        if name == "gcc-libs" {
+            return nil, ErrPackageNotFound
+        }
+        return graph[name], nil
+    }
+
+    tree, err := DepTree("htop", 0, lookup, Options{Explain: true})
+    if err != nil {
+        t.Fatalf("DepTree error = %v", err)
+    }
+    want := &DepNode{Name: "htop", Dependencies: []*DepNode{
+        {Name: "glibc", Dependencies: []*DepNode{{Name: "filesystem"}}},
+        {Name: "ncurses", Dependencies: []*DepNode{{Name: "glibc", Repeated: true}, {Name: "gcc-libs"}}},
+    }}
+    if !reflect.DeepEqual(tree, want) {
+        t.Fatalf("DepTree() = %+v, want %+v", tree, want)
+    }
+    if !reflect.DeepEqual(explained, []string{"htop"}) {
+        t.Fatalf("DepTree explained %q, want only the first lookup", explained)
+    }
+
+    tree, err = DepTree("htop", 1, lookup, Options{})
+    if err != nil {
+        t.Fatalf("DepTree error = %v", err)
+    }
+    for _, n := range tree.Dependencies {
+        if len(n.Dependencies) != 0 {
+            t.Fatalf("DepTree with depth 1 expanded %s", n.Name)
+        }
+    }
+
+    // ncurses is first reached below glibc at the depth limit. Its
+    // direct appearance must still be expanded, not marked repeated.
+    graph["glibc"] = []string{"ncurses"}
+    graph["ncurses"] = []string{"terminfo"}
+    tree, err = DepTree("htop", 2, lookup, Options{})
+    if err != nil {
+        t.Fatalf("DepTree error = %v", err)
+    }
+    want = &DepNode{Name: "htop", Dependencies: []*DepNode{
+        {Name: "glibc", Dependencies: []*DepNode{{Name: "ncurses"}}},
+        {Name: "ncurses", Dependencies: []*DepNode{{Name: "terminfo"}}},
+    }}
+    if !reflect.DeepEqual(tree, want) {
+        t.Fatalf("DepTree() with depth 2 = %+v, want %+v", tree, want)
+    }
+
+    // With one more level, ncurses is expanded below glibc first but
+    // shows more when it appears again directly under htop.
+    graph["terminfo"] = []string{"filesystem"}
+    tree, err = DepTree("htop", 3, lookup, Options{})
+    if err != nil {
+        t.Fatalf("DepTree error = %v", err)
+    }
+    want = &DepNode{Name: "htop", Dependencies: []*DepNode{
+        {Name: "glibc", Dependencies: []*DepNode{{Name: "ncurses", Dependencies: []*DepNode{{Name: "terminfo"}}}}},
+        {Name: "ncurses", Dependencies: []*DepNode{{Name: "terminfo", Dependencies: []*DepNode{{Name: "filesystem"}}}}},
+    }}
+    if !reflect.DeepEqual(tree, want) {
+        t.Fatalf("DepTree() with depth 3 = %+v, want %+v", tree, want)
+    }
+
+    if _, err := DepTree("gcc-libs", 0, lookup, Options{}); !errors.Is(err, ErrPackageNotFound) {
+        t.Fatalf("DepTree of an unknown package error = %v, want ErrPackageNotFound", err)
+    }
+}
+
+func TestParseAptDepends(t *testing.T) {
+    out := `htop
+  Depends: libc6
+ |Depends: libncursesw6
+  Depends: libncursesw5
+  PreDepends: dpkg
+  Depends: <python3:any>
+  Depends: libnl-3-200:amd64
+`
+    got, ok := parseAptDepends("htop", out)
+    want := []string{"dpkg", "libc6", "libncursesw5", "libncursesw6", "libnl-3-200"}
+    if !ok || !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseAptDepends() = %q, %v, want %q", got, ok, want)
+    }
+
+    out = `libncursesw6
+Reverse Depends:
+  htop
+ |vim
+  libncursesw6:i386
+`
+    got, ok = parseAptDepends("libncursesw6", out)
+    if want := []string{"htop", "vim"}; !ok || !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseAptDepends() of rdepends = %q, %v, want %q", got, ok, want)
+    }
+
+    if _, ok := parseAptDepends("htpo", ""); ok {
+        t.Fatal("parseAptDepends() of empty output found the package")
+    }
+}
+
+func TestParseApkDepends(t *testing.T) {
+    out := `htop-3.3.0-r0 depends on:
+so:libc.musl-x86_64.so.1
+so:libncursesw.so.6
+ncurses-terminfo-base>=6.4
+
+`
+    got, err := parseApkDepends("htop", out, false)
+    want := []string{"ncurses-terminfo-base", "so:libc.musl-x86_64.so.1", "so:libncursesw.so.6"}
+    if err != nil || !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseApkDepends() = %q, %v, want %q", got, err, want)
+    }
+
+    out = `ncurses-libs-6.4_p20231125-r0 is required by:
+htop-3.3.0-r0
+vim-9.1.0-r1
+`
+    got, err = parseApkDepends("ncurses-libs", out, true)
+    if want := []string{"htop", "vim"}; err != nil || !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseApkDepends() of reverse dependencies = %q, %v, want %q", got, err, want)
+    }
+
+    if _, err := parseApkDepends("htpo", "", false); !errors.Is(err, ErrPackageNotFound) {
+        t.Fatalf("parseApkDepends() of empty output error = %v, want ErrPackageNotFound", err)
+    }
+}
+
+func TestDepsCommands(t *testing.T) {
+    tests := []struct {
+        family    distro.Family
+        id        string
+        installed []string
+        deps      string
+        rdeps     string
+    }{
+        {distro.FamilyDebian, "debian", nil,
+            "apt-cache depends --no-recommends --no-suggests --no-conflicts --no-breaks --no-replaces --no-enhances -- htop",
+            "apt-cache rdepends --installed --no-recommends --no-suggests --no-conflicts --no-breaks --no-replaces --no-enhances -- htop"},
+        {distro.FamilyRHEL, "fedora", nil,
+            "dnf repoquery --quiet --requires --resolve --queryformat %{name} -- htop",
+            "dnf repoquery --quiet --installed --whatrequires=htop --queryformat %{name}"},
+        {distro.FamilyRHEL, "fedora", []string{"dnf5"},
+            `dnf repoquery --quiet --providers-of=requires --queryformat %{name}\n -- htop`,
+            `dnf repoquery --quiet --installed --whatrequires=htop --queryformat %{name}\n`},
+        {distro.FamilyArch, "arch", []string{"pactree"},
+            "pactree -u -d 1 -s -- htop",
+            "pactree -u -d 1 -r -- htop"},
+        {distro.FamilyAlpine, "alpine", nil, "apk info -R -- htop", "apk info -r -- htop"},
+    }
+    for _, tt := range tests {
+        mgr, runner, _ := newTestManager(tt.family, tt.id)
+        runner.Installed = tt.installed
+        runner.Results = map[string]pkgmgrtest.Result{
+            tt.deps:  {Output: "htop\n"},
+            tt.rdeps: {Output: "htop\n"},
+        }
+        finder := mgr.(DepsFinder)
+        finder.Deps("htop", Options{})
+        finder.ReverseDeps("htop", Options{})
+        if got, want := runner.Commands(), []string{tt.deps, tt.rdeps}; !reflect.DeepEqual(got, want) {
+            t.Errorf("%s %v commands = %q, want %q", tt.id, tt.installed, got, want)
+        }
+    }
+}
+
+func TestPactreeMissing(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    var missing *MissingToolError
+    if _, err := mgr.(DepsFinder).Deps("htop", Options{}); !errors.As(err, &missing) || missing.Tool != "pactree" {
+        t.Fatalf("Deps without pactree error = %v, want a MissingToolError", err)
+    }
+    if len(runner.Calls) != 0 {
+        t.Fatalf("Deps without pactree ran %q", runner.Commands())
+    }
+}
@@ -0,0 +1,691 @@
+package pkgmgr
+
+import (
+    "fmt"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+/********** DNF **********/
+
+type dnfManager struct {
+    env Env
+}
+
+func (m *dnfManager) UpdateAll(opts Options) error {
+    args := []string{"dnf", "upgrade"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    plan := newPlan("Update all packages with dnf", rootStep(args...)).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *dnfManager) Install(pkgs []string, opts Options) error {
+    args := []string{"dnf", "install"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(append(args, "--"), versionedArgs(pkgs, "-")...)
+    plan := newPlan("Install packages with dnf", rootStep(args...)).forAction("install", packageNames(pkgs)...)
+    return notFound(m.env.runOrPrint(plan, opts), dnfNotFound)
+}
+
+func (m *dnfManager) Remove(pkgs []string, opts Options) error {
+    args := []string{"dnf", "remove"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(append(args, "--"), pkgs...)
+    plan := newPlan("Remove packages with dnf", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *dnfManager) Search(query string, opts Options) ([]SearchResult, error) {
+    args := append([]string{"dnf", "search"}, searchTerms(query)...)
+    // dnf5 exits with 1 when nothing matched.
+    step := userStep(args...).allowExit(1)
+    out, err := m.env.query(newPlan("Search for packages with dnf", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    results := parseDnfSearch(string(out))
+    if len(results) == 0 {
+        return nil, nil
+    }
+
+    installed, err := rpmInstalled(m.env, opts)
+    if err != nil {
+        return nil, err
+    }
+    markInstalled(results, installed)
+    return results, nil
+}
+
+func (m *dnfManager) Info(name string, opts Options) (*PackageInfo, error) {
+    // dnf exits with 1 when no package matched.
+    step := userStep("dnf", "info", "--", name).allowExit(1)
+    out, err := m.env.query(newPlan("Show package details with dnf", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    info := parseDnfInfo(string(out))
+    if info.Name == "" {
+        return nil, ErrPackageNotFound
+    }
+
+    requires := userStep("dnf", "repoquery", "--quiet", "--requires", "--", name).allowExit(1)
+    reqOut, err := m.env.query(newPlan("List what the package depends on with dnf", requires), opts)
+    if err != nil {
+        return nil, err
+    }
+    info.Dependencies = parseRpmRequires(string(reqOut))
+    return info, nil
+}
+
+// parseDnfSearch parses dnf search output. dnf4 prints
+// "htop.x86_64 : Interactive process viewer" under ==== section headers,
+// dnf5 prints " htop.x86_64<TAB>Interactive process viewer".
+func parseDnfSearch(out string) []SearchResult {
+    var results []SearchResult
+    seen := make(map[string]bool)
+    for _, line := range strings.Split(out, "\n") {
+        trimmed := strings.TrimSpace(line)
+        if trimmed == "" || strings.HasPrefix(trimmed, "=") {
+            continue
+        }
+
+        var name, summary string
+        if i := strings.Index(trimmed, " : "); i > 0 {
+            name, summary = trimmed[:i], trimmed[i+3:]
+        } else if i := strings.Index(trimmed, "\t"); i > 0 {
+            name, summary = trimmed[:i], trimmed[i+1:]
+        } else {
+            continue
+        }
+
+        name = strings.TrimSpace(name)
+        if strings.Contains(name, " ") {
+            // Status lines such as "Last metadata expiration check".
+            continue
+        }
+        name = trimArch(name)
+        if seen[name] {
+            continue
+        }
+        seen[name] = true
+        results = append(results, SearchResult{Name: name, Summary: strings.TrimSpace(summary)})
+    }
+    return results
+}
+
+// parseDnfInfo parses the first package in dnf info output. Packages
+// listed under "Installed Packages" are installed, and their Size is
+// the installed size rather than the download size.
+func parseDnfInfo(out string) *PackageInfo {
+    info := &PackageInfo{Raw: out}
+    body := out
+    for _, line := range strings.Split(out, "\n") {
+        if strings.Contains(line, " : ") {
+            break
+        }
+        if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "installed packages") {
+            info.Installed = true
+        }
+    }
+    if i := strings.Index(body, "\nName"); i >= 0 {
+        body = body[i+1:]
+    }
+
+    fields := parseKeyValues(body)
+    info.Name = fields["name"]
+    info.Version = fields["version"]
+    if release := fields["release"]; release != "" {
+        info.Version += "-" + release
+    }
+    info.Repository = fields["repository"]
+    if info.Repository == "@System" || info.Repository == "System" {
+        info.Installed = true
+        info.Repository = fields["from repo"]
+    }
+    if size, ok := fields["size"]; ok {
+        if info.Installed {
+            info.InstalledSize = ParseSize(size)
+        } else {
+            info.Size = ParseSize(size)
+        }
+    }
+    if size, ok := fields["installed size"]; ok {
+        info.InstalledSize = ParseSize(size)
+    }
+    if size, ok := fields["package size"]; ok {
+        info.Size = ParseSize(size)
+    }
+    info.License = fields["license"]
+    info.Homepage = fields["url"]
+    info.Description = joinLines(fields["description"])
+    return info
+}
+
+func (m *dnfManager) List(opts Options) ([]InstalledPackage, error) {
+    step := userStep("dnf", "repoquery", "--quiet", "--userinstalled")
+    out, err := m.env.query(newPlan("List packages you installed by name with dnf", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    explicit := make(map[string]bool)
+    for line := range nameSet(string(out)) {
+        explicit[nevraName(line)] = true
+    }
+    return rpmList(m.env, opts, explicit)
+}
+
+func (m *dnfManager) CheckUpdates(opts Options) ([]Upgrade, error) {
+    // check-update refreshes expired metadata itself and exits
+    // with 100 when upgrades are available.
+    step := userStep("dnf", "check-update").allowExit(100)
+    out, err := m.env.query(newPlan("Refresh metadata and list upgradable packages with dnf", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    upgrades := parseDnfCheckUpdate(string(out))
+    if len(upgrades) == 0 {
+        return nil, nil
+    }
+
+    installed, err := rpmInstalled(m.env, opts)
+    if err != nil {
+        return nil, err
+    }
+    secStep := userStep("dnf", "updateinfo", "list", "--security")
+    secOut, err := m.env.query(newPlan("List security advisories with dnf", secStep), opts)
+    if err != nil {
+        return nil, err
+    }
+    security := parseDnfSecurity(string(secOut))
+
+    for i := range upgrades {
+        upgrades[i].OldVersion = installed[upgrades[i].Name]
+        upgrades[i].Security = security[upgrades[i].Name]
+    }
+    return upgrades, nil
+}
+
+// parseDnfCheckUpdate parses dnf check-update output, which looks like:
+//
+//	htop.x86_64    3.3.0-4.fc40    updates
+func parseDnfCheckUpdate(out string) []Upgrade {
+    var upgrades []Upgrade
+    seen := make(map[string]bool)
+    for _, line := range strings.Split(out, "\n") {
+        if strings.HasPrefix(line, "Obsoleting") {
+            break
+        }
+        fields := strings.Fields(line)
+        if len(fields) != 3 || !strings.Contains(fields[0], ".") || strings.HasSuffix(fields[0], ":") {
+            continue
+        }
+        name := trimArch(fields[0])
+        if name == fields[0] || seen[name] {
+            continue
+        }
+        seen[name] = true
+        upgrades = append(upgrades, Upgrade{Name: name, NewVersion: fields[1], Repository: fields[2]})
+    }
+    return upgrades
+}
+
+// parseDnfSecurity returns the package names from dnf updateinfo lines:
+//
+//	FEDORA-2024-1a2b3c4d5e important/Sec. openssl-1:3.1.4-3.fc40.x86_64
+func parseDnfSecurity(out string) map[string]bool {
+    names := make(map[string]bool)
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Fields(line)
+        if len(fields) < 3 {
+            continue
+        }
+        names[nevraName(fields[len(fields)-1])] = true
+    }
+    return names
+}
+
+func (m *dnfManager) Owner(path string, opts Options) ([]Provider, error) {
+    return rpmOwner(m.env, path, opts)
+}
+
+func (m *dnfManager) Provides(command string, opts Options) ([]Provider, error) {
+    // dnf exits with 1 when nothing provides the file.
+    step := userStep("dnf", "--quiet", "provides", "*/bin/"+command).allowExit(1)
+    out, err := m.env.query(newPlan("Search every available package for this command with dnf", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseDnfProvides(string(out)), nil
+}
+
+// parseDnfProvides parses dnf provides output, which looks like:
+//
+//	net-tools-2.0-0.69.20160912git.fc40.x86_64 : Basic networking tools
+//	Repo        : fedora
+//	Matched from:
+//	Filename    : /usr/sbin/ifconfig
+func parseDnfProvides(out string) []Provider {
+    var providers []Provider
+    seen := make(map[string]bool)
+    var current *Provider
+    for _, line := range strings.Split(out, "\n") {
+        key, value, ok := strings.Cut(line, " : ")
+        if !ok {
+            continue
+        }
+        key = strings.TrimSpace(key)
+        value = strings.TrimSpace(value)
+        switch key {
+        case "Repo":
+            if current != nil {
+                current.Repository = value
+            }
+        case "Filename":
+            if current != nil && current.Path == "" {
+                current.Path = value
+            }
+        case "Provide", "Other":
+        default:
+            if strings.Contains(key, " ") {
+                continue
+            }
+            name := nevraName(key)
+            if seen[name] {
+                current = nil
+                continue
+            }
+            seen[name] = true
+            providers = append(providers, Provider{Package: name})
+            current = &providers[len(providers)-1]
+        }
+    }
+    return providers
+}
+
+func (m *dnfManager) CleanupTasks(opts Options) ([]CleanupTask, error) {
+    var tasks []CleanupTask
+    clean := newPlan("Delete cached metadata and package files with dnf", rootStep("dnf", "clean", "all")).forAction("cleanup")
+    // dnf5 moved its cache to /var/cache/libdnf5.
+    for _, dir := range []string{"/var/cache/dnf", "/var/cache/libdnf5"} {
+        if task, ok := m.env.cacheTask(dir, clean); ok {
+            tasks = append(tasks, task)
+            break
+        }
+    }
+
+    step := userStep("dnf", "repoquery", "--quiet", "--unneeded")
+    out, err := m.env.query(newPlan("List dependencies that no installed package needs with dnf", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    var orphans []string
+    for line := range nameSet(string(out)) {
+        orphans = append(orphans, nevraName(line))
+    }
+    sort.Strings(orphans)
+
+    args := []string{"dnf", "autoremove"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    remove := newPlan("Remove dependencies that no installed package needs with dnf", rootStep(args...))
+    task, ok, err := m.env.orphanTask(m, orphans, remove, opts)
+    if err != nil {
+        return nil, err
+    }
+    if ok {
+        tasks = append(tasks, task)
+    }
+    return tasks, nil
+}
+
+func (m *dnfManager) Cleanup(task CleanupTask, opts Options) error {
+    return m.env.runOrPrint(task.plan, opts)
+}
+
+// Hold uses the versionlock plugin. dnf5 has it built in, dnf4 needs
+// python3-dnf-plugin-versionlock, and dnf explains that when it is missing.
+func (m *dnfManager) Hold(pkgs []string, opts Options) error {
+    args := append([]string{"dnf", "versionlock", "add"}, pkgs...)
+    plan := newPlan("Lock packages at their current version with dnf versionlock", rootStep(args...)).forAction("hold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *dnfManager) Unhold(pkgs []string, opts Options) error {
+    args := append([]string{"dnf", "versionlock", "delete"}, pkgs...)
+    plan := newPlan("Remove the version lock from packages with dnf versionlock", rootStep(args...)).forAction("unhold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *dnfManager) Holds(opts Options) ([]string, error) {
+    out, err := m.env.query(newPlan("List locked packages with dnf versionlock", userStep("dnf", "versionlock", "list")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseDnfVersionlock(string(out)), nil
+}
+
+const yumReposDir = "/etc/yum.repos.d"
+
+// dnf5 replaced the dnf4 config-manager flags with subcommands.
+func (m *dnfManager) isDnf5() bool {
+    return m.env.has("dnf5")
+}
+
+func (m *dnfManager) Repos(opts Options) ([]Repo, error) {
+    out, err := m.env.query(newPlan("List configured repositories with dnf", userStep("dnf", "repolist", "--all")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseDnfRepolist(string(out)), nil
+}
+
+// AddRepo understands copr:user/project, rpmfusion-free and
+// rpmfusion-nonfree, links to .repo files, and plain base URLs, which
+// get a new file in /etc/yum.repos.d.
+func (m *dnfManager) AddRepo(spec RepoSpec, opts Options) error {
+    switch {
+    case strings.HasPrefix(spec.URL, "copr:"):
+        project := strings.TrimPrefix(spec.URL, "copr:")
+        args := []string{"dnf", "copr", "enable"}
+        if opts.AssumeYes {
+            args = append(args, "-y")
+        }
+        args = append(args, project)
+        plan := newPlan("Enable the COPR project "+project+", a community build service that Fedora does not review, "+
+            "which adds a file in "+yumReposDir+" and its signing key", rootStep(args...)).forAction("repo-add", spec.URL)
+        return m.env.runOrPrint(plan, opts)
+
+    case spec.URL == "rpmfusion-free" || spec.URL == "rpmfusion-nonfree":
+        release, err := m.rpmFusionRelease(spec.URL, opts)
+        if err != nil {
+            return err
+        }
+        args := []string{"dnf", "install"}
+        if opts.AssumeYes {
+            args = append(args, "-y")
+        }
+        args = append(args, release)
+        plan := newPlan("Install the "+spec.URL+" release package, which adds its repository files and signing keys",
+            rootStep(args...)).forAction("repo-add", spec.URL)
+        return m.env.runOrPrint(plan, opts)
+
+    case strings.HasSuffix(spec.URL, ".repo"):
+        args := []string{"dnf", "config-manager", "--add-repo", spec.URL}
+        if m.isDnf5() {
+            args = []string{"dnf", "config-manager", "addrepo", "--from-repofile=" + spec.URL}
+        }
+        plan := newPlan("Download the repository file into "+yumReposDir+" with dnf config-manager",
+            rootStep(args...)).forAction("repo-add", spec.URL)
+        return m.env.runOrPrint(plan, opts)
+    }
+
+    if err := checkRepoName(spec.Name); err != nil {
+        return err
+    }
+    file := filepath.Join(yumReposDir, spec.Name+".repo")
+    content := "[" + spec.Name + "]\nname=" + spec.Name + "\nbaseurl=" + spec.URL + "\nenabled=1\n"
+    var steps []Step
+    explanation := "Write " + file
+    if spec.Key != "" {
+        steps = append(steps, rootStep("rpm", "--import", spec.Key))
+        content += "gpgcheck=1\ngpgkey=" + spec.Key + "\n"
+        explanation = "Import the signing key with rpm and write " + file + " so packages from it are checked against that key"
+    } else {
+        content += "gpgcheck=0\n"
+        explanation += " without signature checks, since no key was given"
+    }
+    steps = append(steps, rootStep("tee", file).withInput(content))
+    plan := newPlan(explanation, steps...).forAction("repo-add", spec.Name)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// rpmFusionRelease returns the release package URL for this Fedora or
+// EL version, which rpm expands from its macros.
+func (m *dnfManager) rpmFusionRelease(name string, opts Options) (string, error) {
+    kind := strings.TrimPrefix(name, "rpmfusion-")
+    for _, macro := range []string{"fedora", "rhel"} {
+        out, err := m.env.query(newPlan("Read the release version from rpm", userStep("rpm", "-E", "%"+macro)), opts)
+        if err != nil {
+            return "", err
+        }
+        version := strings.TrimSpace(string(out))
+        if version == "" || strings.HasPrefix(version, "%") {
+            continue
+        }
+        dir := macro
+        if macro == "rhel" {
+            dir = "el"
+        }
+        return "https://mirrors.rpmfusion.org/" + kind + "/" + dir + "/" + name + "-release-" + version + ".noarch.rpm", nil
+    }
+    return "", fmt.Errorf("RPM Fusion is only available for Fedora and Enterprise Linux")
+}
+
+// RemoveRepo undoes what AddRepo did: COPR projects and RPM Fusion are
+// removed the way they were added, other repositories by deleting the
+// file that defines them.
+func (m *dnfManager) RemoveRepo(id string, opts Options) error {
+    switch {
+    case strings.HasPrefix(id, "copr:") && strings.Contains(id, "/"):
+        project := strings.TrimPrefix(id, "copr:")
+        plan := newPlan("Remove the COPR project "+project, rootStep("dnf", "copr", "remove", project)).forAction("repo-remove", id)
+        return m.env.runOrPrint(plan, opts)
+
+    case strings.HasPrefix(id, "rpmfusion-"):
+        release := "rpmfusion-free-release"
+        if strings.HasPrefix(id, "rpmfusion-nonfree") {
+            release = "rpmfusion-nonfree-release"
+        }
+        args := []string{"dnf", "remove"}
+        if opts.AssumeYes {
+            args = append(args, "-y")
+        }
+        args = append(args, release)
+        plan := newPlan("Remove "+release+", which owns the RPM Fusion repository files", rootStep(args...)).forAction("repo-remove", id)
+        return m.env.runOrPrint(plan, opts)
+    }
+
+    file, err := m.repoFile(id, opts)
+    if err != nil {
+        return err
+    }
+    plan := newPlan("Delete "+file, rootStep("rm", "-f", file)).forAction("repo-remove", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// repoFile finds the .repo file that defines only id. Files that
+// define several repositories are left alone, since deleting them
+// would remove the others too.
+func (m *dnfManager) repoFile(id string, opts Options) (string, error) {
+    files, err := globFiles(filepath.Join(yumReposDir, "*.repo"))
+    if err != nil {
+        return "", err
+    }
+    if len(files) > 0 {
+        args := append([]string{"grep", "-F", "-l", "-x", "[" + id + "]"}, files...)
+        // grep exits with 1 when no file matched.
+        out, err := m.env.query(newPlan("Find the file that defines "+id, userStep(args...).allowExit(1)), opts)
+        if err != nil {
+            return "", err
+        }
+        files = strings.Fields(string(out))
+    }
+    if len(files) == 0 {
+        return "", fmt.Errorf("%w: %s", ErrRepoNotFound, id)
+    }
+    file := files[0]
+    out, err := m.env.query(newPlan("Read "+file, userStep("cat", file)), opts)
+    if err != nil {
+        return "", err
+    }
+    if sections := len(parseRepoSections(string(out))); sections > 1 {
+        return "", fmt.Errorf("%s defines %d repositories, disable %s instead or edit the file by hand", file, sections, id)
+    }
+    return file, nil
+}
+
+func (m *dnfManager) EnableRepo(id string, opts Options) error {
+    args := []string{"dnf", "config-manager", "--set-enabled", id}
+    if m.isDnf5() {
+        args = []string{"dnf", "config-manager", "setopt", id + ".enabled=1"}
+    }
+    plan := newPlan("Set enabled=1 for the repository with dnf config-manager", rootStep(args...)).forAction("repo-enable", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *dnfManager) DisableRepo(id string, opts Options) error {
+    args := []string{"dnf", "config-manager", "--set-disabled", id}
+    if m.isDnf5() {
+        args = []string{"dnf", "config-manager", "setopt", id + ".enabled=0"}
+    }
+    plan := newPlan("Set enabled=0 for the repository with dnf config-manager", rootStep(args...)).forAction("repo-disable", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// parseDnfRepolist parses dnf repolist --all, where the status is the
+// last column and the name may contain spaces:
+//
+//	repo id                 repo name                       status
+//	fedora                  Fedora 40 - x86_64              enabled
+//	updates-testing         Fedora 40 - x86_64 - Test Updates disabled
+func parseDnfRepolist(out string) []Repo {
+    var repos []Repo
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Fields(line)
+        if len(fields) < 2 || fields[0] == "repo" {
+            continue
+        }
+        status := fields[len(fields)-1]
+        if status != "enabled" && status != "disabled" {
+            continue
+        }
+        repos = append(repos, Repo{
+            ID:      fields[0],
+            Name:    strings.Join(fields[1:len(fields)-1], " "),
+            Enabled: status == "enabled",
+        })
+    }
+    return repos
+}
+
+// parseRepoSections returns the [section] names in an ini style file.
+func parseRepoSections(out string) []string {
+    var sections []string
+    for _, line := range strings.Split(out, "\n") {
+        line = strings.TrimSpace(line)
+        if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+            sections = append(sections, strings.Trim(line, "[]"))
+        }
+    }
+    return sections
+}
+
+func (m *dnfManager) Versions(name string, opts Options) ([]PackageVersion, error) {
+    // dnf exits with 1 when no package matched.
+    step := userStep("dnf", "list", "--showduplicates", "--", name).allowExit(1)
+    out, err := m.env.query(newPlan("List every available version with dnf", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    versions := parseDnfListDuplicates(name, string(out))
+    if len(versions) == 0 {
+        return nil, ErrPackageNotFound
+    }
+    return mergeVersions(versions), nil
+}
+
+func (m *dnfManager) Downgrade(name, version string, opts Options) error {
+    args := []string{"dnf", "downgrade"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(args, "--", name+"-"+version)
+    plan := newPlan("Replace "+name+" with version "+version+" with dnf downgrade. "+
+        "The next upgrade replaces it again, unless you hold the package", rootStep(args...)).forAction("downgrade", name)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// parseDnfListDuplicates parses dnf list --showduplicates, where the
+// installed versions come under their own heading:
+//
+//	Installed Packages
+//	htop.x86_64          3.3.0-3.fc40          @updates
+//	Available Packages
+//	htop.x86_64          3.3.0-2.fc40          fedora
+func parseDnfListDuplicates(name, out string) []PackageVersion {
+    var versions []PackageVersion
+    installed := false
+    for _, line := range strings.Split(out, "\n") {
+        switch strings.ToLower(strings.TrimSpace(line)) {
+        case "installed packages":
+            installed = true
+            continue
+        case "available packages":
+            installed = false
+            continue
+        }
+        fields := strings.Fields(line)
+        if len(fields) != 3 {
+            continue
+        }
+        if i := strings.LastIndex(fields[0], "."); i < 0 || fields[0][:i] != name {
+            continue
+        }
+        repo := strings.TrimPrefix(fields[2], "@")
+        if installed {
+            repo = ""
+        }
+        versions = append(versions, PackageVersion{Version: fields[1], Repository: repo, Installed: installed})
+    }
+    return versions
+}
+
+// nameFormat is a repoquery --queryformat that prints one package name
+// per line. dnf5 no longer ends each line itself.
+func (m *dnfManager) nameFormat() string {
+    if m.isDnf5() {
+        return `%{name}\n`
+    }
+    return "%{name}"
+}
+
+func (m *dnfManager) PackageNames(opts Options) ([]string, error) {
+    step := userStep("dnf", "repoquery", "--quiet", "--queryformat", m.nameFormat())
+    out, err := m.env.query(newPlan("List every package name in the enabled repositories with dnf", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return sortedNames(nameSet(string(out))), nil
+}
+
+func (m *dnfManager) Deps(name string, opts Options) ([]string, error) {
+    // Requirements are capabilities such as libc.so.6, so ask for the
+    // packages that provide them instead. dnf5 replaced --resolve.
+    args := []string{"dnf", "repoquery", "--quiet", "--requires", "--resolve"}
+    if m.isDnf5() {
+        args = []string{"dnf", "repoquery", "--quiet", "--providers-of=requires"}
+    }
+    args = append(args, "--queryformat", m.nameFormat(), "--", name)
+    out, err := m.env.query(newPlan("List the packages that provide what the package requires with dnf", userStep(args...)), opts)
+    if err != nil {
+        return nil, err
+    }
+    return depNames(nameSet(string(out)), name), nil
+}
+
+func (m *dnfManager) ReverseDeps(name string, opts Options) ([]string, error) {
+    step := userStep("dnf", "repoquery", "--quiet", "--installed", "--whatrequires="+name, "--queryformat", m.nameFormat())
+    out, err := m.env.query(newPlan("List installed packages that require the package with dnf", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return depNames(nameSet(string(out)), name), nil
+}
@@ -0,0 +1,120 @@
+package pkgmgr
+
+import (
+    "os"
+    "slices"
+    "strings"
+)
+
+// ElevateEnv is the environment variable that picks the tool used to
+// run commands as root, for people who prefer doas or run0 over sudo.
+const ElevateEnv = "PENGUINGUIDE_ELEVATE"
+
+// ElevationTools lists the tools that can run a command as root, in
+// the order they are tried when no preference is set.
+var ElevationTools = []string{"sudo", "doas", "run0", "pkexec"}
+
+// Elevation is how steps that change the system get root. Tool is put
+// in front of each privileged step, and is empty when penguinguide
+// already runs as root, as in containers and on many Alpine installs.
+// Reason says why, for --explain.
+type Elevation struct {
+    Tool   string
+    Reason string
+}
+
+// geteuid returns the effective user ID. It is a variable so tests do
+// not depend on who runs them.
+var geteuid = os.Geteuid
+
+// prefix returns the argv that goes in front of a privileged step.
+func (el Elevation) prefix() []string {
+    if el.Tool == "" {
+        return nil
+    }
+    return []string{el.Tool}
+}
+
+// WithElevation returns a copy of e that gets root the way preference
+// asks, or picks a tool itself when preference is empty or "auto".
+func (e Env) WithElevation(preference string) Env {
+    e = e.withDefaults()
+    el := e.detectElevation(preference)
+    e.Elevation = &el
+    return e
+}
+
+func (e Env) detectElevation(preference string) Elevation {
+    if geteuid() == 0 {
+        return Elevation{Reason: "penguinguide is already running as root, so these commands run directly."}
+    }
+
+    note := ""
+    if preference != "" && preference != "auto" {
+        if slices.Contains(ElevationTools, preference) && e.has(preference) {
+            return Elevation{Tool: preference, Reason: "They run through " + preference + ", as set with --elevate or " + ElevateEnv + "."}
+        }
+        note = preference + " was asked for but is not available. "
+    }
+
+    for _, tool := range ElevationTools {
+        if e.has(tool) {
+            return Elevation{Tool: tool, Reason: note + "They run through " + tool + ", the first of " + joinOr(ElevationTools) + " installed here."}
+        }
+    }
+    return Elevation{
+        Tool:   "sudo",
+        Reason: note + "None of " + joinOr(ElevationTools) + " is installed, so run penguinguide as root instead.",
+    }
+}
+
+// elevate returns a copy of plan whose privileged steps get root the
+// way e says. Without an Elevation they keep the sudo default.
+func (e Env) elevate(plan Plan) Plan {
+    if e.Elevation == nil {
+        return plan
+    }
+    steps := make([]Step, len(plan.Steps))
+    for i, s := range plan.Steps {
+        if s.Privileged {
+            s.elevation = e.Elevation
+        }
+        steps[i] = s
+    }
+    plan.Steps = steps
+    return plan
+}
+
+// needsRoot reports whether any step of the plan is privileged.
+func (p Plan) needsRoot() bool {
+    for _, s := range p.Steps {
+        if s.Privileged {
+            return true
+        }
+    }
+    return false
+}
+
+func joinOr(words []string) string {
+    switch len(words) {
+    case 0:
+        return ""
+    case 1:
+        return words[0]
+    }
+    return strings.Join(words[:len(words)-1], ", ") + " or " + words[len(words)-1]
+}
+
+// elevationReason explains why a plan runs commands as root and how.
+func (e Env) elevationReason() string {
+    why := "These commands change files that belong to the system, such as installed programs and their settings, which only root can write."
+    if e.Elevation == nil {
+        return why + " sudo asks for your password first."
+    }
+    return why + " " + e.Elevation.Reason
+}
+
+// isRoot reports whether commands already run as root.
+func (e Env) isRoot() bool {
+    return e.Elevation != nil && e.Elevation.Tool == ""
+}
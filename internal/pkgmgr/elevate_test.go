@@ -0,0 +1,103 @@
+package pkgmgr
+
+import (
+    "bytes"
+    "io"
+    "reflect"
+    "strings"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func stubEUID(t *testing.T, uid int) {
+    old := geteuid
+    geteuid = func() int { return uid }
+    t.Cleanup(func() { geteuid = old })
+}
+
+func TestDetectElevation(t *testing.T) {
+    tests := []struct {
+        name       string
+        uid        int
+        installed  []string
+        preference string
+        want       string
+    }{
+        {"root", 0, []string{"sudo"}, "doas", ""},
+        {"sudo first", 1000, []string{"doas", "sudo"}, "", "sudo"},
+        {"doas only", 1000, []string{"doas"}, "", "doas"},
+        {"preference", 1000, []string{"sudo", "run0"}, "run0", "run0"},
+        {"auto", 1000, []string{"pkexec"}, "auto", "pkexec"},
+        {"missing preference", 1000, []string{"sudo"}, "doas", "sudo"},
+        {"unknown preference", 1000, []string{"su", "sudo"}, "su", "sudo"},
+        {"nothing installed", 1000, nil, "", "sudo"},
+    }
+    for _, tt := range tests {
+        stubEUID(t, tt.uid)
+        env := Env{Runner: &pkgmgrtest.Runner{Installed: tt.installed}}
+        if got := env.detectElevation(tt.preference); got.Tool != tt.want {
+            t.Errorf("%s: Tool = %q, want %q (%s)", tt.name, got.Tool, tt.want, got.Reason)
+        }
+    }
+}
+
+// This test checks that privileged steps use the detected tool, or
+// nothing at all as root, while user steps are left alone.
+func TestElevatedCommands(t *testing.T) {
+    tests := []struct {
+        tool string
+        want []string
+    }{
+        {"doas", []string{"doas apk update", "doas apk upgrade"}},
+        {"", []string{"apk update", "apk upgrade"}},
+    }
+    for _, tt := range tests {
+        runner := &pkgmgrtest.Runner{}
+        env := Env{Runner: runner, Prompter: &pkgmgrtest.Prompter{}, Out: io.Discard, Elevation: &Elevation{Tool: tt.tool}}
+        mgr := NewWithEnv(&distro.Distro{Family: distro.FamilyAlpine, ID: "alpine"}, env)
+        if err := mgr.UpdateAll(Options{}); err != nil {
+            t.Fatalf("UpdateAll error = %v", err)
+        }
+        if got := runner.Commands(); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("elevation %q commands = %q, want %q", tt.tool, got, tt.want)
+        }
+    }
+}
+
+func TestExplainSaysWhyRootIsNeeded(t *testing.T) {
+    var out bytes.Buffer
+    env := Env{
+        Runner:    &pkgmgrtest.Runner{},
+        Prompter:  &pkgmgrtest.Prompter{},
+        Out:       &out,
+        Elevation: &Elevation{Tool: "doas", Reason: "They run through doas."},
+    }
+    plan := newPlan("Install packages with apk", rootStep("apk", "add", "htop"))
+    if err := env.runOrPrint(plan, Options{Explain: true}); err != nil {
+        t.Fatalf("runOrPrint error = %v", err)
+    }
+    for _, want := range []string{"Why root is needed", "They run through doas.", "doas apk add htop"} {
+        if !strings.Contains(out.String(), want) {
+            t.Errorf("explain output does not mention %q:\n%s", want, out.String())
+        }
+    }
+
+    out.Reset()
+    if err := env.runOrPrint(newPlan("List files", userStep("ls")), Options{Explain: true}); err != nil {
+        t.Fatalf("runOrPrint error = %v", err)
+    }
+    if strings.Contains(out.String(), "Why root is needed") {
+        t.Errorf("explain output for a user step mentions root:\n%s", out.String())
+    }
+}
+
+func TestAURHelperNotUsedAsRoot(t *testing.T) {
+    runner := &pkgmgrtest.Runner{Installed: []string{"paru"}}
+    env := Env{Runner: runner, Out: io.Discard, Elevation: &Elevation{}}
+    mgr := NewWithEnv(&distro.Distro{Family: distro.FamilyArch, ID: "arch"}, env)
+    if _, ok := mgr.(*pacmanManager); !ok {
+        t.Fatalf("NewWithEnv as root = %T, want *pacmanManager", mgr)
+    }
+}
@@ -0,0 +1,156 @@
+package pkgmgr
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+
+    "penguinguide/internal/ui"
+)
+
+/********** Portage **********/
+
+type emergeManager struct {
+    env Env
+}
+
+// emergeArgs starts an emerge command. Without --yes, emerge shows what
+// it is going to build and asks before it starts.
+func emergeArgs(opts Options, args ...string) []string {
+    argv := []string{"emerge"}
+    if !opts.AssumeYes {
+        argv = append(argv, "--ask")
+    }
+    return append(argv, args...)
+}
+
+// warnCompileTimes reminds people that Gentoo builds from source,
+// before they start something that may keep the machine busy for hours.
+func (m *emergeManager) warnCompileTimes() {
+    fmt.Fprintln(m.env.Out, ui.Warning("Gentoo builds packages from source on this machine."))
+    fmt.Fprintln(m.env.Out, "  Small tools build in minutes, but large ones such as firefox, chromium,")
+    fmt.Fprintln(m.env.Out, "  or gcc can take hours. Many of them have a ready-made -bin package,")
+    fmt.Fprintln(m.env.Out, "  such as firefox-bin, that installs in seconds.")
+    fmt.Fprintln(m.env.Out)
+}
+
+func (m *emergeManager) UpdateAll(opts Options) error {
+    m.warnCompileTimes()
+    plan := newPlan("Sync the Portage tree, then rebuild every package that has a newer version or changed USE flags",
+        rootStep("emaint", "--auto", "sync"),
+        rootStep(emergeArgs(opts, "--verbose", "--update", "--deep", "--newuse", "@world")...),
+    ).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *emergeManager) Install(pkgs []string, opts Options) error {
+    m.warnCompileTimes()
+    args := emergeArgs(opts, "--verbose")
+    args = append(args, emergeAtoms(pkgs)...)
+    plan := newPlan("Build and install packages with emerge", rootStep(args...)).forAction("install", packageNames(pkgs)...)
+    return notFound(m.env.runOrPrint(plan, opts), emergeNotFound)
+}
+
+// Remove uses --depclean, which refuses to remove packages that
+// something else still needs, unlike --unmerge.
+func (m *emergeManager) Remove(pkgs []string, opts Options) error {
+    args := append(emergeArgs(opts, "--depclean"), pkgs...)
+    plan := newPlan("Remove packages with emerge, keeping any that other packages still need", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *emergeManager) Search(query string, opts Options) ([]SearchResult, error) {
+    args := append([]string{"emerge", "--search"}, searchTerms(query)...)
+    out, err := m.env.query(newPlan("Search the Portage tree with emerge", userStep(args...)), opts)
+    if err != nil {
+        return nil, err
+    }
+    var results []SearchResult
+    for _, info := range parseEmergeSearch(string(out)) {
+        results = append(results, SearchResult{
+            Name:      info.Name,
+            Version:   info.Version,
+            Summary:   info.Description,
+            Installed: info.Installed,
+        })
+    }
+    return results, nil
+}
+
+func (m *emergeManager) Info(name string, opts Options) (*PackageInfo, error) {
+    // A leading % makes the search key a regular expression, and @
+    // matches it against category/name instead of the name alone.
+    key := "%^" + regexp.QuoteMeta(name) + "$"
+    if strings.Contains(name, "/") {
+        key = "%@^" + regexp.QuoteMeta(name) + "$"
+    }
+    out, err := m.env.query(newPlan("Show package details with emerge", userStep("emerge", "--search", key)), opts)
+    if err != nil {
+        return nil, err
+    }
+    infos := parseEmergeSearch(string(out))
+    if len(infos) == 0 {
+        return nil, ErrPackageNotFound
+    }
+    infos[0].Raw = string(out)
+    return infos[0], nil
+}
+
+// emergeAtoms turns name@version into =name-version, the atom emerge
+// uses for one exact version.
+func emergeAtoms(pkgs []string) []string {
+    out := make([]string, 0, len(pkgs))
+    for _, p := range pkgs {
+        if name, version := SplitVersion(p); version != "" {
+            p = "=" + name + "-" + version
+        }
+        out = append(out, p)
+    }
+    return out
+}
+
+// parseEmergeSearch parses emerge --search output, which has one
+// block per package. A block starts with "*  sys-process/htop" and
+// goes on with indented "Key: value" lines such as "Latest version
+// installed: [ Not Installed ]". Names keep their category, which
+// emerge needs when two categories have a package with the same name.
+func parseEmergeSearch(out string) []*PackageInfo {
+    var infos []*PackageInfo
+    var cur *PackageInfo
+    for _, line := range strings.Split(out, "\n") {
+        trimmed := strings.TrimSpace(line)
+        if strings.HasPrefix(trimmed, "* ") {
+            fields := strings.Fields(trimmed[1:])
+            if len(fields) == 0 {
+                continue
+            }
+            cur = &PackageInfo{Name: fields[0], Repository: "gentoo"}
+            infos = append(infos, cur)
+            continue
+        }
+        if cur == nil {
+            continue
+        }
+        key, value, ok := strings.Cut(trimmed, ":")
+        if !ok {
+            continue
+        }
+        value = strings.TrimSpace(value)
+        switch key {
+        case "Latest version available":
+            cur.Version = value
+        case "Latest version installed":
+            cur.Installed = value != "" && !strings.Contains(value, "Not Installed")
+        case "Size of files":
+            // emerge groups thousands with commas.
+            cur.Size = ParseSize(strings.ReplaceAll(value, ",", ""))
+        case "Homepage":
+            cur.Homepage = value
+        case "Description":
+            cur.Description = value
+        case "License":
+            cur.License = value
+        }
+    }
+    return infos
+}
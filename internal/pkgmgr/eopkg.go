@@ -0,0 +1,124 @@
+package pkgmgr
+
+import "strings"
+
+/********** eopkg **********/
+
+type eopkgManager struct {
+    env Env
+}
+
+func (m *eopkgManager) UpdateAll(opts Options) error {
+    args := []string{"eopkg", "upgrade"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    plan := newPlan("Update the repository index and all packages with eopkg", rootStep(args...)).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *eopkgManager) Install(pkgs []string, opts Options) error {
+    args := []string{"eopkg", "install"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(args, pkgs...)
+    plan := newPlan("Install packages with eopkg", rootStep(args...)).forAction("install", pkgs...)
+    return notFound(m.env.runOrPrint(plan, opts), eopkgNotFound)
+}
+
+func (m *eopkgManager) Remove(pkgs []string, opts Options) error {
+    args := []string{"eopkg", "remove"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(args, pkgs...)
+    plan := newPlan("Remove packages with eopkg", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *eopkgManager) Search(query string, opts Options) ([]SearchResult, error) {
+    args := append([]string{"eopkg", "search"}, searchTerms(query)...)
+    out, err := m.env.query(newPlan("Search for packages with eopkg", userStep(args...)), opts)
+    if err != nil {
+        return nil, err
+    }
+    results := parseEopkgList(string(out))
+    if len(results) == 0 {
+        return nil, nil
+    }
+
+    listOut, err := m.env.query(newPlan("List installed packages with eopkg", userStep("eopkg", "list-installed")), opts)
+    if err != nil {
+        return nil, err
+    }
+    installed := make(map[string]string)
+    for _, r := range parseEopkgList(string(listOut)) {
+        installed[r.Name] = ""
+    }
+    markInstalled(results, installed)
+    return results, nil
+}
+
+func (m *eopkgManager) Info(name string, opts Options) (*PackageInfo, error) {
+    out, err := m.env.query(newPlan("Show package details with eopkg", userStep("eopkg", "info", name)), opts)
+    if err != nil {
+        return nil, err
+    }
+    info := parseEopkgInfo(string(out))
+    if info.Name == "" {
+        return nil, ErrPackageNotFound
+    }
+    return info, nil
+}
+
+// parseEopkgList parses eopkg search and list-installed output, which
+// is one "name - summary" line per package.
+func parseEopkgList(out string) []SearchResult {
+    var results []SearchResult
+    for _, line := range strings.Split(out, "\n") {
+        name, summary, ok := strings.Cut(line, " - ")
+        name = strings.TrimSpace(name)
+        if !ok || name == "" || strings.Contains(name, " ") {
+            continue
+        }
+        results = append(results, SearchResult{Name: name, Summary: strings.TrimSpace(summary)})
+    }
+    return results
+}
+
+// parseEopkgInfo parses eopkg info, which prints the installed
+// package first, when there is one, and then the one in the
+// repository. Only the first is read. Its name line also carries the
+// version:
+//
+//	Name                : htop, version: 3.3.0, release: 45
+func parseEopkgInfo(out string) *PackageInfo {
+    fields := parseKeyValues(out)
+    info := &PackageInfo{Raw: out}
+    name, rest, _ := strings.Cut(fields["name"], ",")
+    info.Name = strings.TrimSpace(name)
+    if info.Name == "" {
+        return info
+    }
+    version, release := "", ""
+    for _, part := range strings.Split(rest, ",") {
+        key, value, _ := strings.Cut(part, ":")
+        switch strings.TrimSpace(key) {
+        case "version":
+            version = strings.TrimSpace(value)
+        case "release":
+            release = strings.TrimSpace(value)
+        }
+    }
+    info.Version = version
+    if release != "" {
+        info.Version += "-" + release
+    }
+    info.License = fields["licenses"]
+    info.Repository = fields["component"]
+    info.Description = joinLines(fields["summary"])
+    info.Dependencies = strings.Fields(fields["dependencies"])
+    info.Installed = strings.Contains(out, "Installed package:")
+    return info
+}
@@ -0,0 +1,171 @@
+package pkgmgr
+
+import "strings"
+
+/********** Flatpak **********/
+
+// flatpakManager installs apps from the remotes configured in flatpak,
+// usually Flathub. Flatpak asks for administrator rights through polkit
+// when it needs them, so its steps never run with sudo.
+type flatpakManager struct {
+    env Env
+}
+
+func (m *flatpakManager) UpdateAll(opts Options) error {
+    args := []string{"flatpak", "update"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    plan := newPlan("Update all Flatpak apps and runtimes", userStep(args...)).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *flatpakManager) Install(pkgs []string, opts Options) error {
+    args := []string{"flatpak", "install"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(args, pkgs...)
+    plan := newPlan("Install apps with Flatpak", userStep(args...)).forAction("install", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *flatpakManager) Remove(pkgs []string, opts Options) error {
+    args := []string{"flatpak", "uninstall"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(args, pkgs...)
+    plan := newPlan("Remove apps with Flatpak", userStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *flatpakManager) Search(query string, opts Options) ([]SearchResult, error) {
+    results, err := m.search(query, opts)
+    if err != nil || len(results) == 0 {
+        return nil, err
+    }
+
+    installed, err := flatpakInstalled(m.env, opts)
+    if err != nil {
+        return nil, err
+    }
+    markInstalled(results, installed)
+    return results, nil
+}
+
+func (m *flatpakManager) search(query string, opts Options) ([]SearchResult, error) {
+    step := userStep("flatpak", "search", "--columns=application,version,remotes,description", "--", query)
+    out, err := m.env.query(newPlan("Search for apps with Flatpak", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseFlatpakSearch(string(out)), nil
+}
+
+// Info shows installed apps with flatpak info. Apps that are not
+// installed are looked up with a search, which is all flatpak offers
+// without naming a remote.
+func (m *flatpakManager) Info(name string, opts Options) (*PackageInfo, error) {
+    // flatpak info exits with 1 when the app is not installed.
+    step := userStep("flatpak", "info", name).allowExit(1)
+    out, err := m.env.query(newPlan("Show details for an installed app with Flatpak", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    if info := parseFlatpakInfo(string(out)); info.Name != "" {
+        return info, nil
+    }
+
+    results, err := m.search(name, opts)
+    if err != nil {
+        return nil, err
+    }
+    for _, r := range results {
+        if r.Name == name {
+            return &PackageInfo{
+                Name:        r.Name,
+                Version:     r.Version,
+                Repository:  r.Repository,
+                Description: r.Summary,
+            }, nil
+        }
+    }
+    return nil, ErrPackageNotFound
+}
+
+// flatpakInstalled returns installed app IDs mapped to their versions.
+func flatpakInstalled(env Env, opts Options) (map[string]string, error) {
+    step := userStep("flatpak", "list", "--app", "--columns=application,version")
+    out, err := env.query(newPlan("List installed apps with Flatpak", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    installed := make(map[string]string)
+    for _, line := range strings.Split(string(out), "\n") {
+        id, version, _ := strings.Cut(line, "\t")
+        if id = strings.TrimSpace(id); id != "" {
+            installed[id] = strings.TrimSpace(version)
+        }
+    }
+    return installed, nil
+}
+
+// parseFlatpakSearch parses tab separated flatpak search output with
+// the columns application, version, remotes, and description. Lines
+// without tabs, such as "No matches found", are skipped.
+func parseFlatpakSearch(out string) []SearchResult {
+    var results []SearchResult
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Split(line, "\t")
+        if len(fields) < 4 {
+            continue
+        }
+        results = append(results, SearchResult{
+            Name:       strings.TrimSpace(fields[0]),
+            Version:    strings.TrimSpace(fields[1]),
+            Repository: strings.TrimSpace(fields[2]),
+            Summary:    strings.TrimSpace(fields[3]),
+        })
+    }
+    return results
+}
+
+// parseFlatpakInfo parses flatpak info output, which starts with the
+// app name and summary followed by key value lines:
+//
+//	GIMP - GNU Image Manipulation Program
+//
+//	     ID: org.gimp.GIMP
+//	Version: 2.10.34
+func parseFlatpakInfo(out string) *PackageInfo {
+    fields := make(map[string]string)
+    summary := ""
+    for _, line := range strings.Split(out, "\n") {
+        trimmed := strings.TrimSpace(line)
+        if trimmed == "" {
+            continue
+        }
+        key, value, ok := strings.Cut(trimmed, ": ")
+        if !ok || strings.Contains(key, " - ") {
+            if summary == "" {
+                _, summary, _ = strings.Cut(trimmed, " - ")
+            }
+            continue
+        }
+        fields[strings.ToLower(key)] = strings.TrimSpace(value)
+    }
+    if fields["id"] == "" {
+        return &PackageInfo{Raw: out}
+    }
+    return &PackageInfo{
+        Name:          fields["id"],
+        Version:       fields["version"],
+        Repository:    fields["origin"],
+        InstalledSize: ParseSize(fields["installed"]),
+        License:       fields["license"],
+        Description:   strings.TrimSpace(summary),
+        Installed:     true,
+        Raw:           out,
+    }
+}
@@ -0,0 +1,97 @@
+package pkgmgr
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+)
+
+// Holder is implemented by managers that can keep packages at their
+// current version, so a known bad upgrade is not installed.
+type Holder interface {
+    Hold(pkgs []string, opts Options) error
+    Unhold(pkgs []string, opts Options) error
+    // Holds returns the names of held packages.
+    Holds(opts Options) ([]string, error)
+}
+
+// MarkHeld sets Held on upgrades for packages in held.
+func MarkHeld(upgrades []Upgrade, held []string) {
+    set := make(map[string]bool, len(held))
+    for _, name := range held {
+        set[name] = true
+    }
+    for i := range upgrades {
+        upgrades[i].Held = set[upgrades[i].Name]
+    }
+}
+
+// parseDnfVersionlock reads held package names from dnf versionlock list.
+// dnf4 prints one NEVRA pattern per line, such as "htop-0:3.3.0-3.fc40.*",
+// while dnf5 prints "Package name: htop" blocks.
+func parseDnfVersionlock(out string) []string {
+    var names []string
+    for _, line := range strings.Split(out, "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "Last metadata") {
+            continue
+        }
+        if name, ok := strings.CutPrefix(line, "Package name:"); ok {
+            names = append(names, strings.TrimSpace(name))
+            continue
+        }
+        if strings.HasSuffix(line, ".*") && !strings.Contains(line, " ") {
+            names = append(names, nevraName(strings.TrimSuffix(line, ".*")))
+        }
+    }
+    return names
+}
+
+// apkPin matches a world entry pinned to one version, such as "htop=3.2.2-r1".
+var apkPin = regexp.MustCompile(`^([^<>=~@]+)=[^=]`)
+
+// parseApkPins reads packages pinned to an exact version from /etc/apk/world.
+func parseApkPins(out string) []string {
+    var names []string
+    for _, entry := range strings.Fields(out) {
+        if m := apkPin.FindStringSubmatch(entry); m != nil {
+            names = append(names, m[1])
+        }
+    }
+    return names
+}
+
+// pacmanConf is where pacman reads IgnorePkg from.
+const pacmanConf = "/etc/pacman.conf"
+
+// validPacmanName matches the package names makepkg accepts. Hold and
+// Unhold put names into a sed script that runs as root, so anything
+// else, such as a newline starting a new sed command, is refused.
+var validPacmanName = regexp.MustCompile(`^[A-Za-z0-9@_+][A-Za-z0-9@._+-]*$`)
+
+func checkPacmanNames(pkgs []string) error {
+    for _, name := range pkgs {
+        if !validPacmanName.MatchString(name) {
+            return fmt.Errorf("package name %q can only contain letters, digits, and @ . _ + -", name)
+        }
+    }
+    return nil
+}
+
+// ignorePkgAdd returns a sed expression that adds an IgnorePkg line
+// with pkgs right after the [options] header. pacman adds up every
+// IgnorePkg line, so existing ones are left alone.
+func ignorePkgAdd(pkgs []string) string {
+    return `/^\[options\]/a IgnorePkg = ` + strings.Join(pkgs, " ")
+}
+
+// ignorePkgRemove returns sed expressions that drop name from every
+// IgnorePkg line, and then delete IgnorePkg lines left empty.
+// Commented out lines are not touched.
+func ignorePkgRemove(name string) []string {
+    quoted := regexp.QuoteMeta(name)
+    return []string{
+        "-e", `/^[[:space:]]*IgnorePkg[[:space:]]*=/ s/([=[:space:]])` + quoted + `([[:space:]]|$)/\1\2/g`,
+        "-e", `/^[[:space:]]*IgnorePkg[[:space:]]*=[[:space:]]*$/d`,
+    }
+}
@@ -0,0 +1,107 @@
+package pkgmgr
+
+import (
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func TestParseDnfVersionlock(t *testing.T) {
+    dnf4 := `Last metadata expiration check: 0:01:02 ago on Mon 01 Jan 2024.
+htop-0:3.3.0-3.fc40.*
+python3-requests-0:2.31.0-3.fc40.*
+`
+    dnf5 := `# Added by 'versionlock add' command on 2024-05-01 10:00:00
+Package name: htop
+evr = 3.3.0-3.fc40
+`
+    if got, want := parseDnfVersionlock(dnf4), []string{"htop", "python3-requests"}; !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseDnfVersionlock(dnf4) = %v, want %v", got, want)
+    }
+    if got, want := parseDnfVersionlock(dnf5), []string{"htop"}; !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseDnfVersionlock(dnf5) = %v, want %v", got, want)
+    }
+}
+
+func TestParseApkPins(t *testing.T) {
+    got := parseApkPins("alpine-base\nhtop=3.2.2-r1\ncurl>=8.0\nnano@testing\nvim~=9.0\n")
+    if want := []string{"htop"}; !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseApkPins() = %v, want %v", got, want)
+    }
+}
+
+// This test checks the commands each backend runs to hold packages.
+func TestHoldCommands(t *testing.T) {
+    tests := []struct {
+        family distro.Family
+        id     string
+        want   []string
+    }{
+        {distro.FamilyDebian, "debian", []string{"sudo apt-mark hold htop"}},
+        {distro.FamilyRHEL, "fedora", []string{"sudo dnf versionlock add htop"}},
+        {distro.FamilyArch, "arch", []string{`sudo sed -i /^\[options\]/a IgnorePkg = htop /etc/pacman.conf`}},
+        {distro.FamilySUSE, "opensuse-leap", []string{"sudo zypper addlock htop"}},
+        {distro.FamilyAlpine, "alpine", []string{"apk info -v", "sudo apk add htop=3.2.2-r1"}},
+    }
+    for _, tt := range tests {
+        mgr, runner, _ := newTestManager(tt.family, tt.id)
+        runner.Results = map[string]pkgmgrtest.Result{"apk info -v": {Output: "htop-3.2.2-r1\n"}}
+        if err := mgr.(Holder).Hold([]string{"htop"}, Options{}); err != nil {
+            t.Fatalf("%s Hold error = %v", tt.id, err)
+        }
+        if got := runner.Commands(); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s Hold commands = %q, want %q", tt.id, got, tt.want)
+        }
+    }
+}
+
+// This test checks that unholding on Arch only edits IgnorePkg lines.
+func TestPacmanUnholdExpressions(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    if err := mgr.(Holder).Unhold([]string{"gtk+"}, Options{}); err != nil {
+        t.Fatalf("Unhold error = %v", err)
+    }
+    want := [][]string{{
+        "sudo", "sed", "-i", "-E",
+        "-e", `/^[[:space:]]*IgnorePkg[[:space:]]*=/ s/([=[:space:]])gtk\+([[:space:]]|$)/\1\2/g`,
+        "-e", `/^[[:space:]]*IgnorePkg[[:space:]]*=[[:space:]]*$/d`,
+        "/etc/pacman.conf",
+    }}
+    if !reflect.DeepEqual(runner.Calls, want) {
+        t.Fatalf("Unhold argv = %q, want %q", runner.Calls, want)
+    }
+}
+
+// This test checks that names which could smuggle sed commands into
+// the pacman.conf edit are refused before anything runs.
+func TestPacmanHoldRejectsHostileNames(t *testing.T) {
+    hostile := []string{
+        "htop\n1e touch /tmp/pwned",
+        "htop\nw /etc/sudoers",
+        "htop; rm -rf ~",
+        "-e",
+        "",
+    }
+    for _, name := range hostile {
+        mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+        if err := mgr.(Holder).Hold([]string{"curl", name}, Options{}); err == nil {
+            t.Errorf("Hold(%q) succeeded, want an error", name)
+        }
+        if err := mgr.(Holder).Unhold([]string{name}, Options{}); err == nil {
+            t.Errorf("Unhold(%q) succeeded, want an error", name)
+        }
+        if len(runner.Calls) != 0 {
+            t.Errorf("Hold and Unhold of %q ran %q, want nothing", name, runner.Commands())
+        }
+    }
+}
+
+func TestMarkHeld(t *testing.T) {
+    upgrades := []Upgrade{{Name: "htop"}, {Name: "curl"}}
+    MarkHeld(upgrades, []string{"curl"})
+    if upgrades[0].Held || !upgrades[1].Held {
+        t.Fatalf("MarkHeld() = %+v, want only curl held", upgrades)
+    }
+}
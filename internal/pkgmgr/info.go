@@ -0,0 +1,117 @@
+package pkgmgr
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// ErrPackageNotFound is returned when the backend does not know a package.
+var ErrPackageNotFound = errors.New("package not found")
+
+// PackageInfo is the normalized result of Manager.Info. Sizes are in
+// bytes and are zero when the backend does not report them. Raw holds
+// the native output for people who want to see it unchanged. Source is
+// set by callers that look in more than one source.
+type PackageInfo struct {
+    Name          string   `json:"name"`
+    Version       string   `json:"version,omitempty"`
+    Repository    string   `json:"repository,omitempty"`
+    Size          int64    `json:"size,omitempty"`
+    InstalledSize int64    `json:"installed_size,omitempty"`
+    License       string   `json:"license,omitempty"`
+    Homepage      string   `json:"homepage,omitempty"`
+    Dependencies  []string `json:"dependencies,omitempty"`
+    Description   string   `json:"description,omitempty"`
+    Installed     bool     `json:"installed"`
+    Source        Source   `json:"source,omitempty"`
+    Raw           string   `json:"-"`
+}
+
+// parseKeyValues parses "Key : value" style output as printed by dnf,
+// pacman, and zypper. Continuation lines are indented, and dnf prefixes
+// them with ": ". Parsing stops when a key repeats, so only the first
+// package is read when several are listed. Keys are returned lower case.
+func parseKeyValues(out string) map[string]string {
+    fields := make(map[string]string)
+    last := ""
+    for _, line := range strings.Split(out, "\n") {
+        if strings.TrimSpace(line) == "" {
+            continue
+        }
+
+        indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+        trimmed := strings.TrimSpace(line)
+        if indented && last != "" && (strings.HasPrefix(trimmed, ":") || !strings.Contains(trimmed, " : ")) {
+            cont := strings.TrimSpace(strings.TrimPrefix(trimmed, ":"))
+            fields[last] = strings.TrimSpace(fields[last] + "\n" + cont)
+            continue
+        }
+
+        key, value, ok := strings.Cut(line, ":")
+        if !ok {
+            continue
+        }
+        key = strings.ToLower(strings.TrimSpace(key))
+        if _, seen := fields[key]; seen {
+            break
+        }
+        last = key
+        fields[last] = strings.TrimSpace(value)
+    }
+    return fields
+}
+
+// joinLines folds a multi line value into one paragraph.
+func joinLines(s string) string {
+    return strings.Join(strings.Fields(s), " ")
+}
+
+var sizeUnits = map[string]int64{
+    "b": 1, "byte": 1, "bytes": 1,
+    "k": 1024, "kb": 1000, "kib": 1024,
+    "m": 1024 * 1024, "mb": 1000 * 1000, "mib": 1024 * 1024,
+    "g": 1024 * 1024 * 1024, "gb": 1000 * 1000 * 1000, "gib": 1024 * 1024 * 1024,
+}
+
+// ParseSize turns sizes such as "336 KiB", "1.2 M", or "1,5 MiB"
+// into bytes. It returns 0 when the text is not a size.
+func ParseSize(s string) int64 {
+    fields := strings.Fields(s)
+    if len(fields) == 0 {
+        return 0
+    }
+    num := strings.ReplaceAll(fields[0], ",", ".")
+    unit := "b"
+    if len(fields) > 1 {
+        unit = strings.ToLower(fields[1])
+    } else if i := strings.IndexFunc(num, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }); i > 0 {
+        num, unit = num[:i], strings.ToLower(num[i:])
+    }
+
+    value, err := strconv.ParseFloat(num, 64)
+    if err != nil {
+        return 0
+    }
+    mult, ok := sizeUnits[unit]
+    if !ok {
+        return 0
+    }
+    return int64(value * float64(mult))
+}
+
+// FormatSize prints a byte count the way people read it, such as "1.2 MiB".
+func FormatSize(n int64) string {
+    if n < 1024 {
+        return fmt.Sprintf("%d B", n)
+    }
+    units := []string{"KiB", "MiB", "GiB", "TiB"}
+    value := float64(n) / 1024
+    i := 0
+    for value >= 1024 && i < len(units)-1 {
+        value /= 1024
+        i++
+    }
+    return fmt.Sprintf("%.1f %s", value, units[i])
+}
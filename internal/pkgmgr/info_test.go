@@ -0,0 +1,312 @@
+package pkgmgr
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func TestParseSize(t *testing.T) {
+    tests := []struct {
+        in   string
+        want int64
+    }{
+        {"336 KiB", 336 * 1024},
+        {"1.5 MiB", 1572864},
+        {"1,5 MiB", 1572864},
+        {"386 kB", 386000},
+        {"200 k", 200 * 1024},
+        {"2.0M", 2 * 1024 * 1024},
+        {"1234", 1234},
+        {"", 0},
+        {"unknown", 0},
+    }
+    for _, tc := range tests {
+        if got := ParseSize(tc.in); got != tc.want {
+            t.Fatalf("ParseSize(%q) = %d, want %d", tc.in, got, tc.want)
+        }
+    }
+}
+
+func TestFormatSize(t *testing.T) {
+    tests := []struct {
+        in   int64
+        want string
+    }{
+        {512, "512 B"},
+        {336 * 1024, "336.0 KiB"},
+        {1572864, "1.5 MiB"},
+    }
+    for _, tc := range tests {
+        if got := FormatSize(tc.in); got != tc.want {
+            t.Fatalf("FormatSize(%d) = %q, want %q", tc.in, got, tc.want)
+        }
+    }
+}
+
+func TestParseAptShow(t *testing.T) {
+    out := `Package: htop
+Version: 3.0.5-7build2
+Priority: optional
+Installed-Size: 342 kB
+Depends: libc6 (>= 2.34), libncursesw6 (>= 6), libnl-3-200 | libnl-3-dev
+Homepage: https://htop.dev/
+Download-Size: 128 kB
+APT-Sources: http://archive.ubuntu.com/ubuntu jammy/main amd64 Packages
+Description: interactive processes viewer
+ Htop is an ncursed-based process viewer similar to top.
+
+Package: htop
+Version: 3.0.0-1
+`
+    got := parseAptShow(out)
+    got.Raw = ""
+    want := &PackageInfo{
+        Name:          "htop",
+        Version:       "3.0.5-7build2",
+        Repository:    "http://archive.ubuntu.com/ubuntu jammy/main amd64 Packages",
+        Size:          128000,
+        InstalledSize: 342000,
+        Homepage:      "https://htop.dev/",
+        Dependencies:  []string{"libc6", "libncursesw6", "libnl-3-200 | libnl-3-dev"},
+        Description:   "interactive processes viewer\nHtop is an ncursed-based process viewer similar to top.",
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseAptShow() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseDnfInfo(t *testing.T) {
+    out := `Last metadata expiration check: 0:01:02 ago on Mon 01 Jan 2024.
+Installed Packages
+Name         : htop
+Version      : 3.3.0
+Release      : 3.fc40
+Architecture : x86_64
+Size         : 448 k
+Source       : htop-3.3.0-3.fc40.src.rpm
+Repository   : @System
+From repo    : fedora
+Summary      : Interactive process viewer
+URL          : https://htop.dev/
+License      : GPL-2.0-or-later
+Description  : htop is an interactive text-mode process viewer for Linux,
+             : similar to top.
+
+Available Packages
+Name         : htop
+Version      : 3.3.0
+`
+    got := parseDnfInfo(out)
+    got.Raw = ""
+    want := &PackageInfo{
+        Name:          "htop",
+        Version:       "3.3.0-3.fc40",
+        Repository:    "fedora",
+        InstalledSize: 448 * 1024,
+        License:       "GPL-2.0-or-later",
+        Homepage:      "https://htop.dev/",
+        Description:   "htop is an interactive text-mode process viewer for Linux, similar to top.",
+        Installed:     true,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseDnfInfo() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParsePacmanInfo(t *testing.T) {
+    out := `Repository      : extra
+Name            : htop
+Version         : 3.3.0-1
+Description     : Interactive process viewer
+URL             : https://htop.dev/
+Licenses        : GPL-2.0-or-later
+Depends On      : glibc  libcap  libcap.so=2-64  libnl
+                  ncurses
+Optional Deps   : lm_sensors: show cpu temperatures
+Download Size   : 170.90 KiB
+Installed Size  : 459.82 KiB
+`
+    got := parsePacmanInfo(out)
+    got.Raw = ""
+    want := &PackageInfo{
+        Name:          "htop",
+        Version:       "3.3.0-1",
+        Repository:    "extra",
+        Size:          ParseSize("170.90 KiB"),
+        InstalledSize: ParseSize("459.82 KiB"),
+        License:       "GPL-2.0-or-later",
+        Homepage:      "https://htop.dev/",
+        Dependencies:  []string{"glibc", "libcap", "libcap.so=2-64", "libnl", "ncurses"},
+        Description:   "Interactive process viewer",
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parsePacmanInfo() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseApkInfo(t *testing.T) {
+    out := `htop-3.2.2-r1 description:
+Interactive process viewer
+
+htop-3.2.2-r1 webpage:
+https://htop.dev/
+
+htop-3.2.2-r1 installed size:
+336 KiB
+
+htop-3.2.2-r1 depends on:
+so:libc.musl-x86_64.so.1
+so:libncursesw.so.6
+
+htop-3.2.2-r1 license:
+GPL-2.0-or-later
+
+`
+    got := parseApkInfo(out)
+    got.Raw = ""
+    want := &PackageInfo{
+        Name:          "htop",
+        Version:       "3.2.2-r1",
+        InstalledSize: 336 * 1024,
+        License:       "GPL-2.0-or-later",
+        Homepage:      "https://htop.dev/",
+        Dependencies:  []string{"so:libc.musl-x86_64.so.1", "so:libncursesw.so.6"},
+        Description:   "Interactive process viewer",
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseApkInfo() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseZypperInfo(t *testing.T) {
+    out := `Loading repository data...
+Reading installed packages...
+
+
+Information for package htop:
+-----------------------------
+Repository     : Main Repository (OSS)
+Name           : htop
+Version        : 3.2.2-1.3
+Arch           : x86_64
+Installed Size : 336.3 KiB
+Installed      : Yes
+Status         : up-to-date
+License        : GPL-2.0-or-later
+Upstream URL   : https://htop.dev/
+Summary        : An interactive process viewer
+Description    :
+    htop is an interactive text-mode process viewer for Linux.
+
+    It is similar to top.
+Requires       : [2]
+    libc.so.6()(64bit)
+    libncursesw.so.6()(64bit)
+`
+    got := parseZypperInfo(out)
+    got.Raw = ""
+    want := &PackageInfo{
+        Name:          "htop",
+        Version:       "3.2.2-1.3",
+        Repository:    "Main Repository (OSS)",
+        InstalledSize: ParseSize("336.3 KiB"),
+        License:       "GPL-2.0-or-later",
+        Homepage:      "https://htop.dev/",
+        Dependencies:  []string{"libc.so.6()(64bit)", "libncursesw.so.6()(64bit)"},
+        Description:   "htop is an interactive text-mode process viewer for Linux. It is similar to top.",
+        Installed:     true,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseZypperInfo() = %+v, want %+v", got, want)
+    }
+}
+
+// This test checks that Info reports unknown packages with
+// ErrPackageNotFound instead of an empty result.
+func TestInfoNotFound(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyDebian, "debian")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "apt show -- nosuchpkg": {Err: &ExitError{Argv: []string{"apt"}, Code: 100}},
+    }
+    if _, err := mgr.Info("nosuchpkg", Options{}); !errors.Is(err, ErrPackageNotFound) {
+        t.Fatalf("Info error = %v, want ErrPackageNotFound", err)
+    }
+}
+
+// This test checks that pacman falls back to the local database
+// for packages that are installed but not in any repository.
+func TestPacmanInfoLocalOnly(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "pacman -Si -- yay": {Err: &ExitError{Argv: []string{"pacman"}, Code: 1}},
+        "pacman -Qi -- yay": {Output: "Name            : yay\nVersion         : 12.3.5-1\n"},
+    }
+    info, err := mgr.Info("yay", Options{})
+    if err != nil {
+        t.Fatalf("Info error = %v", err)
+    }
+    if info.Name != "yay" || !info.Installed {
+        t.Fatalf("Info() = %+v, want installed yay", info)
+    }
+}
+
+func TestParseXbpsInfo(t *testing.T) {
+    out := `architecture: x86_64
+filename-size: 120KB
+homepage: https://htop.dev/
+installed_size: 336KB
+license: GPL-2.0-only
+pkgver: htop-3.3.0_1
+repository: https://repo-default.voidlinux.org/current
+run_depends:
+	libncursesw>=6.4_1
+	glibc>=2.36_1
+short_desc: Interactive process viewer
+`
+    got := parseXbpsInfo(out)
+    got.Raw = ""
+    want := &PackageInfo{
+        Name:          "htop",
+        Version:       "3.3.0_1",
+        Repository:    "https://repo-default.voidlinux.org/current",
+        Size:          120 * 1000,
+        InstalledSize: 336 * 1000,
+        License:       "GPL-2.0-only",
+        Homepage:      "https://htop.dev/",
+        Dependencies:  []string{"libncursesw", "glibc"},
+        Description:   "Interactive process viewer",
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseXbpsInfo() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseEopkgInfo(t *testing.T) {
+    out := `Installed package:
+Name                : htop, version: 3.3.0, release: 45
+Summary             : Interactive process viewer
+Licenses            : GPL-2.0-or-later
+Component           : system.utils
+Dependencies        : ncurses libnl
+Package found in Solus repository:
+Name                : htop, version: 3.3.0, release: 46
+`
+    got := parseEopkgInfo(out)
+    got.Raw = ""
+    want := &PackageInfo{
+        Name:         "htop",
+        Version:      "3.3.0-45",
+        Repository:   "system.utils",
+        License:      "GPL-2.0-or-later",
+        Dependencies: []string{"ncurses", "libnl"},
+        Description:  "Interactive process viewer",
+        Installed:    true,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseEopkgInfo() = %+v, want %+v", got, want)
+    }
+}
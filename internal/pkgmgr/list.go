@@ -0,0 +1,89 @@
+package pkgmgr
+
+import (
+    "os"
+    "sort"
+    "strings"
+    "time"
+)
+
+// InstalledPackage is one package reported by Lister.List.
+// Size is the installed size in bytes. Size and InstallDate are
+// zero when the backend does not record them.
+type InstalledPackage struct {
+    Name        string    `json:"name"`
+    Version     string    `json:"version"`
+    Explicit    bool      `json:"explicit"`
+    Size        int64     `json:"size,omitempty"`
+    InstallDate time.Time `json:"install_date,omitzero"`
+}
+
+// Lister is implemented by managers that can list installed packages.
+type Lister interface {
+    List(opts Options) ([]InstalledPackage, error)
+}
+
+// ListFilter selects which installed packages to keep.
+type ListFilter int
+
+const (
+    ListAll ListFilter = iota
+    // ListExplicit keeps packages the user asked for by name.
+    ListExplicit
+    // ListDependencies keeps packages pulled in by something else.
+    ListDependencies
+)
+
+// FilterInstalled returns the packages that match filter.
+func FilterInstalled(pkgs []InstalledPackage, filter ListFilter) []InstalledPackage {
+    if filter == ListAll {
+        return pkgs
+    }
+    var out []InstalledPackage
+    for _, p := range pkgs {
+        if p.Explicit == (filter == ListExplicit) {
+            out = append(out, p)
+        }
+    }
+    return out
+}
+
+// SortInstalled sorts packages by "name", "date" (newest first), or
+// "size" (largest first). Packages with unknown dates or sizes go last.
+func SortInstalled(pkgs []InstalledPackage, by string) {
+    sort.SliceStable(pkgs, func(i, j int) bool {
+        a, b := pkgs[i], pkgs[j]
+        switch by {
+        case "date":
+            if !a.InstallDate.Equal(b.InstallDate) {
+                return a.InstallDate.After(b.InstallDate)
+            }
+        case "size":
+            if a.Size != b.Size {
+                return a.Size > b.Size
+            }
+        }
+        return a.Name < b.Name
+    })
+}
+
+// fileModTime returns when path was last changed, or the zero time.
+// It is a variable so tests do not depend on the local filesystem.
+var fileModTime = func(path string) time.Time {
+    st, err := os.Stat(path)
+    if err != nil {
+        return time.Time{}
+    }
+    return st.ModTime()
+}
+
+// nameSet turns one name per line into a set, ignoring blank lines.
+func nameSet(out string) map[string]bool {
+    set := make(map[string]bool)
+    for _, line := range strings.Split(out, "\n") {
+        if line = strings.TrimSpace(line); line != "" {
+            set[line] = true
+        }
+    }
+    return set
+}
@@ -0,0 +1,157 @@
+package pkgmgr
+
+import (
+    "encoding/json"
+    "reflect"
+    "testing"
+    "time"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func TestParseDpkgList(t *testing.T) {
+    when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+    old := fileModTime
+    fileModTime = func(path string) time.Time {
+        if path == "/var/lib/dpkg/info/libc6:amd64.list" {
+            return when
+        }
+        return time.Time{}
+    }
+    defer func() { fileModTime = old }()
+
+    out := "htop\thtop\t3.0.5-7build2\t342\tii \n" +
+        "libc6\tlibc6:amd64\t2.35-0ubuntu3\t13000\tii \n" +
+        "oldpkg\toldpkg\t1.0\t10\trc \n"
+    got := parseDpkgList(out, map[string]bool{"htop": true})
+    want := []InstalledPackage{
+        {Name: "htop", Version: "3.0.5-7build2", Explicit: true, Size: 342 * 1024},
+        {Name: "libc6", Version: "2.35-0ubuntu3", Size: 13000 * 1024, InstallDate: when},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseDpkgList() = %+v, want %+v", got, want)
+    }
+}
+
+func TestNevraName(t *testing.T) {
+    tests := map[string]string{
+        "htop-0:3.3.0-3.fc40.x86_64":       "htop",
+        "python3-dnf-4.19.0-1.fc40.noarch": "python3-dnf",
+        "kernel-core-6.8.5-301.fc40.x86_64": "kernel-core",
+    }
+    for in, want := range tests {
+        if got := nevraName(in); got != want {
+            t.Fatalf("nevraName(%q) = %q, want %q", in, got, want)
+        }
+    }
+}
+
+func TestDnfList(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyRHEL, "fedora")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "dnf repoquery --quiet --userinstalled": {Output: "htop-0:3.3.0-3.fc40.x86_64\n"},
+        `rpm -qa --queryformat %{NAME}\t%{VERSION}-%{RELEASE}\t%{SIZE}\t%{INSTALLTIME}\n`: {
+            Output: "htop\t3.3.0-3.fc40\t458752\t1704164645\n" +
+                "glibc\t2.39-4.fc40\t6700000\t1704000000\n" +
+                "gpg-pubkey\ta15b79cc-63d04c2c\t0\t1704000000\n",
+        },
+    }
+
+    got, err := mgr.(Lister).List(Options{})
+    if err != nil {
+        t.Fatalf("List error = %v", err)
+    }
+    want := []InstalledPackage{
+        {Name: "htop", Version: "3.3.0-3.fc40", Explicit: true, Size: 458752, InstallDate: time.Unix(1704164645, 0)},
+        {Name: "glibc", Version: "2.39-4.fc40", Size: 6700000, InstallDate: time.Unix(1704000000, 0)},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("List() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParsePacmanList(t *testing.T) {
+    out := `Name            : htop
+Version         : 3.3.0-1
+Installed Size  : 459.82 KiB
+Install Date    : Tue 02 Jan 2024 10:15:00 AM UTC
+Install Reason  : Explicitly installed
+
+Name            : ncurses
+Version         : 6.4_20230520-1
+Installed Size  : 8.91 MiB
+Install Date    : Mon 01 Jan 2024 09:00:00 AM UTC
+Install Reason  : Installed as a dependency for another package
+`
+    got := parsePacmanList(out)
+    if len(got) != 2 {
+        t.Fatalf("parsePacmanList() returned %d packages, want 2", len(got))
+    }
+    if got[0].Name != "htop" || !got[0].Explicit || got[0].Size != ParseSize("459.82 KiB") {
+        t.Fatalf("first package = %+v", got[0])
+    }
+    if want := time.Date(2024, 1, 2, 10, 15, 0, 0, time.UTC); !got[0].InstallDate.Equal(want) {
+        t.Fatalf("install date = %v, want %v", got[0].InstallDate, want)
+    }
+    if got[1].Name != "ncurses" || got[1].Explicit {
+        t.Fatalf("second package = %+v", got[1])
+    }
+}
+
+func TestParseApkWorld(t *testing.T) {
+    got := parseApkWorld("alpine-base\nhtop>=3.2\nvim=9.0.1\nfoo@testing\n")
+    want := map[string]bool{"alpine-base": true, "htop": true, "vim": true, "foo": true}
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseApkWorld() = %v, want %v", got, want)
+    }
+}
+
+func TestFilterAndSortInstalled(t *testing.T) {
+    pkgs := []InstalledPackage{
+        {Name: "b", Size: 10, InstallDate: time.Unix(200, 0), Explicit: true},
+        {Name: "a", Size: 30},
+        {Name: "c", Size: 20, InstallDate: time.Unix(300, 0)},
+    }
+
+    explicit := FilterInstalled(pkgs, ListExplicit)
+    if len(explicit) != 1 || explicit[0].Name != "b" {
+        t.Fatalf("FilterInstalled(explicit) = %+v", explicit)
+    }
+    if deps := FilterInstalled(pkgs, ListDependencies); len(deps) != 2 {
+        t.Fatalf("FilterInstalled(deps) = %+v", deps)
+    }
+
+    names := func() []string {
+        var out []string
+        for _, p := range pkgs {
+            out = append(out, p.Name)
+        }
+        return out
+    }
+    SortInstalled(pkgs, "size")
+    if got := names(); !reflect.DeepEqual(got, []string{"a", "c", "b"}) {
+        t.Fatalf("sort by size = %v", got)
+    }
+    SortInstalled(pkgs, "date")
+    if got := names(); !reflect.DeepEqual(got, []string{"c", "b", "a"}) {
+        t.Fatalf("sort by date = %v", got)
+    }
+}
+
+// This test checks that list --json leaves out unknown install dates
+// instead of printing the zero time.
+func TestInstalledPackageJSON(t *testing.T) {
+    out, err := json.Marshal([]InstalledPackage{
+        {Name: "busybox", Version: "1.36.1-r29", Explicit: true},
+        {Name: "htop", Version: "3.3.0-1", InstallDate: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := `[{"name":"busybox","version":"1.36.1-r29","explicit":true},` +
+        `{"name":"htop","version":"3.3.0-1","explicit":false,"install_date":"2024-01-02T03:04:05Z"}]`
+    if string(out) != want {
+        t.Fatalf("json.Marshal() = %s, want %s", out, want)
+    }
+}
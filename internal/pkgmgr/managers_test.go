@@ -0,0 +1,337 @@
+package pkgmgr
+
+import (
+    "bytes"
+    "errors"
+    "io"
+    "reflect"
+    "strings"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+    "penguinguide/internal/ui"
+)
+
+func newTestManager(family distro.Family, id string, answers ...bool) (Manager, *pkgmgrtest.Runner, *pkgmgrtest.Prompter) {
+    runner := &pkgmgrtest.Runner{}
+    prompter := &pkgmgrtest.Prompter{Answers: answers}
+    env := Env{Runner: runner, Prompter: prompter, Out: io.Discard}
+    return NewWithEnv(&distro.Distro{Family: family, ID: id}, env), runner, prompter
+}
+
+// This test checks the exact argv each backend runs for install,
+// remove, and update, with and without --yes.
+func TestManagerCommands(t *testing.T) {
+    pkgs := []string{"htop", "foo; rm -rf ~"}
+
+    tests := []struct {
+        name   string
+        family distro.Family
+        id     string
+        opts   Options
+        call   func(m Manager, opts Options) error
+        want   []string
+    }{
+        {"apt install", distro.FamilyDebian, "debian", Options{},
+            func(m Manager, o Options) error { return m.Install(pkgs, o) },
+            []string{"sudo apt install -- htop foo; rm -rf ~"}},
+        {"apt install yes", distro.FamilyDebian, "debian", Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Install(pkgs, o) },
+            []string{"sudo apt install -y -- htop foo; rm -rf ~"}},
+        {"apt update", distro.FamilyDebian, "debian", Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.UpdateAll(o) },
+            []string{"sudo apt update", "sudo apt upgrade -y"}},
+        {"dnf remove yes", distro.FamilyRHEL, "fedora", Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Remove([]string{"htop"}, o) },
+            []string{"sudo dnf remove -y -- htop"}},
+        {"dnf update", distro.FamilyRHEL, "fedora", Options{},
+            func(m Manager, o Options) error { return m.UpdateAll(o) },
+            []string{"sudo dnf upgrade"}},
+        {"pacman install yes", distro.FamilyArch, "arch", Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Install([]string{"htop"}, o) },
+            []string{"sudo pacman -S --noconfirm -- htop"}},
+        {"pacman remove", distro.FamilyArch, "arch", Options{},
+            func(m Manager, o Options) error { return m.Remove([]string{"htop"}, o) },
+            []string{"sudo pacman -R -- htop"}},
+        {"apk install", distro.FamilyAlpine, "alpine", Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Install([]string{"htop"}, o) },
+            []string{"sudo apk add -- htop"}},
+        {"apk update", distro.FamilyAlpine, "alpine", Options{},
+            func(m Manager, o Options) error { return m.UpdateAll(o) },
+            []string{"sudo apk update", "sudo apk upgrade"}},
+        {"zypper tumbleweed update", distro.FamilySUSE, "opensuse-tumbleweed", Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.UpdateAll(o) },
+            []string{"sudo zypper --non-interactive refresh", "sudo zypper --non-interactive dist-upgrade"}},
+        {"zypper leap update", distro.FamilySUSE, "opensuse-leap", Options{},
+            func(m Manager, o Options) error { return m.UpdateAll(o) },
+            []string{"sudo zypper refresh", "sudo zypper update"}},
+    }
+
+    for _, tc := range tests {
+        t.Run(tc.name, func(t *testing.T) {
+            mgr, runner, _ := newTestManager(tc.family, tc.id)
+            if err := tc.call(mgr, tc.opts); err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := runner.Commands(); !reflect.DeepEqual(got, tc.want) {
+                t.Fatalf("commands = %q, want %q", got, tc.want)
+            }
+        })
+    }
+
+    // The package name with shell characters must stay a single argument.
+    mgr, runner, _ := newTestManager(distro.FamilyDebian, "debian")
+    _ = mgr.Install(pkgs, Options{})
+    argv := runner.Calls[0]
+    if argv[len(argv)-1] != "foo; rm -rf ~" {
+        t.Fatalf("last argument = %q, want the package name unchanged", argv[len(argv)-1])
+    }
+}
+
+// This test checks the managers for distributions outside the main
+// families, which are picked by subfamily.
+func TestSubfamilyManagerCommands(t *testing.T) {
+    profile := false
+    old := nixProfileInUse
+    nixProfileInUse = func() bool { return profile }
+    t.Cleanup(func() { nixProfileInUse = old })
+
+    tests := []struct {
+        name      string
+        subfamily distro.Subfamily
+        profile   bool
+        opts      Options
+        call      func(m Manager, opts Options) error
+        want      []string
+    }{
+        {"xbps install", distro.SubfamilyVoid, false, Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Install([]string{"htop"}, o) },
+            []string{"sudo xbps-install -S -y htop"}},
+        {"xbps update", distro.SubfamilyVoid, false, Options{},
+            func(m Manager, o Options) error { return m.UpdateAll(o) },
+            []string{"sudo xbps-install -Su"}},
+        {"emerge install", distro.SubfamilyGentoo, false, Options{},
+            func(m Manager, o Options) error { return m.Install([]string{"htop", "vim@9.1.0"}, o) },
+            []string{"sudo emerge --ask --verbose htop =vim-9.1.0"}},
+        {"emerge remove yes", distro.SubfamilyGentoo, false, Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Remove([]string{"htop"}, o) },
+            []string{"sudo emerge --depclean htop"}},
+        {"emerge update", distro.SubfamilyGentoo, false, Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.UpdateAll(o) },
+            []string{"sudo emaint --auto sync", "sudo emerge --verbose --update --deep --newuse @world"}},
+        {"nix-env install", distro.SubfamilyNixOS, false, Options{},
+            func(m Manager, o Options) error { return m.Install([]string{"htop"}, o) },
+            []string{"nix-env --install --attr nixos.htop"}},
+        {"nix profile install", distro.SubfamilyNixOS, true, Options{},
+            func(m Manager, o Options) error { return m.Install([]string{"htop"}, o) },
+            []string{"nix --extra-experimental-features nix-command flakes profile install nixpkgs#htop"}},
+        {"nix update", distro.SubfamilyNixOS, false, Options{},
+            func(m Manager, o Options) error { return m.UpdateAll(o) },
+            []string{"sudo nixos-rebuild switch --upgrade", "nix-env --upgrade"}},
+        {"eopkg remove yes", distro.SubfamilySolus, false, Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Remove([]string{"htop"}, o) },
+            []string{"sudo eopkg remove -y htop"}},
+    }
+    for _, tt := range tests {
+        profile = tt.profile
+        runner := &pkgmgrtest.Runner{}
+        env := Env{Runner: runner, Prompter: &pkgmgrtest.Prompter{}, Out: io.Discard}
+        mgr := NewWithEnv(&distro.Distro{Family: distro.FamilyOther, Subfamily: tt.subfamily, ID: string(tt.subfamily)}, env)
+        if err := tt.call(mgr, tt.opts); err != nil {
+            t.Fatalf("%s: unexpected error: %v", tt.name, err)
+        }
+        if got := runner.Commands(); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s: commands = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+// This test checks that the NixOS configuration hint shows the rebuild
+// command the way this system gets root.
+func TestNixHintUsesElevation(t *testing.T) {
+    old := nixProfileInUse
+    nixProfileInUse = func() bool { return false }
+    t.Cleanup(func() { nixProfileInUse = old })
+
+    for tool, want := range map[string]string{
+        "doas": "run " + ui.Value("doas nixos-rebuild switch"),
+        "":     "run " + ui.Value("nixos-rebuild switch"),
+    } {
+        var out bytes.Buffer
+        env := Env{Runner: &pkgmgrtest.Runner{}, Prompter: &pkgmgrtest.Prompter{}, Out: &out, Elevation: &Elevation{Tool: tool}}
+        mgr := NewWithEnv(&distro.Distro{Family: distro.FamilyOther, Subfamily: distro.SubfamilyNixOS, ID: "nixos"}, env)
+        if err := mgr.Install([]string{"htop"}, Options{AssumeYes: true}); err != nil {
+            t.Fatalf("Install error = %v", err)
+        }
+        if !strings.Contains(out.String(), want) {
+            t.Errorf("hint with elevation %q = %q, want it to contain %q", tool, out.String(), want)
+        }
+    }
+}
+
+// This test checks that image-based systems install through their
+// own tools, while searches still use dnf and zypper.
+func TestAtomicManagerCommands(t *testing.T) {
+    tests := []struct {
+        name string
+        d    distro.Distro
+        opts Options
+        call func(m Manager, opts Options) error
+        want []string
+    }{
+        {"rpm-ostree install", distro.Distro{Family: distro.FamilyRHEL, ID: "fedora", Atomic: distro.AtomicOSTree}, Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Install([]string{"htop"}, o) },
+            []string{"rpm-ostree install htop"}},
+        {"rpm-ostree remove", distro.Distro{Family: distro.FamilyRHEL, ID: "fedora", Atomic: distro.AtomicOSTree}, Options{},
+            func(m Manager, o Options) error { return m.Remove([]string{"htop"}, o) },
+            []string{"rpm-ostree uninstall htop"}},
+        {"rpm-ostree search", distro.Distro{Family: distro.FamilyRHEL, ID: "fedora", Atomic: distro.AtomicOSTree}, Options{},
+            func(m Manager, o Options) error { _, err := m.Search("htop", o); return err },
+            []string{"dnf search -- htop"}},
+        {"transactional-update install", distro.Distro{Family: distro.FamilySUSE, ID: "opensuse-aeon", Atomic: distro.AtomicTransactional}, Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Install([]string{"htop"}, o) },
+            []string{"sudo transactional-update --non-interactive pkg install htop"}},
+        {"transactional-update update", distro.Distro{Family: distro.FamilySUSE, ID: "opensuse-microos", Atomic: distro.AtomicTransactional}, Options{},
+            func(m Manager, o Options) error { return m.UpdateAll(o) },
+            []string{"sudo transactional-update dup"}},
+        {"rpm-ostree versioned install", distro.Distro{Family: distro.FamilyRHEL, ID: "fedora", Atomic: distro.AtomicOSTree}, Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Install([]string{"htop@3.3.0"}, o) },
+            []string{"rpm-ostree install htop-3.3.0"}},
+        {"transactional-update versioned install", distro.Distro{Family: distro.FamilySUSE, ID: "opensuse-aeon", Atomic: distro.AtomicTransactional}, Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.Install([]string{"htop@3.3.0"}, o) },
+            []string{"sudo transactional-update --non-interactive pkg install htop=3.3.0"}},
+        {"transactional-update downgrade", distro.Distro{Family: distro.FamilySUSE, ID: "opensuse-aeon", Atomic: distro.AtomicTransactional}, Options{AssumeYes: true},
+            func(m Manager, o Options) error { return m.(Versioner).Downgrade("htop", "3.2.2", o) },
+            []string{"sudo transactional-update --non-interactive pkg install --oldpackage htop=3.2.2"}},
+    }
+    for _, tt := range tests {
+        runner := &pkgmgrtest.Runner{}
+        env := Env{Runner: runner, Prompter: &pkgmgrtest.Prompter{}, Out: io.Discard}
+        mgr := NewWithEnv(&tt.d, env)
+        if err := tt.call(mgr, tt.opts); err != nil {
+            t.Fatalf("%s: unexpected error: %v", tt.name, err)
+        }
+        if got := runner.Commands(); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s: commands = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+// This test checks that image-based systems keep every read-only
+// capability of dnf and zypper, but none that would change the system
+// behind the image tool's back.
+func TestAtomicManagerCapabilities(t *testing.T) {
+    for _, d := range []distro.Distro{
+        {Family: distro.FamilyRHEL, ID: "fedora", Atomic: distro.AtomicOSTree},
+        {Family: distro.FamilySUSE, ID: "opensuse-aeon", Atomic: distro.AtomicTransactional},
+    } {
+        mgr := NewWithEnv(&d, Env{Runner: &pkgmgrtest.Runner{}, Out: io.Discard})
+        for name, ok := range map[string]bool{
+            "Lister":         is[Lister](mgr),
+            "UpdateChecker":  is[UpdateChecker](mgr),
+            "ProvidesFinder": is[ProvidesFinder](mgr),
+            "NameLister":     is[NameLister](mgr),
+            "Versioner":      is[Versioner](mgr),
+        } {
+            if !ok {
+                t.Errorf("%s manager is not a %s", d.Atomic, name)
+            }
+        }
+        if is[Holder](mgr) || is[RepoManager](mgr) || is[Cleaner](mgr) {
+            t.Errorf("%s manager can hold, manage repositories, or clean up, want none of them", d.Atomic)
+        }
+    }
+    mgr := NewWithEnv(&distro.Distro{Family: distro.FamilyRHEL, ID: "fedora", Atomic: distro.AtomicOSTree}, Env{Runner: &pkgmgrtest.Runner{}, Out: io.Discard})
+    if !is[DepsFinder](mgr) {
+        t.Error("rpm-ostree manager is not a DepsFinder")
+    }
+    if err := mgr.(Versioner).Downgrade("htop", "3.2.2", Options{}); err == nil {
+        t.Error("rpm-ostree Downgrade succeeded, want an error pointing to rpm-ostree rollback")
+    }
+}
+
+func is[T any](m Manager) bool {
+    _, ok := m.(T)
+    return ok
+}
+
+// This test checks that dry run asks before running and only runs
+// the commands when the user says yes.
+func TestDryRunPrompt(t *testing.T) {
+    mgr, runner, prompter := newTestManager(distro.FamilyArch, "arch", false)
+    if err := mgr.Install([]string{"htop"}, Options{DryRun: true}); err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if len(prompter.Questions) != 1 {
+        t.Fatalf("questions = %q, want one confirmation", prompter.Questions)
+    }
+    if len(runner.Calls) != 0 {
+        t.Fatalf("commands ran after the user declined: %q", runner.Commands())
+    }
+
+    mgr, runner, _ = newTestManager(distro.FamilyArch, "arch", true)
+    if err := mgr.Install([]string{"htop"}, Options{DryRun: true}); err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if got := runner.Commands(); !reflect.DeepEqual(got, []string{"sudo pacman -S -- htop"}) {
+        t.Fatalf("commands = %q, want the install to run after confirmation", got)
+    }
+}
+
+// This test checks how runner errors are reported: a failing step stops
+// the plan and is returned, while Ctrl+C is treated as a quiet cancel.
+func TestExitHandling(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyDebian, "debian")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "sudo apt update": {Err: &ExitError{Argv: []string{"sudo"}, Code: 100}},
+    }
+    err := mgr.UpdateAll(Options{})
+    var exitErr *ExitError
+    if !errors.As(err, &exitErr) || exitErr.Code != 100 {
+        t.Fatalf("UpdateAll error = %v, want exit status 100", err)
+    }
+    if len(runner.Calls) != 1 {
+        t.Fatalf("commands = %q, want the upgrade to be skipped after a failed update", runner.Commands())
+    }
+
+    mgr, runner, _ = newTestManager(distro.FamilyRHEL, "fedora")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "sudo dnf install -- htop": {Err: ErrCanceled},
+    }
+    if err := mgr.Install([]string{"htop"}, Options{}); err != nil {
+        t.Fatalf("Install error = %v, want nil after Ctrl+C", err)
+    }
+}
+
+type recordingRecorder struct {
+    ops []Operation
+}
+
+func (r *recordingRecorder) Record(op Operation) {
+    r.ops = append(r.ops, op)
+}
+
+// This test checks that executed plans are reported to the recorder
+// with their exit status, and that skipped dry runs are not.
+func TestRecorderSeesExecutedPlans(t *testing.T) {
+    rec := &recordingRecorder{}
+    runner := &pkgmgrtest.Runner{Results: map[string]pkgmgrtest.Result{
+        "sudo pacman -R -- vim": {Err: &ExitError{Argv: []string{"sudo"}, Code: 1}},
+    }}
+    prompter := &pkgmgrtest.Prompter{Answers: []bool{false}}
+    env := Env{Runner: runner, Prompter: prompter, Out: io.Discard, Recorder: rec}
+    mgr := NewWithEnv(&distro.Distro{Family: distro.FamilyArch, ID: "arch"}, env)
+
+    _ = mgr.Install([]string{"htop"}, Options{DryRun: true})
+    _ = mgr.Install([]string{"htop"}, Options{})
+    _ = mgr.Remove([]string{"vim"}, Options{})
+
+    want := []Operation{
+        {Action: "install", Packages: []string{"htop"}, Command: "sudo pacman -S -- htop", ExitCode: 0},
+        {Action: "remove", Packages: []string{"vim"}, Command: "sudo pacman -R -- vim", ExitCode: 1},
+    }
+    if !reflect.DeepEqual(rec.ops, want) {
+        t.Fatalf("recorded = %+v, want %+v", rec.ops, want)
+    }
+}
@@ -0,0 +1,250 @@
+package pkgmgr
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "penguinguide/internal/ui"
+)
+
+/********** Nix **********/
+
+// nixManager installs packages into the user's Nix profile. On NixOS
+// the system itself comes from /etc/nixos/configuration.nix, so that
+// is where packages everyone needs belong. The profile is for the
+// rest, and it needs no root.
+type nixManager struct {
+    env Env
+}
+
+// nixProfileInUse reports whether the user's profile is managed by
+// nix profile rather than nix-env, which cannot read each other's
+// profiles. It is a variable so tests do not depend on the home
+// directory of whoever runs them.
+var nixProfileInUse = func() bool {
+    home, err := os.UserHomeDir()
+    if err != nil {
+        return false
+    }
+    _, err = os.Stat(filepath.Join(home, ".nix-profile", "manifest.json"))
+    return err == nil
+}
+
+// nixCommand returns a nix command with the new CLI turned on, since
+// many installs still have it behind an experimental flag.
+func nixCommand(args ...string) []string {
+    return append([]string{"nix", "--extra-experimental-features", "nix-command flakes"}, args...)
+}
+
+// explainConfiguration tells people where NixOS wants packages to go,
+// before they install something the imperative way.
+func (m *nixManager) explainConfiguration() {
+    fmt.Fprintln(m.env.Out, ui.Warning("On NixOS, the preferred place for packages is your system configuration."))
+    fmt.Fprintln(m.env.Out, "  Add them to environment.systemPackages in /etc/nixos/configuration.nix")
+    rebuild := m.env.elevate(newPlan("", rootStep("nixos-rebuild", "switch"))).String()
+    fmt.Fprintln(m.env.Out, "  and run "+ui.Value(rebuild)+". That way they survive a")
+    fmt.Fprintln(m.env.Out, "  reinstall and every change can be rolled back. penguinguide installs")
+    fmt.Fprintln(m.env.Out, "  into your user profile instead, which is handy for trying things out.")
+    fmt.Fprintln(m.env.Out)
+}
+
+func (m *nixManager) UpdateAll(opts Options) error {
+    user := userStep("nix-env", "--upgrade")
+    if nixProfileInUse() {
+        user = userStep(nixCommand("profile", "upgrade", "--all")...)
+    }
+    plan := newPlan("Rebuild the system from configuration.nix with the newest channel, then upgrade the packages in your user profile",
+        rootStep("nixos-rebuild", "switch", "--upgrade"),
+        user,
+    ).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *nixManager) Install(pkgs []string, opts Options) error {
+    m.explainConfiguration()
+    var step Step
+    if nixProfileInUse() {
+        args := nixCommand("profile", "install")
+        for _, p := range pkgs {
+            args = append(args, "nixpkgs#"+p)
+        }
+        step = userStep(args...)
+    } else {
+        args := []string{"nix-env", "--install", "--attr"}
+        for _, p := range pkgs {
+            args = append(args, "nixos."+p)
+        }
+        step = userStep(args...)
+    }
+    plan := newPlan("Install packages into your user profile with Nix", step).forAction("install", pkgs...)
+    return notFound(m.env.runOrPrint(plan, opts), nixNotFound)
+}
+
+func (m *nixManager) Remove(pkgs []string, opts Options) error {
+    step := userStep(append([]string{"nix-env", "--uninstall"}, pkgs...)...)
+    if nixProfileInUse() {
+        step = userStep(append(nixCommand("profile", "remove"), pkgs...)...)
+    }
+    plan := newPlan("Remove packages from your user profile with Nix. Packages from configuration.nix stay until you take them out there", step).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *nixManager) Search(query string, opts Options) ([]SearchResult, error) {
+    step := userStep(nixCommand("search", "nixpkgs", query, "--json")...)
+    out, err := m.env.query(newPlan("Search nixpkgs with nix search", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    results, err := parseNixSearch(out)
+    if err != nil || len(results) == 0 {
+        return nil, err
+    }
+
+    installed, err := m.installed(opts)
+    if err != nil {
+        return nil, err
+    }
+    markInstalled(results, installed)
+    return results, nil
+}
+
+func (m *nixManager) Info(name string, opts Options) (*PackageInfo, error) {
+    step := userStep(nixCommand("search", "nixpkgs", "^"+name+"$", "--json")...)
+    out, err := m.env.query(newPlan("Show package details with nix search", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    results, err := parseNixSearch(out)
+    if err != nil {
+        return nil, err
+    }
+    for _, r := range results {
+        if r.Name != name {
+            continue
+        }
+        installed, err := m.installed(opts)
+        if err != nil {
+            return nil, err
+        }
+        _, ok := installed[name]
+        return &PackageInfo{
+            Name:        r.Name,
+            Version:     r.Version,
+            Repository:  "nixpkgs",
+            Description: r.Summary,
+            Installed:   ok,
+            Raw:         string(out),
+        }, nil
+    }
+    return nil, ErrPackageNotFound
+}
+
+// installed returns the packages in the user's profile mapped to
+// their versions, which are unknown for nix profile.
+func (m *nixManager) installed(opts Options) (map[string]string, error) {
+    if nixProfileInUse() {
+        out, err := m.env.query(newPlan("List your user profile with nix profile", userStep(nixCommand("profile", "list", "--json")...)), opts)
+        if err != nil {
+            return nil, err
+        }
+        return parseNixProfileList(out)
+    }
+    out, err := m.env.query(newPlan("List your user profile with nix-env", userStep("nix-env", "--query")), opts)
+    if err != nil {
+        return nil, err
+    }
+    installed := make(map[string]string)
+    for _, line := range strings.Split(string(out), "\n") {
+        if line = strings.TrimSpace(line); line != "" {
+            name, version := splitNixName(line)
+            installed[name] = version
+        }
+    }
+    return installed, nil
+}
+
+// parseNixSearch parses nix search --json output, which maps the
+// attribute path of each package to its details:
+//
+//	{"legacyPackages.x86_64-linux.htop": {"pname": "htop", "version": "3.3.0", "description": "..."}}
+//
+// The name is the attribute path without the system, which is what
+// nix profile install and nix-env --attr take.
+func parseNixSearch(out []byte) ([]SearchResult, error) {
+    var found map[string]struct {
+        Version     string `json:"version"`
+        Description string `json:"description"`
+    }
+    if err := json.Unmarshal(out, &found); err != nil {
+        return nil, fmt.Errorf("reading nix search output: %w", err)
+    }
+    results := make([]SearchResult, 0, len(found))
+    for attr, pkg := range found {
+        results = append(results, SearchResult{
+            Name:       nixAttrName(attr),
+            Version:    pkg.Version,
+            Repository: "nixpkgs",
+            Summary:    pkg.Description,
+        })
+    }
+    sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+    return results, nil
+}
+
+// parseNixProfileList parses nix profile list --json. Nix 2.20 and
+// later key the elements by name, older versions list them in an
+// array and only the attribute path says which package each one is.
+func parseNixProfileList(out []byte) (map[string]string, error) {
+    var list struct {
+        Elements json.RawMessage `json:"elements"`
+    }
+    if err := json.Unmarshal(out, &list); err != nil {
+        return nil, fmt.Errorf("reading nix profile list output: %w", err)
+    }
+    type element struct {
+        AttrPath string `json:"attrPath"`
+    }
+    installed := make(map[string]string)
+    var byName map[string]element
+    if err := json.Unmarshal(list.Elements, &byName); err == nil {
+        for name := range byName {
+            installed[name] = ""
+        }
+        return installed, nil
+    }
+    var byIndex []element
+    if err := json.Unmarshal(list.Elements, &byIndex); err != nil {
+        return nil, fmt.Errorf("reading nix profile list output: %w", err)
+    }
+    for _, e := range byIndex {
+        if e.AttrPath != "" {
+            installed[nixAttrName(e.AttrPath)] = ""
+        }
+    }
+    return installed, nil
+}
+
+// nixAttrName turns legacyPackages.x86_64-linux.python3Packages.requests
+// into python3Packages.requests.
+func nixAttrName(attr string) string {
+    parts := strings.SplitN(attr, ".", 3)
+    if len(parts) == 3 && (parts[0] == "legacyPackages" || parts[0] == "packages") {
+        return parts[2]
+    }
+    return attr
+}
+
+// splitNixName splits a nix-env name such as python3-3.12.3 at the
+// first dash followed by a digit, the way Nix itself does.
+func splitNixName(s string) (string, string) {
+    for i := 0; i < len(s)-1; i++ {
+        if s[i] == '-' && isDigit(s[i+1]) {
+            return s[:i], s[i+1:]
+        }
+    }
+    return s, ""
+}
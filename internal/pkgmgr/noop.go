@@ -0,0 +1,29 @@
+package pkgmgr
+
+import "fmt"
+
+/********** Fallback **********/
+
+type noopManager struct {
+    distroID string
+}
+
+func (m *noopManager) UpdateAll(opts Options) error {
+    return fmt.Errorf("package manager not implemented for distro %q", m.distroID)
+}
+
+func (m *noopManager) Install(pkgs []string, opts Options) error {
+    return fmt.Errorf("install not implemented for distro %q", m.distroID)
+}
+
+func (m *noopManager) Remove(pkgs []string, opts Options) error {
+    return fmt.Errorf("remove not implemented for distro %q", m.distroID)
+}
+
+func (m *noopManager) Search(query string, opts Options) ([]SearchResult, error) {
+    return nil, fmt.Errorf("search not implemented for distro %q", m.distroID)
+}
+
+func (m *noopManager) Info(name string, opts Options) (*PackageInfo, error) {
+    return nil, fmt.Errorf("info not implemented for distro %q", m.distroID)
+}
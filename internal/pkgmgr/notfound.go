@@ -0,0 +1,125 @@
+package pkgmgr
+
+import (
+    "errors"
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+
+    "penguinguide/internal/fuzzy"
+)
+
+// NotFoundError is returned by Install when the package manager said
+// it does not know some of the names. Err is the original failure.
+type NotFoundError struct {
+    Names []string
+    Err   error
+}
+
+func (e *NotFoundError) Error() string {
+    return fmt.Sprintf("no package named %s", strings.Join(e.Names, ", "))
+}
+
+func (e *NotFoundError) Unwrap() error {
+    return e.Err
+}
+
+// Is makes errors.Is(err, ErrPackageNotFound) true for a NotFoundError.
+func (e *NotFoundError) Is(target error) bool {
+    return target == ErrPackageNotFound
+}
+
+// The messages each backend prints for names it does not know. They
+// only match English output, so in other languages an install that
+// fails this way is reported like any other failure.
+var (
+    aptNotFound       = regexp.MustCompile(`Unable to locate package (\S+)`)
+    dnfNotFound       = regexp.MustCompile(`(?:No match for argument|Unable to find a match):? (\S+)`)
+    pacmanNotFound    = regexp.MustCompile(`target not found: (\S+)`)
+    apkNotFound       = regexp.MustCompile(`(?m)^\s+(\S+) \(no such package\)`)
+    zypperNotFound    = regexp.MustCompile(`(?:Package|No provider of) '([^']+)' not found`)
+    rpmOstreeNotFound = regexp.MustCompile(`Packages not found: ([^,\s]+)`)
+    xbpsNotFound      = regexp.MustCompile(`Package '([^']+)' not found in repository pool`)
+    emergeNotFound    = regexp.MustCompile(`there are no ebuilds to satisfy "([^"]+)"`)
+    eopkgNotFound     = regexp.MustCompile(`(?:Package (\S+) not found in any active repository|No package named (\S+))`)
+    // nix profile names every attribute path it tried, nix-env the
+    // one it was given.
+    nixNotFound = regexp.MustCompile(`(?:does not provide attribute .* or '([^']+)'|attribute '([^']+)' in selection path)`)
+    // paru and yay list what they could not find under a heading,
+    // and pass pacman's own message through for repo packages.
+    aurNotFound = regexp.MustCompile(`(?mi)(?:^\s+(\S+) \(target\)|target not found: (\S+))`)
+)
+
+// notFound turns an install failure into a NotFoundError when the
+// command's error output says which names it did not know.
+func notFound(err error, pattern *regexp.Regexp) error {
+    var exitErr *ExitError
+    if !errors.As(err, &exitErr) {
+        return err
+    }
+    var names []string
+    seen := make(map[string]bool)
+    for _, m := range pattern.FindAllStringSubmatch(exitErr.Stderr, -1) {
+        for _, name := range m[1:] {
+            if name != "" && !seen[name] {
+                seen[name] = true
+                names = append(names, name)
+            }
+        }
+    }
+    if len(names) == 0 {
+        return err
+    }
+    return &NotFoundError{Names: names, Err: err}
+}
+
+// NameLister is implemented by managers that can list the name of
+// every package in their repositories, which makes suggestions for
+// misspelled names much better than a search.
+type NameLister interface {
+    PackageNames(opts Options) ([]string, error)
+}
+
+// Suggest returns up to limit package names close to name, for when
+// an install failed because name does not exist.
+func Suggest(mgr Manager, name string, limit int, opts Options) ([]string, error) {
+    if lister, ok := mgr.(NameLister); ok {
+        names, err := lister.PackageNames(opts)
+        if err != nil {
+            return nil, err
+        }
+        return fuzzy.Rank(name, names, limit), nil
+    }
+
+    // Searching for the name itself finds longer names that contain
+    // it, and searching for its first half finds most typos.
+    queries := []string{name}
+    if half := len(name) / 2; half >= 3 {
+        queries = append(queries, name[:half])
+    }
+    for _, query := range queries {
+        results, err := mgr.Search(query, opts)
+        if err != nil {
+            return nil, err
+        }
+        names := make([]string, 0, len(results))
+        for _, r := range results {
+            names = append(names, r.Name)
+        }
+        if suggestions := fuzzy.Rank(name, names, limit); len(suggestions) > 0 {
+            return suggestions, nil
+        }
+    }
+    return nil, nil
+}
+
+// sortedNames returns the names in set in order.
+func sortedNames(set map[string]bool) []string {
+    names := make([]string, 0, len(set))
+    for name := range set {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
@@ -0,0 +1,107 @@
+package pkgmgr
+
+import (
+    "errors"
+    "reflect"
+    "regexp"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func TestNotFound(t *testing.T) {
+    tests := []struct {
+        name    string
+        pattern *regexp.Regexp
+        stderr  string
+        want    []string
+    }{
+        {"apt", aptNotFound, "E: Unable to locate package htpo\nE: Unable to locate package crul\n", []string{"htpo", "crul"}},
+        {"dnf4", dnfNotFound, "No match for argument: htpo\nError: Unable to find a match: htpo\n", []string{"htpo"}},
+        {"dnf5", dnfNotFound, "Failed to resolve the transaction:\nNo match for argument: htpo\n", []string{"htpo"}},
+        {"pacman", pacmanNotFound, "error: target not found: htpo\n", []string{"htpo"}},
+        {"apk", apkNotFound, "ERROR: unable to select packages:\n  htpo (no such package):\n    required by: world[htpo]\n", []string{"htpo"}},
+        {"zypper", zypperNotFound, "Package 'htpo' not found.\n", []string{"htpo"}},
+        {"yay", aurNotFound, " -> Could not find all required packages:\n    htpo (target)\n", []string{"htpo"}},
+        {"paru", aurNotFound, "error: could not find all required packages:\n    htpo (target)\n", []string{"htpo"}},
+        {"rpm-ostree", rpmOstreeNotFound, "error: Packages not found: htpo\n", []string{"htpo"}},
+        {"xbps", xbpsNotFound, "Package 'htpo' not found in repository pool.\n", []string{"htpo"}},
+        {"emerge", emergeNotFound, "emerge: there are no ebuilds to satisfy \"htpo\".\n", []string{"htpo"}},
+        {"nix profile", nixNotFound, "error: flake 'flake:nixpkgs' does not provide attribute 'packages.x86_64-linux.htpo', 'legacyPackages.x86_64-linux.htpo' or 'htpo'\n", []string{"htpo"}},
+        {"nix-env", nixNotFound, "error: attribute 'htpo' in selection path 'nixos.htpo' not found\n", []string{"htpo"}},
+        {"other failure", aptNotFound, "E: Could not get lock /var/lib/dpkg/lock-frontend\n", nil},
+    }
+    for _, tt := range tests {
+        exitErr := &ExitError{Argv: []string{tt.name}, Code: 1, Stderr: tt.stderr}
+        err := notFound(exitErr, tt.pattern)
+        var nf *NotFoundError
+        if !errors.As(err, &nf) {
+            if tt.want != nil {
+                t.Errorf("%s: notFound() = %v, want a NotFoundError", tt.name, err)
+            }
+            continue
+        }
+        if !reflect.DeepEqual(nf.Names, tt.want) {
+            t.Errorf("%s: Names = %q, want %q", tt.name, nf.Names, tt.want)
+        }
+        if !errors.Is(err, ErrPackageNotFound) || !errors.Is(err, exitErr) {
+            t.Errorf("%s: %v does not wrap ErrPackageNotFound and the exit error", tt.name, err)
+        }
+    }
+}
+
+func TestInstallNotFound(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "sudo pacman -S -- htpo": {Err: &ExitError{Argv: []string{"sudo"}, Code: 1, Stderr: "error: target not found: htpo\n"}},
+    }
+    err := mgr.Install([]string{"htpo"}, Options{})
+    var nf *NotFoundError
+    if !errors.As(err, &nf) || !reflect.DeepEqual(nf.Names, []string{"htpo"}) {
+        t.Fatalf("Install error = %v, want a NotFoundError for htpo", err)
+    }
+}
+
+func TestSuggest(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyDebian, "debian")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "apt-cache pkgnames": {Output: "htop\nbtop\natop\ncurl\nhtop-dev\n"},
+    }
+    got, err := Suggest(mgr, "htpo", 3, Options{})
+    if err != nil {
+        t.Fatalf("Suggest error = %v", err)
+    }
+    if len(got) == 0 || got[0] != "htop" {
+        t.Fatalf("Suggest() = %q, want htop first", got)
+    }
+}
+
+// searchOnly is a manager without a NameLister, so Suggest has to
+// fall back to searching.
+type searchOnly struct {
+    Manager
+    queries []string
+}
+
+func (m *searchOnly) Search(query string, opts Options) ([]SearchResult, error) {
+    m.queries = append(m.queries, query)
+    if query == "fir" {
+        return []SearchResult{{Name: "firefox"}, {Name: "firefox-esr"}, {Name: "firejail"}}, nil
+    }
+    return nil, nil
+}
+
+func TestSuggestBySearch(t *testing.T) {
+    mgr := &searchOnly{}
+    got, err := Suggest(mgr, "firefxo", 5, Options{})
+    if err != nil {
+        t.Fatalf("Suggest error = %v", err)
+    }
+    if len(got) == 0 || got[0] != "firefox" {
+        t.Fatalf("Suggest() = %q, want firefox first", got)
+    }
+    if want := []string{"firefxo", "fir"}; !reflect.DeepEqual(mgr.queries, want) {
+        t.Fatalf("Suggest searched for %q, want %q", mgr.queries, want)
+    }
+}
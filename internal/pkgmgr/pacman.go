@@ -0,0 +1,634 @@
+package pkgmgr
+
+import (
+    "fmt"
+    "path/filepath"
+    "regexp"
+    "strings"
+    "time"
+)
+
+/********** Pacman **********/
+
+type pacmanManager struct {
+    env Env
+}
+
+func (m *pacmanManager) UpdateAll(opts Options) error {
+    args := []string{"pacman", "-Syu"}
+    if opts.AssumeYes {
+        args = append(args, "--noconfirm")
+    }
+    plan := newPlan("Update all packages with pacman", rootStep(args...)).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+// Install takes packages with a version, such as htop@3.3.0, from the
+// package cache with pacman -U, because the repos only carry the
+// current version.
+func (m *pacmanManager) Install(pkgs []string, opts Options) error {
+    var names, files []string
+    for _, p := range pkgs {
+        name, version := SplitVersion(p)
+        if version == "" {
+            names = append(names, p)
+            continue
+        }
+        file, err := pacmanCachedFile(name, version)
+        if err != nil {
+            return err
+        }
+        files = append(files, file)
+    }
+
+    var steps []Step
+    explanation := "Install packages with pacman"
+    if len(names) > 0 {
+        args := []string{"pacman", "-S"}
+        if opts.AssumeYes {
+            args = append(args, "--noconfirm")
+        }
+        steps = append(steps, rootStep(append(append(args, "--"), names...)...))
+    }
+    if len(files) > 0 {
+        steps = append(steps, m.installFilesStep(files, opts))
+        explanation += ", taking the requested versions from the package cache in " + pacmanCacheDir
+    }
+    plan := newPlan(explanation, steps...).forAction("install", packageNames(pkgs)...)
+    return notFound(m.env.runOrPrint(plan, opts), pacmanNotFound)
+}
+
+// installFilesStep installs package files with pacman -U.
+func (m *pacmanManager) installFilesStep(files []string, opts Options) Step {
+    args := []string{"pacman", "-U"}
+    if opts.AssumeYes {
+        args = append(args, "--noconfirm")
+    }
+    return rootStep(append(args, files...)...)
+}
+
+func (m *pacmanManager) Remove(pkgs []string, opts Options) error {
+    args := []string{"pacman", "-R"}
+    if opts.AssumeYes {
+        args = append(args, "--noconfirm")
+    }
+    args = append(append(args, "--"), pkgs...)
+    plan := newPlan("Remove packages with pacman", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *pacmanManager) Search(query string, opts Options) ([]SearchResult, error) {
+    args := append([]string{"pacman", "-Ss"}, searchTerms(query)...)
+    // pacman exits with 1 when nothing matched.
+    step := userStep(args...).allowExit(1)
+    out, err := m.env.query(newPlan("Search for packages with pacman", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parsePacmanSearch(string(out)), nil
+}
+
+func (m *pacmanManager) Info(name string, opts Options) (*PackageInfo, error) {
+    // pacman exits with 1 for unknown packages.
+    step := userStep("pacman", "-Si", "--", name).allowExit(1)
+    out, err := m.env.query(newPlan("Show package details with pacman", step), opts)
+    if err != nil {
+        return nil, err
+    }
+
+    local := userStep("pacman", "-Qi", "--", name).allowExit(1)
+    localOut, err := m.env.query(newPlan("Check whether the package is installed with pacman", local), opts)
+    if err != nil {
+        return nil, err
+    }
+
+    info := parsePacmanInfo(string(out))
+    installed := parsePacmanInfo(string(localOut))
+    if info.Name == "" {
+        // Packages built locally or from the AUR only exist in the local database.
+        info = installed
+    }
+    if info.Name == "" {
+        return nil, ErrPackageNotFound
+    }
+    info.Installed = installed.Name != ""
+    return info, nil
+}
+
+// parsePacmanSearch parses pacman -Ss output, which looks like:
+//
+//	extra/htop 3.3.0-1 [installed]
+//	    Interactive process viewer
+func parsePacmanSearch(out string) []SearchResult {
+    var results []SearchResult
+    for _, line := range strings.Split(out, "\n") {
+        if strings.TrimSpace(line) == "" {
+            continue
+        }
+        if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+            if n := len(results); n > 0 {
+                results[n-1].Summary = strings.TrimSpace(line)
+            }
+            continue
+        }
+
+        fields := strings.Fields(line)
+        slash := strings.Index(fields[0], "/")
+        if slash < 0 || len(fields) < 2 {
+            continue
+        }
+        results = append(results, SearchResult{
+            Name:       fields[0][slash+1:],
+            Version:    fields[1],
+            Repository: fields[0][:slash],
+            Installed:  isMarkedInstalled(line),
+        })
+    }
+    return results
+}
+
+// isMarkedInstalled reports whether a search line carries an installed
+// marker. pacman and paru print [installed], yay prints (Installed).
+func isMarkedInstalled(line string) bool {
+    lower := strings.ToLower(line)
+    return strings.Contains(lower, "[installed") || strings.Contains(lower, "(installed")
+}
+
+// parsePacmanInfo parses pacman -Si or -Qi output.
+func parsePacmanInfo(out string) *PackageInfo {
+    fields := parseKeyValues(out)
+    info := &PackageInfo{
+        Name:          fields["name"],
+        Version:       fields["version"],
+        Repository:    fields["repository"],
+        Size:          ParseSize(fields["download size"]),
+        InstalledSize: ParseSize(fields["installed size"]),
+        License:       joinLines(fields["licenses"]),
+        Homepage:      fields["url"],
+        Description:   fields["description"],
+        Raw:           out,
+    }
+    if deps := strings.Fields(fields["depends on"]); len(deps) > 0 && deps[0] != "None" {
+        info.Dependencies = deps
+    }
+    return info
+}
+
+func (m *pacmanManager) List(opts Options) ([]InstalledPackage, error) {
+    out, err := m.env.query(newPlan("List installed packages with pacman", userStep("pacman", "-Qi")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parsePacmanList(string(out)), nil
+}
+
+// parsePacmanList parses pacman -Qi output for every installed package.
+func parsePacmanList(out string) []InstalledPackage {
+    var pkgs []InstalledPackage
+    for _, block := range strings.Split(out, "\n\n") {
+        fields := parseKeyValues(block)
+        if fields["name"] == "" {
+            continue
+        }
+        pkgs = append(pkgs, InstalledPackage{
+            Name:        fields["name"],
+            Version:     fields["version"],
+            Explicit:    strings.HasPrefix(fields["install reason"], "Explicitly"),
+            Size:        ParseSize(fields["installed size"]),
+            InstallDate: parsePacmanDate(fields["install date"]),
+        })
+    }
+    return pkgs
+}
+
+// pacmanDateLayouts covers the date formats pacman has used over time.
+var pacmanDateLayouts = []string{
+    "Mon 02 Jan 2006 03:04:05 PM MST",
+    "Mon 02 Jan 2006 15:04:05 MST",
+    "Mon Jan _2 15:04:05 2006",
+}
+
+func parsePacmanDate(s string) time.Time {
+    for _, layout := range pacmanDateLayouts {
+        if t, err := time.Parse(layout, s); err == nil {
+            return t
+        }
+    }
+    return time.Time{}
+}
+
+// CheckUpdates uses checkupdates from pacman-contrib when it is installed,
+// because it syncs into a temporary database. Running pacman -Sy on its
+// own would leave the system in a partial upgrade state.
+func (m *pacmanManager) CheckUpdates(opts Options) ([]Upgrade, error) {
+    // checkupdates exits with 2 and pacman -Qu with 1 when nothing is pending.
+    step := userStep("checkupdates").allowExit(2)
+    explanation := "Refresh a temporary copy of the package databases and list upgrades with checkupdates"
+    if !m.env.has("checkupdates") {
+        step = userStep("pacman", "-Qu").allowExit(1)
+        explanation = "List upgrades from the last sync with pacman (install pacman-contrib for fresh results)"
+    }
+    out, err := m.env.query(newPlan(explanation, step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parsePacmanUpgrades(string(out)), nil
+}
+
+// parsePacmanUpgrades parses "htop 3.2.0-1 -> 3.3.0-1" lines.
+func parsePacmanUpgrades(out string) []Upgrade {
+    var upgrades []Upgrade
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Fields(line)
+        if len(fields) < 4 || fields[2] != "->" {
+            continue
+        }
+        upgrades = append(upgrades, Upgrade{Name: fields[0], OldVersion: fields[1], NewVersion: fields[3]})
+    }
+    return upgrades
+}
+
+func (m *pacmanManager) Owner(path string, opts Options) ([]Provider, error) {
+    // pacman exits with 1 when no package owns the path.
+    step := userStep("pacman", "-Qo", "--", path).allowExit(1)
+    out, err := m.env.query(newPlan("Find the installed package that owns this file with pacman", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseOwnedBy(string(out), false), nil
+}
+
+// Provides searches the pacman files database, which is separate from
+// the package database and only exists after pacman -Fy has been run.
+func (m *pacmanManager) Provides(command string, opts Options) ([]Provider, error) {
+    // pacman exits with 1 when nothing matched.
+    step := userStep("pacman", "-F", command).allowExit(1)
+    out, err := m.env.query(newPlan("Search the pacman files database for this command", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parsePacmanFiles(string(out)), nil
+}
+
+// parsePacmanFiles parses pacman -F output, which looks like:
+//
+//	core/net-tools 2.10-2
+//	    usr/bin/ifconfig
+func parsePacmanFiles(out string) []Provider {
+    var providers []Provider
+    for _, line := range strings.Split(out, "\n") {
+        if strings.TrimSpace(line) == "" {
+            continue
+        }
+        if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+            if n := len(providers); n > 0 && providers[n-1].Path == "" {
+                providers[n-1].Path = "/" + strings.TrimSpace(line)
+            }
+            continue
+        }
+        fields := strings.Fields(line)
+        repo, name, ok := strings.Cut(fields[0], "/")
+        if !ok {
+            continue
+        }
+        providers = append(providers, Provider{Package: name, Repository: repo})
+    }
+    return providers
+}
+
+// CleanupTasks prefers paccache from pacman-contrib, which keeps the
+// three latest versions of each package so a bad upgrade can still be
+// rolled back. pacman -Sc only keeps the installed version.
+func (m *pacmanManager) CleanupTasks(opts Options) ([]CleanupTask, error) {
+    var tasks []CleanupTask
+    if m.env.has("paccache") {
+        out, err := m.env.query(newPlan("Measure what paccache would delete, without deleting anything", userStep("paccache", "-d")), opts)
+        if err != nil {
+            return nil, err
+        }
+        if size := parsePaccacheDryRun(string(out)); size > 0 {
+            plan := newPlan("Delete all but the three latest versions of each package with paccache", rootStep("paccache", "-r")).forAction("cleanup")
+            tasks = append(tasks, m.env.newCleanupTask("cache", "Old package versions kept in "+pacmanCacheDir, size, plan))
+        }
+    } else {
+        args := []string{"pacman", "-Sc"}
+        if opts.AssumeYes {
+            args = append(args, "--noconfirm")
+        }
+        plan := newPlan("Delete cached packages that are no longer installed with pacman (install pacman-contrib to keep recent versions)",
+            rootStep(args...)).forAction("cleanup")
+        // -Sc keeps the files of installed versions, so only the rest count.
+        out, err := m.env.query(newPlan("List installed versions with pacman", userStep("pacman", "-Q")), opts)
+        if err != nil {
+            return nil, err
+        }
+        installed := make(map[string]string)
+        for _, line := range strings.Split(string(out), "\n") {
+            if fields := strings.Fields(line); len(fields) == 2 {
+                installed[fields[0]] = fields[1]
+            }
+        }
+        if size := pacmanStaleCache(dirFiles(pacmanCacheDir), installed); size > 0 {
+            tasks = append(tasks, m.env.newCleanupTask("cache", "Cached packages in "+pacmanCacheDir+" whose version is not installed", size, plan))
+        }
+    }
+
+    // pacman exits with 1 when there are no orphans.
+    step := userStep("pacman", "-Qdtq").allowExit(1)
+    out, err := m.env.query(newPlan("List dependencies that no installed package needs with pacman", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    orphans := strings.Fields(string(out))
+    args := []string{"pacman", "-Rns"}
+    if opts.AssumeYes {
+        args = append(args, "--noconfirm")
+    }
+    args = append(args, orphans...)
+    remove := newPlan("Remove orphaned dependencies and their unused config files with pacman", rootStep(args...))
+    task, ok, err := m.env.orphanTask(m, orphans, remove, opts)
+    if err != nil {
+        return nil, err
+    }
+    if ok {
+        tasks = append(tasks, task)
+    }
+    return tasks, nil
+}
+
+func (m *pacmanManager) Cleanup(task CleanupTask, opts Options) error {
+    return m.env.runOrPrint(task.plan, opts)
+}
+
+// Hold adds the packages to IgnorePkg in pacman.conf, so pacman -Syu
+// skips them and warns each time it does.
+func (m *pacmanManager) Hold(pkgs []string, opts Options) error {
+    if err := checkPacmanNames(pkgs); err != nil {
+        return err
+    }
+    step := rootStep("sed", "-i", ignorePkgAdd(pkgs), pacmanConf)
+    plan := newPlan("Add the packages to IgnorePkg in "+pacmanConf+" so upgrades skip them", step).forAction("hold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *pacmanManager) Unhold(pkgs []string, opts Options) error {
+    if err := checkPacmanNames(pkgs); err != nil {
+        return err
+    }
+    args := []string{"sed", "-i", "-E"}
+    for _, name := range pkgs {
+        args = append(args, ignorePkgRemove(name)...)
+    }
+    args = append(args, pacmanConf)
+    plan := newPlan("Remove the packages from IgnorePkg in "+pacmanConf, rootStep(args...)).forAction("unhold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *pacmanManager) Holds(opts Options) ([]string, error) {
+    out, err := m.env.query(newPlan("Read IgnorePkg from the pacman configuration", userStep("pacman-conf", "IgnorePkg")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return strings.Fields(string(out)), nil
+}
+
+// Repos reads the repository sections from pacman.conf. Sections that
+// are commented out, such as the testing repositories, are disabled.
+func (m *pacmanManager) Repos(opts Options) ([]Repo, error) {
+    out, err := m.env.query(newPlan("Read "+pacmanConf, userStep("cat", pacmanConf)), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parsePacmanRepos(string(out)), nil
+}
+
+// AddRepo appends a section to pacman.conf. With a key fingerprint the
+// key is fetched and locally signed, so pacman checks packages against
+// it. Without one the section trusts whatever the server sends.
+func (m *pacmanManager) AddRepo(spec RepoSpec, opts Options) error {
+    if err := checkRepoName(spec.Name); err != nil {
+        return err
+    }
+    var steps []Step
+    section := "\n[" + spec.Name + "]\n"
+    explanation := "Append a [" + spec.Name + "] section to " + pacmanConf
+    if spec.Key != "" {
+        steps = append(steps,
+            rootStep("pacman-key", "--recv-keys", spec.Key),
+            rootStep("pacman-key", "--lsign-key", spec.Key),
+        )
+        explanation = "Fetch and locally sign the repository key with pacman-key, then append a [" + spec.Name + "] section to " + pacmanConf
+    } else {
+        section += "SigLevel = Optional TrustAll\n"
+        explanation += " that accepts unsigned packages, since no key was given"
+    }
+    section += "Server = " + spec.URL + "\n"
+    steps = append(steps, rootStep("tee", "-a", pacmanConf).withInput(section))
+    plan := newPlan(explanation+". Run penguinguide update afterwards to sync it.", steps...).forAction("repo-add", spec.Name)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// RemoveRepo deletes the section, up to the next blank line.
+func (m *pacmanManager) RemoveRepo(id string, opts Options) error {
+    if _, err := m.pacmanRepo(id, opts); err != nil {
+        return err
+    }
+    expr := `/^#?\[` + regexp.QuoteMeta(id) + `\]$/,/^$/d`
+    plan := newPlan("Delete the ["+id+"] section from "+pacmanConf,
+        rootStep("sed", "-i", "-E", expr, pacmanConf)).forAction("repo-remove", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// EnableRepo uncomments the section, the same edit the Arch wiki
+// describes for multilib.
+func (m *pacmanManager) EnableRepo(id string, opts Options) error {
+    if _, err := m.pacmanRepo(id, opts); err != nil {
+        return err
+    }
+    expr := `/^#\[` + regexp.QuoteMeta(id) + `\]$/,/^$/ s/^#//`
+    plan := newPlan("Uncomment the ["+id+"] section in "+pacmanConf+". Run penguinguide update afterwards to sync it.",
+        rootStep("sed", "-i", "-E", expr, pacmanConf)).forAction("repo-enable", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *pacmanManager) DisableRepo(id string, opts Options) error {
+    if _, err := m.pacmanRepo(id, opts); err != nil {
+        return err
+    }
+    expr := `/^\[` + regexp.QuoteMeta(id) + `\]$/,/^$/ s/^([^#])/#\1/`
+    plan := newPlan("Comment out the ["+id+"] section in "+pacmanConf,
+        rootStep("sed", "-i", "-E", expr, pacmanConf)).forAction("repo-disable", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *pacmanManager) pacmanRepo(id string, opts Options) (Repo, error) {
+    repos, err := m.Repos(opts)
+    if err != nil {
+        return Repo{}, err
+    }
+    return findRepo(repos, id)
+}
+
+// parsePacmanRepos parses the repository sections of pacman.conf:
+//
+//	[core]
+//	Include = /etc/pacman.d/mirrorlist
+//
+//	#[multilib]
+//	#Include = /etc/pacman.d/mirrorlist
+func parsePacmanRepos(out string) []Repo {
+    var repos []Repo
+    var current *Repo
+    for _, line := range strings.Split(out, "\n") {
+        line = strings.TrimSpace(line)
+        enabled := !strings.HasPrefix(line, "#")
+        line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+        if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+            current = nil
+            name := strings.Trim(line, "[]")
+            if name == "options" {
+                continue
+            }
+            repos = append(repos, Repo{ID: name, Name: name, Enabled: enabled, File: pacmanConf})
+            current = &repos[len(repos)-1]
+            continue
+        }
+        key, value, ok := strings.Cut(line, "=")
+        key = strings.TrimSpace(key)
+        if current != nil && ok && current.URL == "" && (key == "Server" || key == "Include") {
+            current.URL = strings.TrimSpace(value)
+        }
+    }
+    return repos
+}
+
+const pacmanCacheDir = "/var/cache/pacman/pkg"
+
+// Versions lists the installed version, the one in the repos, and every
+// version left in the package cache, which is where downgrades come from.
+func (m *pacmanManager) Versions(name string, opts Options) ([]PackageVersion, error) {
+    var versions []PackageVersion
+
+    // pacman exits with 1 for packages that are not installed or not in the repos.
+    out, err := m.env.query(newPlan("Show the installed version with pacman", userStep("pacman", "-Q", "--", name).allowExit(1)), opts)
+    if err != nil {
+        return nil, err
+    }
+    if fields := strings.Fields(string(out)); len(fields) == 2 && fields[0] == name {
+        versions = append(versions, PackageVersion{Version: fields[1], Installed: true})
+    }
+
+    out, err = m.env.query(newPlan("Show the version in the repos with pacman", userStep("pacman", "-Si", "--", name).allowExit(1)), opts)
+    if err != nil {
+        return nil, err
+    }
+    if info := parsePacmanInfo(string(out)); info.Name == name {
+        versions = append(versions, PackageVersion{Version: info.Version, Repository: info.Repository})
+    }
+
+    cached, err := pacmanCachedVersions(name)
+    if err != nil {
+        return nil, err
+    }
+    versions = append(versions, cached...)
+    if len(versions) == 0 {
+        return nil, ErrPackageNotFound
+    }
+    return mergeVersions(versions), nil
+}
+
+// Downgrade installs an older version from the package cache. Arch
+// does not keep old versions in its repos, so anything that has been
+// cleaned from the cache has to come from the Arch Linux Archive.
+func (m *pacmanManager) Downgrade(name, version string, opts Options) error {
+    file, err := pacmanCachedFile(name, version)
+    if err != nil {
+        return err
+    }
+    plan := newPlan("Install "+name+" "+version+" from the package cache with pacman -U. "+
+        "The next full upgrade replaces it again, unless you hold the package",
+        m.installFilesStep([]string{file}, opts)).forAction("downgrade", name)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// pacmanCachedVersions lists the versions of name in the package cache.
+func pacmanCachedVersions(name string) ([]PackageVersion, error) {
+    files, err := globFiles(filepath.Join(pacmanCacheDir, name+"-*.pkg.tar*"))
+    if err != nil {
+        return nil, err
+    }
+    var versions []PackageVersion
+    for _, file := range files {
+        if version, ok := parsePacmanCacheFile(name, filepath.Base(file)); ok {
+            versions = append(versions, PackageVersion{Version: version, Cached: true, file: file})
+        }
+    }
+    return versions, nil
+}
+
+// pacmanCachedFile returns the cached package file for name at version.
+// A version without a release, such as 3.3.0, matches the newest release.
+func pacmanCachedFile(name, version string) (string, error) {
+    cached, err := pacmanCachedVersions(name)
+    if err != nil {
+        return "", err
+    }
+    sortVersions(cached)
+    for _, v := range cached {
+        if v.Version == version || strings.HasPrefix(v.Version, version+"-") {
+            return v.file, nil
+        }
+    }
+    return "", fmt.Errorf("%w: %s %s is not in %s. Arch only keeps the current version in its repos, "+
+        "older ones are on the Arch Linux Archive at https://archive.archlinux.org", ErrVersionNotFound, name, version, pacmanCacheDir)
+}
+
+// parsePacmanCacheFile returns the version in a cached package file name
+// such as htop-3.3.0-1-x86_64.pkg.tar.zst, which is the version and the
+// release, neither of which may contain a dash.
+func parsePacmanCacheFile(name, file string) (string, bool) {
+    if strings.HasSuffix(file, ".sig") || !strings.HasPrefix(file, name+"-") {
+        return "", false
+    }
+    parts := strings.Split(strings.TrimPrefix(file, name+"-"), "-")
+    if len(parts) != 3 || !strings.Contains(parts[2], ".pkg.tar") {
+        return "", false
+    }
+    return parts[0] + "-" + parts[1], true
+}
+
+func (m *pacmanManager) PackageNames(opts Options) ([]string, error) {
+    out, err := m.env.query(newPlan("List every package name in the repos with pacman", userStep("pacman", "-Slq")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return sortedNames(nameSet(string(out))), nil
+}
+
+// Deps uses pactree from pacman-contrib. It reads the sync databases,
+// so it also works for packages that are not installed.
+func (m *pacmanManager) Deps(name string, opts Options) ([]string, error) {
+    return m.pactree(name, "List what the package depends on with pactree", opts, "-s")
+}
+
+func (m *pacmanManager) ReverseDeps(name string, opts Options) ([]string, error) {
+    return m.pactree(name, "List installed packages that depend on the package with pactree", opts, "-r")
+}
+
+// pactree lists the packages one level away from name. pactree exits
+// with 1 and prints nothing when it does not know the package.
+func (m *pacmanManager) pactree(name, explanation string, opts Options, flags ...string) ([]string, error) {
+    if !m.env.has("pactree") {
+        return nil, &MissingToolError{Tool: "pactree", Install: "penguinguide install pacman-contrib"}
+    }
+    args := append([]string{"pactree", "-u", "-d", "1"}, flags...)
+    step := userStep(append(args, "--", name)...).allowExit(1)
+    out, err := m.env.query(newPlan(explanation, step), opts)
+    if err != nil {
+        return nil, err
+    }
+    if strings.TrimSpace(string(out)) == "" {
+        return nil, ErrPackageNotFound
+    }
+    return depNames(nameSet(string(out)), name), nil
+}
@@ -1,14 +1,18 @@
 package pkgmgr
 
 import (
+    "io"
     "reflect"
     "testing"
 
     "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
 )
 
-// This test checks that New returns the expected concrete manager type
-// for each known distro family and that unknown families get the noop manager.
+// This test checks that NewWithEnv returns the expected concrete manager
+// type for each known distro family and that unknown families get the
+// noop manager. The fake runner has no AUR helper installed, so the
+// result does not depend on the machine running the test.
 func TestNewReturnsExpectedManager(t *testing.T) {
     tests := []struct {
         name string
@@ -47,6 +51,14 @@ func TestNewReturnsExpectedManager(t *testing.T) {
             },
             want: &apkManager{},
         },
+        {
+            name: "suse family gets zypperManager",
+            d: &distro.Distro{
+                Family: distro.FamilySUSE,
+                ID:     "opensuse-leap",
+            },
+            want: &zypperManager{},
+        },
         {
             name: "unknown family gets noopManager",
             d: &distro.Distro{
@@ -59,18 +71,40 @@ func TestNewReturnsExpectedManager(t *testing.T) {
 
     for _, tc := range tests {
         t.Run(tc.name, func(t *testing.T) {
-            mgr := New(tc.d)
+            env := Env{Runner: &pkgmgrtest.Runner{}, Out: io.Discard}
+            mgr := NewWithEnv(tc.d, env)
             if mgr == nil {
-                t.Fatalf("New returned nil for distro %+v", tc.d)
+                t.Fatalf("NewWithEnv returned nil for distro %+v", tc.d)
             }
 
             gotType := reflect.TypeOf(mgr)
             wantType := reflect.TypeOf(tc.want)
 
             if gotType != wantType {
-                t.Fatalf("New(%+v) type = %v, want %v", tc.d, gotType, wantType)
+                t.Fatalf("NewWithEnv(%+v) type = %v, want %v", tc.d, gotType, wantType)
             }
         })
     }
 }
 
+
+// This test checks that Tumbleweed style releases are treated as rolling
+// so UpdateAll uses dist-upgrade, while Leap and SLES are not.
+func TestIsRollingSUSE(t *testing.T) {
+    tests := []struct {
+        id   string
+        want bool
+    }{
+        {"opensuse-tumbleweed", true},
+        {"opensuse-slowroll", true},
+        {"opensuse-leap", false},
+        {"sles", false},
+    }
+
+    for _, tc := range tests {
+        got := IsRollingSUSE(&distro.Distro{ID: tc.id, Family: distro.FamilySUSE})
+        if got != tc.want {
+            t.Fatalf("IsRollingSUSE(%q) = %v, want %v", tc.id, got, tc.want)
+        }
+    }
+}
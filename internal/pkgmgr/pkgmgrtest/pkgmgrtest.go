@@ -0,0 +1,96 @@
+// Package pkgmgrtest provides fakes for the pkgmgr Runner and Prompter
+// interfaces so package manager logic can be exercised without running
+// a real package manager.
+package pkgmgrtest
+
+import (
+    "errors"
+    "strings"
+)
+
+// Result is what the fake Runner returns for one command.
+type Result struct {
+    Output string
+    Err    error
+}
+
+// Runner records every command it is asked to run and returns
+// scripted results instead of running anything.
+type Runner struct {
+    // Calls holds the argv of every command, in order.
+    Calls [][]string
+
+    // Results maps a command line, argv joined with single spaces,
+    // to the result the fake returns. Unknown commands succeed
+    // with no output.
+    Results map[string]Result
+
+    // Installed lists the commands LookPath finds.
+    Installed []string
+
+    // Inputs maps a command line to the stdin it was given.
+    Inputs map[string]string
+}
+
+// Run records argv and returns the scripted error.
+func (r *Runner) Run(argv []string) error {
+    return r.record(argv).Err
+}
+
+// RunInput records argv and input and returns the scripted error.
+func (r *Runner) RunInput(argv []string, input string) error {
+    if r.Inputs == nil {
+        r.Inputs = make(map[string]string)
+    }
+    r.Inputs[strings.Join(argv, " ")] = input
+    return r.record(argv).Err
+}
+
+// Output records argv and returns the scripted output and error.
+func (r *Runner) Output(argv []string) ([]byte, error) {
+    res := r.record(argv)
+    return []byte(res.Output), res.Err
+}
+
+// LookPath reports commands listed in Installed as found in /usr/bin.
+func (r *Runner) LookPath(file string) (string, error) {
+    for _, name := range r.Installed {
+        if name == file {
+            return "/usr/bin/" + file, nil
+        }
+    }
+    return "", errors.New("executable file not found in $PATH")
+}
+
+// Commands returns every recorded call as a command line,
+// which keeps test expectations short.
+func (r *Runner) Commands() []string {
+    out := make([]string, 0, len(r.Calls))
+    for _, argv := range r.Calls {
+        out = append(out, strings.Join(argv, " "))
+    }
+    return out
+}
+
+func (r *Runner) record(argv []string) Result {
+    r.Calls = append(r.Calls, append([]string(nil), argv...))
+    return r.Results[strings.Join(argv, " ")]
+}
+
+// Prompter answers questions from a fixed list and records
+// what it was asked. Once the answers run out it says no.
+type Prompter struct {
+    Answers   []bool
+    Questions []string
+}
+
+// Confirm records the question and returns the next scripted answer.
+func (p *Prompter) Confirm(question string) bool {
+    p.Questions = append(p.Questions, question)
+    if len(p.Answers) == 0 {
+        return false
+    }
+    answer := p.Answers[0]
+    p.Answers = p.Answers[1:]
+    return answer
+}
@@ -0,0 +1,273 @@
+package pkgmgr
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "penguinguide/internal/ui"
+)
+
+// Step is a single native command. Args is the argument vector that is
+// passed to exec.Command directly, so nothing in it is ever interpreted
+// by a shell. OKCodes lists non-zero exit codes that still mean success,
+// such as pacman -Ss exiting with 1 when nothing matched. Input is
+// text passed to the command on stdin, used to write configuration
+// files with tee instead of a shell redirection.
+type Step struct {
+    Args       []string
+    Privileged bool
+    OKCodes    []int
+    Input      string
+
+    // elevation is how a privileged step gets root once an Env with an
+    // Elevation runs it. Steps without one use sudo.
+    elevation *Elevation
+}
+
+// Plan describes what penguinguide is about to do for one action:
+// the native commands to run, in order, and a short explanation.
+// Action and Packages describe the change for the history log.
+type Plan struct {
+    Explanation string
+    Steps       []Step
+    Action      string
+    Packages    []string
+}
+
+// rootStep returns a step that needs administrator rights.
+func rootStep(args ...string) Step {
+    return Step{Args: args, Privileged: true}
+}
+
+// userStep returns a step that runs as the current user.
+func userStep(args ...string) Step {
+    return Step{Args: args}
+}
+
+// withInput returns a copy of the step that reads input on stdin.
+func (s Step) withInput(input string) Step {
+    s.Input = input
+    return s
+}
+
+// allowExit returns a copy of the step that treats codes as success.
+func (s Step) allowExit(codes ...int) Step {
+    s.OKCodes = append(append([]int(nil), s.OKCodes...), codes...)
+    return s
+}
+
+func newPlan(explanation string, steps ...Step) Plan {
+    return Plan{Explanation: explanation, Steps: steps}
+}
+
+// forAction labels a plan that changes the system for the history log.
+func (p Plan) forAction(action string, pkgs ...string) Plan {
+    p.Action = action
+    p.Packages = pkgs
+    return p
+}
+
+// Argv returns the full argument vector for the step, including
+// sudo or the configured elevation tool when the step is privileged.
+func (s Step) Argv() []string {
+    if !s.Privileged {
+        return s.Args
+    }
+    if s.elevation != nil {
+        return append(s.elevation.prefix(), s.Args...)
+    }
+    return append([]string{"sudo"}, s.Args...)
+}
+
+// String renders the step as a shell quoted command line
+// that can be copied into a terminal.
+func (s Step) String() string {
+    argv := s.Argv()
+    quoted := make([]string, 0, len(argv))
+    for _, a := range argv {
+        quoted = append(quoted, shellQuote(a))
+    }
+    if s.Input != "" {
+        return "printf '%s' " + shellQuote(s.Input) + " | " + strings.Join(quoted, " ")
+    }
+    return strings.Join(quoted, " ")
+}
+
+// String renders every step of the plan as one shell command line.
+// Steps are joined with && because each one only runs if the
+// previous one succeeded, which matches how the plan is executed.
+func (p Plan) String() string {
+    parts := make([]string, 0, len(p.Steps))
+    for _, s := range p.Steps {
+        parts = append(parts, s.String())
+    }
+    return strings.Join(parts, " && ")
+}
+
+// shellQuote returns s quoted for a POSIX shell. Plain words such as
+// package names are left alone so the common case stays readable.
+func shellQuote(s string) string {
+    if s == "" {
+        return "''"
+    }
+    safe := true
+    for _, r := range s {
+        if !isShellSafe(r) {
+            safe = false
+            break
+        }
+    }
+    if safe {
+        return s
+    }
+    return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func isShellSafe(r rune) bool {
+    switch {
+    case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+        return true
+    }
+    return strings.ContainsRune("@%+=:,./_-", r)
+}
+
+/********** Execution **********/
+
+// runOrPrint explains, previews, and runs a plan according to opts.
+func (e Env) runOrPrint(plan Plan, opts Options) error {
+    plan = e.elevate(plan)
+    command := plan.String()
+
+    if opts.Explain {
+        fmt.Fprintln(e.Out, ui.Heading("Explanation"))
+        if plan.Explanation != "" {
+            fmt.Fprintln(e.Out, "  "+plan.Explanation)
+        }
+        fmt.Fprintln(e.Out)
+        if plan.needsRoot() {
+            fmt.Fprintln(e.Out, ui.Key("Why root is needed:"))
+            fmt.Fprintln(e.Out, "  "+e.elevationReason())
+            fmt.Fprintln(e.Out)
+        }
+        fmt.Fprintln(e.Out, ui.Key("Native command:"))
+        fmt.Fprintln(e.Out, "  "+ui.Value(command))
+        fmt.Fprintln(e.Out)
+    }
+
+    if opts.DryRun {
+        fmt.Fprintln(e.Out, ui.Heading("Dry run"))
+        fmt.Fprintln(e.Out, "  Would run:")
+        fmt.Fprintln(e.Out, "    "+ui.Value(command))
+        fmt.Fprintln(e.Out)
+
+        if !e.Prompter.Confirm("Do you want to run this command now") {
+            fmt.Fprintln(e.Out, ui.Muted("Skipped running command."))
+            return nil
+        }
+
+        opts.DryRun = false
+        fmt.Fprintln(e.Out)
+    }
+
+    if !opts.Explain && plan.Explanation != "" {
+        fmt.Fprintln(e.Out, ui.Info(plan.Explanation))
+    }
+
+    for _, step := range plan.Steps {
+        fmt.Fprintln(e.Out, ui.Key("Running:"))
+        fmt.Fprintln(e.Out, "  "+ui.Value(step.String()))
+
+        err := step.check(e.run(step))
+        if errors.Is(err, ErrCanceled) {
+            e.record(plan, exitCanceled)
+            fmt.Fprintln(e.Out)
+            fmt.Fprintln(e.Out, ui.Muted("Command canceled by user."))
+            return nil
+        }
+        if err != nil {
+            e.record(plan, exitCode(err))
+            return err
+        }
+    }
+
+    e.record(plan, 0)
+    return nil
+}
+
+// run executes one step, passing its input when it has one.
+func (e Env) run(step Step) error {
+    if step.Input == "" {
+        return e.Runner.Run(step.Argv())
+    }
+    r, ok := e.Runner.(InputRunner)
+    if !ok {
+        return fmt.Errorf("%s needs input, which this runner cannot pass", step.Args[0])
+    }
+    return r.RunInput(step.Argv(), step.Input)
+}
+
+// exitCanceled is the status a shell reports for a command stopped by Ctrl+C.
+const exitCanceled = 130
+
+// exitCode returns the exit status behind err, or -1 when the
+// command could not be started at all.
+func exitCode(err error) int {
+    var exitErr *ExitError
+    if errors.As(err, &exitErr) {
+        return exitErr.Code
+    }
+    return -1
+}
+
+func (e Env) record(plan Plan, code int) {
+    if e.Recorder == nil {
+        return
+    }
+    e.Recorder.Record(Operation{
+        Action:   plan.Action,
+        Packages: plan.Packages,
+        Command:  plan.String(),
+        ExitCode: code,
+    })
+}
+
+// query runs a read only plan and returns what its steps printed.
+// Queries do not change the system, so they run without the dry run
+// confirmation, but --explain still shows the native command first.
+func (e Env) query(plan Plan, opts Options) ([]byte, error) {
+    plan = e.elevate(plan)
+    if opts.Explain {
+        fmt.Fprintln(e.Out, ui.Heading("Explanation"))
+        if plan.Explanation != "" {
+            fmt.Fprintln(e.Out, "  "+plan.Explanation)
+        }
+        fmt.Fprintln(e.Out)
+        fmt.Fprintln(e.Out, ui.Key("Native command:"))
+        fmt.Fprintln(e.Out, "  "+ui.Value(plan.String()))
+        fmt.Fprintln(e.Out)
+    }
+
+    var all []byte
+    for _, step := range plan.Steps {
+        out, err := e.Runner.Output(step.Argv())
+        all = append(all, out...)
+        if err := step.check(err); err != nil {
+            return all, err
+        }
+    }
+    return all, nil
+}
+
+// check drops exit errors whose code the step lists in OKCodes.
+func (s Step) check(err error) error {
+    var exitErr *ExitError
+    if errors.As(err, &exitErr) {
+        for _, code := range s.OKCodes {
+            if exitErr.Code == code {
+                return nil
+            }
+        }
+    }
+    return err
+}
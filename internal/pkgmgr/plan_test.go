@@ -0,0 +1,64 @@
+package pkgmgr
+
+import "testing"
+
+// This test checks that arguments are quoted so the rendered command
+// can be pasted into a shell and means the same thing as the argv.
+func TestShellQuote(t *testing.T) {
+    tests := []struct {
+        in   string
+        want string
+    }{
+        {"htop", "htop"},
+        {"python3-dev", "python3-dev"},
+        {"lib32-mesa", "lib32-mesa"},
+        {"foo=1.2.3", "foo=1.2.3"},
+        {"", "''"},
+        {"foo bar", "'foo bar'"},
+        {"foo; rm -rf ~", "'foo; rm -rf ~'"},
+        {"it's", `'it'\''s'`},
+        {"$(id)", "'$(id)'"},
+    }
+
+    for _, tc := range tests {
+        got := shellQuote(tc.in)
+        if got != tc.want {
+            t.Fatalf("shellQuote(%q) = %s, want %s", tc.in, got, tc.want)
+        }
+    }
+}
+
+// This test checks that plans render privileged steps with sudo
+// and join multiple steps the way they are executed.
+func TestPlanString(t *testing.T) {
+    plan := newPlan("test",
+        rootStep("apt", "update"),
+        rootStep("apt", "install", "-y", "htop"),
+    )
+    want := "sudo apt update && sudo apt install -y htop"
+    if got := plan.String(); got != want {
+        t.Fatalf("Plan.String() = %q, want %q", got, want)
+    }
+
+    search := newPlan("test", userStep("apt", "search", "foo; rm -rf ~"))
+    want = "apt search 'foo; rm -rf ~'"
+    if got := search.String(); got != want {
+        t.Fatalf("Plan.String() = %q, want %q", got, want)
+    }
+
+    argv := search.Steps[0].Argv()
+    if len(argv) != 3 || argv[2] != "foo; rm -rf ~" {
+        t.Fatalf("Argv() = %q, want the query as a single argument", argv)
+    }
+}
+
+// This test checks that steps with input render as a pipe into the
+// command, so the preview can be pasted into a terminal.
+func TestStepStringWithInput(t *testing.T) {
+    step := rootStep("tee", "-a", "/etc/apk/repositories").withInput("https://example.org/alpine/v3.19/testing\n")
+    want := `printf '%s' 'https://example.org/alpine/v3.19/testing
+' | sudo tee -a /etc/apk/repositories`
+    if got := step.String(); got != want {
+        t.Fatalf("Step.String() = %q, want %q", got, want)
+    }
+}
@@ -0,0 +1,62 @@
+package pkgmgr
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Provider is a package that owns or ships a file.
+type Provider struct {
+    Package    string `json:"package"`
+    Path       string `json:"path,omitempty"`
+    Repository string `json:"repository,omitempty"`
+}
+
+// ProvidesFinder is implemented by managers that can answer
+// "which package is this file from" questions.
+type ProvidesFinder interface {
+    // Owner returns the installed package that owns path.
+    Owner(path string, opts Options) ([]Provider, error)
+
+    // Provides returns available packages that ship a command named command.
+    Provides(command string, opts Options) ([]Provider, error)
+}
+
+// MissingToolError reports a helper program that is needed for an
+// action but is not installed, along with how to install it.
+type MissingToolError struct {
+    Tool    string
+    Install string
+}
+
+func (e *MissingToolError) Error() string {
+    return fmt.Sprintf("%s is needed for this, install it with: %s", e.Tool, e.Install)
+}
+
+// binPaths lists where a command named command would normally live.
+func binPaths(command string) []string {
+    return []string{"/usr/bin/" + command, "/usr/sbin/" + command, "/bin/" + command, "/sbin/" + command}
+}
+
+// parseOwnedBy parses "path is owned by pkg" lines from pacman -Qo and apk.
+// When splitVersion is set the package is "name-version" and only the
+// name is kept.
+func parseOwnedBy(out string, splitVersion bool) []Provider {
+    var providers []Provider
+    for _, line := range strings.Split(out, "\n") {
+        path, pkg, ok := strings.Cut(line, " is owned by ")
+        if !ok {
+            continue
+        }
+        fields := strings.Fields(pkg)
+        if len(fields) == 0 {
+            continue
+        }
+        name := fields[0]
+        if splitVersion {
+            name, _ = splitNameVersion(name)
+        }
+        providers = append(providers, Provider{Package: name, Path: strings.TrimSpace(path)})
+    }
+    return providers
+}
@@ -0,0 +1,129 @@
+package pkgmgr
+
+import (
+    "errors"
+    "io"
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func TestParseDebProviders(t *testing.T) {
+    out := "net-tools: /sbin/ifconfig\nlibc-bin, libc6:amd64: /usr/share/doc\n"
+    want := []Provider{
+        {Package: "net-tools", Path: "/sbin/ifconfig"},
+        {Package: "libc-bin", Path: "/usr/share/doc"},
+        {Package: "libc6", Path: "/usr/share/doc"},
+    }
+    if got := parseDebProviders(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseDebProviders() = %+v, want %+v", got, want)
+    }
+}
+
+// This test checks that apt explains how to get apt-file when it
+// is missing instead of failing with a confusing exec error.
+func TestAptProvidesNeedsAptFile(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyDebian, "debian")
+    _, err := mgr.(ProvidesFinder).Provides("ifconfig", Options{})
+    var missing *MissingToolError
+    if !errors.As(err, &missing) || missing.Tool != "apt-file" {
+        t.Fatalf("Provides error = %v, want a MissingToolError for apt-file", err)
+    }
+    if want := "penguinguide install apt-file && sudo apt-file update"; missing.Install != want {
+        t.Fatalf("Install hint = %q, want %q", missing.Install, want)
+    }
+    if len(runner.Calls) != 0 {
+        t.Fatalf("commands = %q, want nothing to run", runner.Commands())
+    }
+
+    // The index update goes through whatever gets root on this system.
+    for tool, want := range map[string]string{
+        "doas": "penguinguide install apt-file && doas apt-file update",
+        "":     "penguinguide install apt-file && apt-file update",
+    } {
+        env := Env{Runner: &pkgmgrtest.Runner{}, Out: io.Discard, Elevation: &Elevation{Tool: tool}}
+        _, err := NewWithEnv(&distro.Distro{Family: distro.FamilyDebian, ID: "debian"}, env).(ProvidesFinder).Provides("ifconfig", Options{})
+        if !errors.As(err, &missing) || missing.Install != want {
+            t.Fatalf("Provides error with elevation %q = %v, want the hint %q", tool, err, want)
+        }
+    }
+
+    runner.Installed = []string{"apt-file"}
+    runner.Results = map[string]pkgmgrtest.Result{
+        "apt-file search --regexp ^/(usr/)?s?bin/ifconfig$": {Output: "net-tools: /sbin/ifconfig\n"},
+    }
+    got, err := mgr.(ProvidesFinder).Provides("ifconfig", Options{})
+    if err != nil || len(got) != 1 || got[0].Package != "net-tools" {
+        t.Fatalf("Provides() = %+v, %v, want net-tools", got, err)
+    }
+}
+
+func TestParseDnfProvides(t *testing.T) {
+    out := `net-tools-2.0-0.69.20160912git.fc40.x86_64 : Basic networking tools
+Repo        : fedora
+Matched from:
+Filename    : /usr/sbin/ifconfig
+
+net-tools-2.0-0.69.20160912git.fc40.x86_64 : Basic networking tools
+Repo        : @System
+Matched from:
+Filename    : /usr/sbin/ifconfig
+`
+    want := []Provider{{Package: "net-tools", Path: "/usr/sbin/ifconfig", Repository: "fedora"}}
+    if got := parseDnfProvides(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseDnfProvides() = %+v, want %+v", got, want)
+    }
+}
+
+func TestRpmOwner(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilySUSE, "opensuse-leap")
+    runner.Results = map[string]pkgmgrtest.Result{
+        `rpm --query --file --queryformat %{NAME}\n -- /usr/bin/htop`: {Output: "htop\n"},
+        `rpm --query --file --queryformat %{NAME}\n -- /tmp/x`: {
+            Output: "file /tmp/x is not owned by any package\n",
+            Err:    &ExitError{Argv: []string{"rpm"}, Code: 1},
+        },
+    }
+    finder := mgr.(ProvidesFinder)
+
+    got, err := finder.Owner("/usr/bin/htop", Options{})
+    if err != nil || !reflect.DeepEqual(got, []Provider{{Package: "htop", Path: "/usr/bin/htop"}}) {
+        t.Fatalf("Owner() = %+v, %v, want htop", got, err)
+    }
+    got, err = finder.Owner("/tmp/x", Options{})
+    if err != nil || len(got) != 0 {
+        t.Fatalf("Owner() = %+v, %v, want no owner", got, err)
+    }
+}
+
+func TestParseOwnedBy(t *testing.T) {
+    pacman := parseOwnedBy("/usr/bin/ifconfig is owned by net-tools 2.10-2\n", false)
+    if want := []Provider{{Package: "net-tools", Path: "/usr/bin/ifconfig"}}; !reflect.DeepEqual(pacman, want) {
+        t.Fatalf("parseOwnedBy(pacman) = %+v, want %+v", pacman, want)
+    }
+    apk := parseOwnedBy("/bin/ls is owned by busybox-1.36.1-r5\n", true)
+    if want := []Provider{{Package: "busybox", Path: "/bin/ls"}}; !reflect.DeepEqual(apk, want) {
+        t.Fatalf("parseOwnedBy(apk) = %+v, want %+v", apk, want)
+    }
+}
+
+func TestParsePacmanFiles(t *testing.T) {
+    out := "core/net-tools 2.10-2\n    usr/bin/ifconfig\n"
+    want := []Provider{{Package: "net-tools", Path: "/usr/bin/ifconfig", Repository: "core"}}
+    if got := parsePacmanFiles(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parsePacmanFiles() = %+v, want %+v", got, want)
+    }
+}
+
+func TestApkProvides(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyAlpine, "alpine")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "apk search --exact cmd:ifconfig": {Output: "net-tools-2.10-r3\n"},
+    }
+    got, err := mgr.(ProvidesFinder).Provides("ifconfig", Options{})
+    if err != nil || !reflect.DeepEqual(got, []Provider{{Package: "net-tools"}}) {
+        t.Fatalf("Provides() = %+v, %v, want net-tools", got, err)
+    }
+}
@@ -0,0 +1,98 @@
+package pkgmgr
+
+import (
+    "errors"
+    "fmt"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// Repo is one configured package repository. ID is what the repo
+// commands take to remove, enable, or disable it. File is the
+// configuration file it lives in, when the backend reports one.
+type Repo struct {
+    ID      string `json:"id"`
+    Name    string `json:"name,omitempty"`
+    URL     string `json:"url,omitempty"`
+    Enabled bool   `json:"enabled"`
+    File    string `json:"file,omitempty"`
+}
+
+// RepoSpec describes a repository to add. URL is either a repository
+// address or a shorthand the backend knows, such as ppa:user/name on
+// Ubuntu, copr:user/project or rpmfusion-free on Fedora. Key is where
+// to get the signing key, or the key fingerprint for pacman. Suite and
+// Components are only used for apt repositories.
+type RepoSpec struct {
+    Name       string
+    URL        string
+    Key        string
+    Suite      string
+    Components string
+}
+
+// RepoManager is implemented by managers that can change which
+// repositories packages come from.
+type RepoManager interface {
+    Repos(opts Options) ([]Repo, error)
+    AddRepo(spec RepoSpec, opts Options) error
+    RemoveRepo(id string, opts Options) error
+    EnableRepo(id string, opts Options) error
+    DisableRepo(id string, opts Options) error
+}
+
+// ErrRepoNotFound is returned when no configured repository has the given ID.
+var ErrRepoNotFound = errors.New("repository not found")
+
+// errRepoNeedsName is returned when a plain repository address is added without a name.
+var errRepoNeedsName = errors.New("a name is needed for this repository, pass one with --name")
+
+// globFiles lists files matching a pattern. It is a variable so tests
+// do not depend on the local filesystem.
+var globFiles = filepath.Glob
+
+// findRepo returns the repository with id.
+func findRepo(repos []Repo, id string) (Repo, error) {
+    for _, r := range repos {
+        if r.ID == id {
+            return r, nil
+        }
+    }
+    return Repo{}, fmt.Errorf("%w: %s", ErrRepoNotFound, id)
+}
+
+// validRepoName reports whether name is safe to use as a file name and
+// as a section header, which rules out paths and sed special characters.
+var validRepoName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func checkRepoName(name string) error {
+    if name == "" {
+        return errRepoNeedsName
+    }
+    if !validRepoName.MatchString(name) {
+        return fmt.Errorf("repository name %q can only contain letters, digits, dots, dashes, and underscores", name)
+    }
+    return nil
+}
+
+// downloadStep returns a step that saves url to dest as root, using
+// curl or wget, whichever is installed.
+func (e Env) downloadStep(url, dest string) (Step, error) {
+    switch {
+    case e.has("curl"):
+        return rootStep("curl", "-fsSL", "-o", dest, url), nil
+    case e.has("wget"):
+        return rootStep("wget", "-q", "-O", dest, url), nil
+    }
+    return Step{}, &MissingToolError{Tool: "curl", Install: "penguinguide install curl"}
+}
+
+// keyFileName returns the file name to store a downloaded key under.
+// apt tells armored and binary keys apart by the extension.
+func keyFileName(name, keyURL string) string {
+    if strings.HasSuffix(keyURL, ".gpg") {
+        return name + ".gpg"
+    }
+    return name + ".asc"
+}
@@ -0,0 +1,364 @@
+package pkgmgr
+
+import (
+    "errors"
+    "reflect"
+    "strings"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+// stubGlob makes globFiles return files for each pattern.
+func stubGlob(t *testing.T, files map[string][]string) {
+    old := globFiles
+    globFiles = func(pattern string) ([]string, error) { return files[pattern], nil }
+    t.Cleanup(func() { globFiles = old })
+}
+
+func TestParseAptSourcesList(t *testing.T) {
+    out := `# See sources.list(5)
+deb [arch=amd64 signed-by=/etc/apt/keyrings/docker.asc] https://download.docker.com/linux/ubuntu jammy stable
+# deb http://archive.canonical.com/ubuntu jammy partner
+deb-src http://archive.ubuntu.com/ubuntu jammy main
+`
+    got := parseAptSourcesList("docker", "/etc/apt/sources.list.d/docker.list", out)
+    want := []Repo{
+        {ID: "docker", Name: "jammy stable", URL: "https://download.docker.com/linux/ubuntu", Enabled: true, File: "/etc/apt/sources.list.d/docker.list"},
+        {ID: "docker", Name: "jammy partner", URL: "http://archive.canonical.com/ubuntu", Enabled: false, File: "/etc/apt/sources.list.d/docker.list"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseAptSourcesList() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseDeb822Sources(t *testing.T) {
+    out := `Types: deb
+URIs: http://archive.ubuntu.com/ubuntu/
+Suites: noble noble-updates
+Components: main restricted
+Signed-By: /usr/share/keyrings/ubuntu-archive-keyring.gpg
+
+Enabled: no
+Types: deb
+URIs: http://archive.ubuntu.com/ubuntu/ http://mirror.example.org/ubuntu/
+Suites: noble-proposed
+Components: main
+`
+    got := parseDeb822Sources("ubuntu", "/etc/apt/sources.list.d/ubuntu.sources", out)
+    want := []Repo{
+        {ID: "ubuntu", Name: "noble noble-updates main restricted", URL: "http://archive.ubuntu.com/ubuntu/", Enabled: true, File: "/etc/apt/sources.list.d/ubuntu.sources"},
+        {ID: "ubuntu", Name: "noble-proposed main", URL: "http://archive.ubuntu.com/ubuntu/", Enabled: false, File: "/etc/apt/sources.list.d/ubuntu.sources"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseDeb822Sources() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseDnfRepolist(t *testing.T) {
+    out := `repo id                      repo name                                  status
+fedora                       Fedora 40 - x86_64                         enabled
+updates-testing              Fedora 40 - x86_64 - Test Updates          disabled
+`
+    want := []Repo{
+        {ID: "fedora", Name: "Fedora 40 - x86_64", Enabled: true},
+        {ID: "updates-testing", Name: "Fedora 40 - x86_64 - Test Updates", Enabled: false},
+    }
+    if got := parseDnfRepolist(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseDnfRepolist() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParsePacmanRepos(t *testing.T) {
+    out := `[options]
+HoldPkg = pacman glibc
+
+#[core-testing]
+#Include = /etc/pacman.d/mirrorlist
+
+[core]
+Include = /etc/pacman.d/mirrorlist
+
+[chaotic-aur]
+SigLevel = Required
+Server = https://cdn-mirror.chaotic.cx/$repo/$arch
+`
+    want := []Repo{
+        {ID: "core-testing", Name: "core-testing", URL: "/etc/pacman.d/mirrorlist", Enabled: false, File: pacmanConf},
+        {ID: "core", Name: "core", URL: "/etc/pacman.d/mirrorlist", Enabled: true, File: pacmanConf},
+        {ID: "chaotic-aur", Name: "chaotic-aur", URL: "https://cdn-mirror.chaotic.cx/$repo/$arch", Enabled: true, File: pacmanConf},
+    }
+    if got := parsePacmanRepos(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parsePacmanRepos() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseApkRepositories(t *testing.T) {
+    out := `# Alpine repositories
+https://dl-cdn.alpinelinux.org/alpine/v3.20/main
+#https://dl-cdn.alpinelinux.org/alpine/v3.20/community
+@testing https://dl-cdn.alpinelinux.org/alpine/edge/testing
+`
+    want := []Repo{
+        {ID: "https://dl-cdn.alpinelinux.org/alpine/v3.20/main", URL: "https://dl-cdn.alpinelinux.org/alpine/v3.20/main", Enabled: true, File: apkRepositories},
+        {ID: "https://dl-cdn.alpinelinux.org/alpine/v3.20/community", URL: "https://dl-cdn.alpinelinux.org/alpine/v3.20/community", Enabled: false, File: apkRepositories},
+        {ID: "https://dl-cdn.alpinelinux.org/alpine/edge/testing", Name: "@testing", URL: "https://dl-cdn.alpinelinux.org/alpine/edge/testing", Enabled: true, File: apkRepositories},
+    }
+    if got := parseApkRepositories(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseApkRepositories() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseZypperRepos(t *testing.T) {
+    out := `#  | Alias    | Name            | Enabled | GPG Check | Refresh | URI
+---+----------+-----------------+---------+-----------+---------+-------------------------------------------
+ 1 | repo-oss | Main Repository | Yes     | (r ) Yes  | Yes     | http://download.opensuse.org/distribution/
+ 2 | packman  | Packman         | No      | ----      | ----    | https://ftp.gwdg.de/pub/linux/packman/
+`
+    want := []Repo{
+        {ID: "repo-oss", Name: "Main Repository", URL: "http://download.opensuse.org/distribution/", Enabled: true, File: "/etc/zypp/repos.d/repo-oss.repo"},
+        {ID: "packman", Name: "Packman", URL: "https://ftp.gwdg.de/pub/linux/packman/", Enabled: false, File: "/etc/zypp/repos.d/packman.repo"},
+    }
+    if got := parseZypperRepos(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseZypperRepos() = %+v, want %+v", got, want)
+    }
+}
+
+// This test checks the commands each backend runs to add a signed
+// repository, and the file content written through tee.
+func TestAddRepoCommands(t *testing.T) {
+    spec := RepoSpec{Name: "example", URL: "https://example.org/repo", Key: "https://example.org/key.gpg"}
+    tests := []struct {
+        family   distro.Family
+        id       string
+        want     []string
+        teeCmd   string
+        teeInput string
+    }{
+        {distro.FamilyDebian, "debian", []string{
+            "sudo install -d -m 0755 /etc/apt/keyrings",
+            "sudo curl -fsSL -o /etc/apt/keyrings/example.gpg https://example.org/key.gpg",
+            "sudo tee /etc/apt/sources.list.d/example.sources",
+            "sudo apt update",
+        }, "sudo tee /etc/apt/sources.list.d/example.sources",
+            "Types: deb\nURIs: https://example.org/repo\nSuites: stable\nComponents: main\nSigned-By: /etc/apt/keyrings/example.gpg\n"},
+        {distro.FamilyRHEL, "fedora", []string{
+            "sudo rpm --import https://example.org/key.gpg",
+            "sudo tee /etc/yum.repos.d/example.repo",
+        }, "sudo tee /etc/yum.repos.d/example.repo",
+            "[example]\nname=example\nbaseurl=https://example.org/repo\nenabled=1\ngpgcheck=1\ngpgkey=https://example.org/key.gpg\n"},
+        {distro.FamilySUSE, "opensuse-tumbleweed", []string{
+            "sudo rpm --import https://example.org/key.gpg",
+            "sudo zypper addrepo --refresh https://example.org/repo example",
+        }, "", ""},
+        {distro.FamilyAlpine, "alpine", []string{
+            "sudo curl -fsSL -o /etc/apk/keys/key.gpg https://example.org/key.gpg",
+            "sudo tee -a /etc/apk/repositories",
+            "sudo apk update",
+        }, "sudo tee -a /etc/apk/repositories", "@example https://example.org/repo\n"},
+    }
+    for _, tt := range tests {
+        mgr, runner, _ := newTestManager(tt.family, tt.id)
+        runner.Installed = []string{"curl"}
+        if err := mgr.(RepoManager).AddRepo(spec, Options{}); err != nil {
+            t.Fatalf("%s AddRepo error = %v", tt.id, err)
+        }
+        if got := runner.Commands(); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s AddRepo commands = %q, want %q", tt.id, got, tt.want)
+        }
+        if tt.teeCmd != "" && runner.Inputs[tt.teeCmd] != tt.teeInput {
+            t.Errorf("%s AddRepo wrote %q, want %q", tt.id, runner.Inputs[tt.teeCmd], tt.teeInput)
+        }
+    }
+}
+
+// This test checks that a pacman repository without a key is added
+// with a SigLevel that says so, and that the key is signed when given.
+func TestPacmanAddRepo(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    spec := RepoSpec{Name: "custom", URL: "https://example.org/$arch"}
+    if err := mgr.(RepoManager).AddRepo(spec, Options{}); err != nil {
+        t.Fatalf("AddRepo error = %v", err)
+    }
+    if got, want := runner.Inputs["sudo tee -a /etc/pacman.conf"], "\n[custom]\nSigLevel = Optional TrustAll\nServer = https://example.org/$arch\n"; got != want {
+        t.Fatalf("AddRepo wrote %q, want %q", got, want)
+    }
+
+    mgr, runner, _ = newTestManager(distro.FamilyArch, "arch")
+    spec.Key = "3056513887B78AEB"
+    if err := mgr.(RepoManager).AddRepo(spec, Options{}); err != nil {
+        t.Fatalf("AddRepo error = %v", err)
+    }
+    want := []string{
+        "sudo pacman-key --recv-keys 3056513887B78AEB",
+        "sudo pacman-key --lsign-key 3056513887B78AEB",
+        "sudo tee -a /etc/pacman.conf",
+    }
+    if got := runner.Commands(); !reflect.DeepEqual(got, want) {
+        t.Fatalf("AddRepo commands = %q, want %q", got, want)
+    }
+}
+
+// This test checks the shorthands dnf understands.
+func TestDnfAddRepoShorthands(t *testing.T) {
+    tests := []struct {
+        url       string
+        installed []string
+        want      []string
+    }{
+        {"copr:atim/lazygit", nil, []string{"sudo dnf copr enable atim/lazygit"}},
+        {"rpmfusion-free", nil, []string{
+            "rpm -E %fedora",
+            "sudo dnf install https://mirrors.rpmfusion.org/free/fedora/rpmfusion-free-release-40.noarch.rpm",
+        }},
+        {"https://example.org/example.repo", nil, []string{"sudo dnf config-manager --add-repo https://example.org/example.repo"}},
+        {"https://example.org/example.repo", []string{"dnf5"}, []string{"sudo dnf config-manager addrepo --from-repofile=https://example.org/example.repo"}},
+    }
+    for _, tt := range tests {
+        mgr, runner, _ := newTestManager(distro.FamilyRHEL, "fedora")
+        runner.Installed = tt.installed
+        runner.Results = map[string]pkgmgrtest.Result{"rpm -E %fedora": {Output: "40\n"}}
+        if err := mgr.(RepoManager).AddRepo(RepoSpec{URL: tt.url}, Options{}); err != nil {
+            t.Fatalf("AddRepo(%s) error = %v", tt.url, err)
+        }
+        if got := runner.Commands(); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("AddRepo(%s) commands = %q, want %q", tt.url, got, tt.want)
+        }
+    }
+}
+
+func TestAddRepoNeedsSafeName(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyDebian, "debian")
+    rm := mgr.(RepoManager)
+    if err := rm.AddRepo(RepoSpec{URL: "https://example.org/repo"}, Options{}); !errors.Is(err, errRepoNeedsName) {
+        t.Fatalf("AddRepo without a name error = %v, want errRepoNeedsName", err)
+    }
+    if err := rm.AddRepo(RepoSpec{Name: "../evil", URL: "https://example.org/repo"}, Options{}); err == nil {
+        t.Fatal("AddRepo with a path as name succeeded")
+    }
+    if len(runner.Calls) != 0 {
+        t.Fatalf("AddRepo ran %q for an invalid name", runner.Commands())
+    }
+}
+
+// This test checks that apt only edits files in sources.list.d, and
+// removes the key saved alongside the repository.
+func TestAptRepoEdits(t *testing.T) {
+    stubGlob(t, map[string][]string{
+        "/etc/apt/sources.list.d/*.sources": {"/etc/apt/sources.list.d/example.sources"},
+        "/etc/apt/keyrings/example.*":       {"/etc/apt/keyrings/example.gpg"},
+    })
+    results := map[string]pkgmgrtest.Result{
+        "cat /etc/apt/sources.list":                   {Output: "deb http://deb.debian.org/debian bookworm main\n"},
+        "cat /etc/apt/sources.list.d/example.sources": {Output: "Types: deb\nURIs: https://example.org/repo\nSuites: stable\n"},
+    }
+    reads := []string{"cat /etc/apt/sources.list", "cat /etc/apt/sources.list.d/example.sources",
+        "dpkg --search /etc/apt/sources.list.d/example.sources"}
+
+    mgr, runner, _ := newTestManager(distro.FamilyDebian, "debian")
+    runner.Results = results
+    if err := mgr.(RepoManager).RemoveRepo("example", Options{}); err != nil {
+        t.Fatalf("RemoveRepo error = %v", err)
+    }
+    want := append(append([]string(nil), reads...),
+        "sudo rm -f /etc/apt/sources.list.d/example.sources /etc/apt/keyrings/example.gpg", "sudo apt update")
+    if got := runner.Commands(); !reflect.DeepEqual(got, want) {
+        t.Errorf("RemoveRepo commands = %q, want %q", got, want)
+    }
+
+    mgr, runner, _ = newTestManager(distro.FamilyDebian, "debian")
+    runner.Results = results
+    if err := mgr.(RepoManager).DisableRepo("example", Options{}); err != nil {
+        t.Fatalf("DisableRepo error = %v", err)
+    }
+    want = append(append([]string(nil), reads...),
+        "sudo sed -i -e /^Enabled:/d -e /^Types:/i Enabled: no /etc/apt/sources.list.d/example.sources", "sudo apt update")
+    if got := runner.Commands(); !reflect.DeepEqual(got, want) {
+        t.Errorf("DisableRepo commands = %q, want %q", got, want)
+    }
+
+    mgr, _, _ = newTestManager(distro.FamilyDebian, "debian")
+    if err := mgr.(RepoManager).RemoveRepo("missing", Options{}); !errors.Is(err, ErrRepoNotFound) {
+        t.Errorf("RemoveRepo(missing) error = %v, want ErrRepoNotFound", err)
+    }
+}
+
+// This test checks that apt refuses to remove or disable the
+// distribution's own repositories, whether they are in the installer's
+// .sources file or in a file that comes with a package.
+func TestAptRepoEditsKeepDistroFiles(t *testing.T) {
+    stubGlob(t, map[string][]string{
+        "/etc/apt/sources.list.d/*.sources": {"/etc/apt/sources.list.d/ubuntu.sources", "/etc/apt/sources.list.d/vendor.sources"},
+    })
+    results := map[string]pkgmgrtest.Result{
+        "cat /etc/apt/sources.list.d/ubuntu.sources":           {Output: "Types: deb\nURIs: http://archive.ubuntu.com/ubuntu/\nSuites: noble\nComponents: main\n"},
+        "cat /etc/apt/sources.list.d/vendor.sources":           {Output: "Types: deb\nURIs: https://vendor.example/apt\nSuites: stable\n"},
+        "dpkg --search /etc/apt/sources.list.d/vendor.sources": {Output: "vendor-archive: /etc/apt/sources.list.d/vendor.sources\n"},
+    }
+    for _, id := range []string{"ubuntu", "vendor"} {
+        for name, call := range map[string]func(RepoManager) error{
+            "RemoveRepo":  func(m RepoManager) error { return m.RemoveRepo(id, Options{}) },
+            "DisableRepo": func(m RepoManager) error { return m.DisableRepo(id, Options{}) },
+        } {
+            mgr, runner, _ := newTestManager(distro.FamilyDebian, "ubuntu")
+            runner.Results = results
+            if err := call(mgr.(RepoManager)); err == nil || errors.Is(err, ErrRepoNotFound) {
+                t.Errorf("%s(%s) error = %v, want a refusal", name, id, err)
+            }
+            for _, cmd := range runner.Commands() {
+                if strings.HasPrefix(cmd, "sudo ") {
+                    t.Errorf("%s(%s) ran %q", name, id, cmd)
+                }
+            }
+        }
+    }
+}
+
+// This test checks the sed expressions for pacman sections, which must
+// escape repository names used in the address.
+func TestPacmanRepoEdits(t *testing.T) {
+    conf := "[options]\n\n#[multilib]\n#Include = /etc/pacman.d/mirrorlist\n\n[chaotic.aur]\nServer = https://x\n"
+    tests := []struct {
+        call func(RepoManager) error
+        want string
+    }{
+        {func(r RepoManager) error { return r.EnableRepo("multilib", Options{}) },
+            `sudo sed -i -E /^#\[multilib\]$/,/^$/ s/^#// /etc/pacman.conf`},
+        {func(r RepoManager) error { return r.DisableRepo("chaotic.aur", Options{}) },
+            `sudo sed -i -E /^\[chaotic\.aur\]$/,/^$/ s/^([^#])/#\1/ /etc/pacman.conf`},
+        {func(r RepoManager) error { return r.RemoveRepo("chaotic.aur", Options{}) },
+            `sudo sed -i -E /^#?\[chaotic\.aur\]$/,/^$/d /etc/pacman.conf`},
+    }
+    for _, tt := range tests {
+        mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+        runner.Results = map[string]pkgmgrtest.Result{"cat /etc/pacman.conf": {Output: conf}}
+        if err := tt.call(mgr.(RepoManager)); err != nil {
+            t.Fatalf("error = %v", err)
+        }
+        if got := runner.Commands(); len(got) != 2 || got[1] != tt.want {
+            t.Errorf("commands = %q, want %q after reading pacman.conf", got, tt.want)
+        }
+    }
+}
+
+// This test checks that dnf refuses to delete a file that defines
+// more than the repository being removed.
+func TestDnfRemoveRepoSharedFile(t *testing.T) {
+    stubGlob(t, map[string][]string{"/etc/yum.repos.d/*.repo": {"/etc/yum.repos.d/fedora.repo", "/etc/yum.repos.d/example.repo"}})
+    grep := "grep -F -l -x [fedora-source] /etc/yum.repos.d/fedora.repo /etc/yum.repos.d/example.repo"
+
+    mgr, runner, _ := newTestManager(distro.FamilyRHEL, "fedora")
+    runner.Results = map[string]pkgmgrtest.Result{
+        grep:                               {Output: "/etc/yum.repos.d/fedora.repo\n"},
+        "cat /etc/yum.repos.d/fedora.repo": {Output: "[fedora]\nname=Fedora\n\n[fedora-source]\nname=Fedora Source\n"},
+    }
+    if err := mgr.(RepoManager).RemoveRepo("fedora-source", Options{}); err == nil {
+        t.Fatal("RemoveRepo of a shared file succeeded")
+    }
+    for _, cmd := range runner.Commands() {
+        if cmd == "sudo rm -f /etc/yum.repos.d/fedora.repo" {
+            t.Fatal("RemoveRepo deleted a file with other repositories in it")
+        }
+    }
+}
@@ -0,0 +1,113 @@
+package pkgmgr
+
+import (
+    "strconv"
+    "strings"
+    "time"
+)
+
+/********** RPM helpers shared by dnf and zypper **********/
+
+// rpmInstalled returns installed package names mapped to their versions.
+func rpmInstalled(env Env, opts Options) (map[string]string, error) {
+    step := userStep("rpm", "-qa", "--queryformat", `%{NAME} %{VERSION}-%{RELEASE}\n`)
+    out, err := env.query(newPlan("List installed packages with rpm", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseNameVersionLines(string(out)), nil
+}
+
+// parseNameVersionLines parses "name version" lines.
+func parseNameVersionLines(out string) map[string]string {
+    installed := make(map[string]string)
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Fields(line)
+        if len(fields) == 2 {
+            installed[fields[0]] = fields[1]
+        }
+    }
+    return installed
+}
+
+// parseRpmRequires parses one capability per line, dropping rpmlib
+// internals and file paths that mean nothing to a newcomer.
+func parseRpmRequires(out string) []string {
+    var deps []string
+    seen := make(map[string]bool)
+    for _, line := range strings.Split(out, "\n") {
+        dep := strings.TrimSpace(line)
+        if dep == "" || strings.HasPrefix(dep, "rpmlib(") || strings.HasPrefix(dep, "/") || seen[dep] {
+            continue
+        }
+        seen[dep] = true
+        deps = append(deps, dep)
+    }
+    return deps
+}
+
+// rpmList lists installed packages with their size and install time.
+// explicit holds the names the user installed by name.
+func rpmList(env Env, opts Options, explicit map[string]bool) ([]InstalledPackage, error) {
+    step := userStep("rpm", "-qa", "--queryformat", `%{NAME}\t%{VERSION}-%{RELEASE}\t%{SIZE}\t%{INSTALLTIME}\n`)
+    out, err := env.query(newPlan("List installed packages with rpm", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseRpmList(string(out), explicit), nil
+}
+
+func parseRpmList(out string, explicit map[string]bool) []InstalledPackage {
+    var pkgs []InstalledPackage
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Split(line, "\t")
+        if len(fields) < 4 || fields[0] == "gpg-pubkey" {
+            continue
+        }
+        size, _ := strconv.ParseInt(fields[2], 10, 64)
+        p := InstalledPackage{
+            Name:     fields[0],
+            Version:  fields[1],
+            Explicit: explicit[fields[0]],
+            Size:     size,
+        }
+        if secs, err := strconv.ParseInt(fields[3], 10, 64); err == nil && secs > 0 {
+            p.InstallDate = time.Unix(secs, 0)
+        }
+        pkgs = append(pkgs, p)
+    }
+    return pkgs
+}
+
+// nevraName returns the name from "htop-0:3.3.0-3.fc40.x86_64".
+func nevraName(nevra string) string {
+    s := trimArch(strings.TrimSpace(nevra))
+    for i := 0; i < 2; i++ {
+        j := strings.LastIndex(s, "-")
+        if j <= 0 {
+            return s
+        }
+        s = s[:j]
+    }
+    return s
+}
+
+// rpmOwner returns the installed package that owns path.
+func rpmOwner(env Env, path string, opts Options) ([]Provider, error) {
+    // rpm exits with 1 when no package owns the path.
+    step := userStep("rpm", "--query", "--file", "--queryformat", `%{NAME}\n`, "--", path).allowExit(1)
+    out, err := env.query(newPlan("Find the installed package that owns this file with rpm", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    var providers []Provider
+    for _, line := range strings.Split(string(out), "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.Contains(line, " ") {
+            // "file ... is not owned by any package"
+            continue
+        }
+        providers = append(providers, Provider{Package: line, Path: path})
+    }
+    return providers, nil
+}
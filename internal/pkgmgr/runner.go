@@ -0,0 +1,185 @@
+package pkgmgr
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "os/exec"
+    "strings"
+    "syscall"
+
+    "penguinguide/internal/ui"
+)
+
+// Runner executes native commands. The default runner uses exec.Command,
+// tests and embedding tools can provide their own.
+type Runner interface {
+    // Run executes argv with the terminal attached so the user can see
+    // progress and answer prompts from the package manager.
+    Run(argv []string) error
+
+    // Output executes argv and returns what it wrote to stdout.
+    Output(argv []string) ([]byte, error)
+
+    // LookPath reports where a command is installed, like exec.LookPath.
+    LookPath(file string) (string, error)
+}
+
+// InputRunner is implemented by runners that can pass text on stdin,
+// which steps that write files with tee need.
+type InputRunner interface {
+    // RunInput executes argv with input on stdin. Standard output is
+    // discarded because tee echoes its input.
+    RunInput(argv []string, input string) error
+}
+
+// Prompter asks the user yes or no questions.
+type Prompter interface {
+    Confirm(question string) bool
+}
+
+// Operation describes a plan that runOrPrint executed.
+type Operation struct {
+    Action   string
+    Packages []string
+    Command  string
+    ExitCode int
+}
+
+// Recorder is told about every plan that changes the system,
+// whether it succeeded or not. Skipped dry runs are not recorded.
+type Recorder interface {
+    Record(op Operation)
+}
+
+// Env holds everything a manager uses to talk to the outside world.
+// Zero fields are filled with the defaults for a real terminal,
+// except Recorder and Elevation, which are optional.
+type Env struct {
+    Runner   Runner
+    Prompter Prompter
+    Out      io.Writer
+    Recorder Recorder
+
+    // Elevation is how privileged steps get root. When it is nil they
+    // run through sudo.
+    Elevation *Elevation
+}
+
+// ErrCanceled is returned by a Runner when the user interrupted
+// the command with Ctrl+C.
+var ErrCanceled = errors.New("command canceled by user")
+
+// ExitError reports a command that ran but exited with a non-zero status.
+type ExitError struct {
+    Argv   []string
+    Code   int
+    Stderr string
+}
+
+func (e *ExitError) Error() string {
+    return fmt.Sprintf("%s exited with status %d", e.Argv[0], e.Code)
+}
+
+// DefaultEnv returns an Env that runs real commands on this terminal.
+func DefaultEnv() Env {
+    env := Env{
+        Runner:   execRunner{},
+        Prompter: stdinPrompter{out: os.Stdout},
+        Out:      os.Stdout,
+    }
+    el := env.detectElevation(os.Getenv(ElevateEnv))
+    env.Elevation = &el
+    return env
+}
+
+// has reports whether a command is installed.
+func (e Env) has(file string) bool {
+    _, err := e.Runner.LookPath(file)
+    return err == nil
+}
+
+func (e Env) withDefaults() Env {
+    if e.Out == nil {
+        e.Out = os.Stdout
+    }
+    if e.Runner == nil {
+        e.Runner = execRunner{}
+    }
+    if e.Prompter == nil {
+        e.Prompter = stdinPrompter{out: e.Out}
+    }
+    return e
+}
+
+/********** Defaults **********/
+
+type execRunner struct{}
+
+// Run shows the command's errors on the terminal and also keeps them,
+// so callers can tell why it failed, such as a package that was not found.
+func (execRunner) Run(argv []string) error {
+    var stderr bytes.Buffer
+    cmd := exec.Command(argv[0], argv[1:]...)
+    cmd.Stdout = os.Stdout
+    cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+    cmd.Stdin = os.Stdin
+    return exitError(argv, cmd.Run(), stderr.String())
+}
+
+func (execRunner) RunInput(argv []string, input string) error {
+    var stderr bytes.Buffer
+    cmd := exec.Command(argv[0], argv[1:]...)
+    cmd.Stdin = strings.NewReader(input)
+    cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+    return exitError(argv, cmd.Run(), stderr.String())
+}
+
+func (execRunner) Output(argv []string) ([]byte, error) {
+    var stderr bytes.Buffer
+    cmd := exec.Command(argv[0], argv[1:]...)
+    cmd.Stderr = &stderr
+    out, err := cmd.Output()
+    return out, exitError(argv, err, stderr.String())
+}
+
+// exitError converts errors from os/exec into ErrCanceled or *ExitError
+// so callers do not need to know about wait statuses.
+func exitError(argv []string, err error, stderr string) error {
+    if err == nil {
+        return nil
+    }
+    var exitErr *exec.ExitError
+    if !errors.As(err, &exitErr) {
+        return err
+    }
+    if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+        if status.Signaled() && status.Signal() == syscall.SIGINT {
+            return ErrCanceled
+        }
+    }
+    return &ExitError{Argv: argv, Code: exitErr.ExitCode(), Stderr: stderr}
+}
+
+func (execRunner) LookPath(file string) (string, error) {
+    return exec.LookPath(file)
+}
+
+type stdinPrompter struct {
+    out io.Writer
+}
+
+func (p stdinPrompter) Confirm(question string) bool {
+    fmt.Fprint(p.out, ui.Info(question)+" [y/N]: ")
+
+    var response string
+    if _, err := fmt.Fscan(os.Stdin, &response); err != nil {
+        fmt.Fprintln(p.out)
+        return false
+    }
+
+    response = strings.TrimSpace(strings.ToLower(response))
+    return response == "y" || response == "yes"
+}
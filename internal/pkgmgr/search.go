@@ -0,0 +1,80 @@
+package pkgmgr
+
+import "strings"
+
+// SearchResult is one package found by Manager.Search.
+// Fields the backend does not report are left empty. Source is set
+// by callers that search more than one source.
+type SearchResult struct {
+    Name       string `json:"name"`
+    Version    string `json:"version,omitempty"`
+    Repository string `json:"repository,omitempty"`
+    Summary    string `json:"summary,omitempty"`
+    Installed  bool   `json:"installed"`
+    Source     Source `json:"source,omitempty"`
+}
+
+// searchTerms splits a query into the words a backend searches for.
+// They follow "--", so a word such as -rf is a search term and never
+// an option.
+func searchTerms(query string) []string {
+    return append([]string{"--"}, strings.Fields(query)...)
+}
+
+// markInstalled sets Installed, and the version when the backend did not
+// report one, for results whose name appears in installed.
+func markInstalled(results []SearchResult, installed map[string]string) {
+    for i := range results {
+        version, ok := installed[results[i].Name]
+        if !ok {
+            continue
+        }
+        results[i].Installed = true
+        if results[i].Version == "" {
+            results[i].Version = version
+        }
+    }
+}
+
+// rpmArches are the architecture suffixes dnf adds to package names.
+var rpmArches = map[string]bool{
+    "x86_64": true, "i686": true, "i386": true, "noarch": true,
+    "aarch64": true, "armv7hl": true, "ppc64le": true, "s390x": true,
+    "riscv64": true, "src": true,
+}
+
+// trimArch turns "htop.x86_64" into "htop".
+func trimArch(name string) string {
+    i := strings.LastIndex(name, ".")
+    if i > 0 && rpmArches[name[i+1:]] {
+        return name[:i]
+    }
+    return name
+}
+
+// splitNameVersion splits an apk style "name-1.2.3-r0" into
+// name and version. The release suffix -rN is kept in the version.
+func splitNameVersion(s string) (string, string) {
+    rest := s
+    release := ""
+    if i := strings.LastIndex(rest, "-r"); i > 0 && isDigits(rest[i+2:]) {
+        rest, release = rest[:i], rest[i:]
+    }
+    i := strings.LastIndex(rest, "-")
+    if i <= 0 {
+        return s, ""
+    }
+    return rest[:i], rest[i+1:] + release
+}
+
+func isDigits(s string) bool {
+    if s == "" {
+        return false
+    }
+    for _, r := range s {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return true
+}
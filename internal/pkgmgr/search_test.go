@@ -0,0 +1,334 @@
+package pkgmgr
+
+import (
+    "io"
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func TestParseAptSearch(t *testing.T) {
+    out := `Sorting...
+Full Text Search...
+htop/jammy,now 3.0.5-7build2 amd64 [installed]
+  interactive processes viewer
+
+btop/jammy 1.2.13-1 amd64
+  Modern and colorful command line resource monitor
+`
+    want := []SearchResult{
+        {Name: "htop", Version: "3.0.5-7build2", Repository: "jammy", Summary: "interactive processes viewer", Installed: true},
+        {Name: "btop", Version: "1.2.13-1", Repository: "jammy", Summary: "Modern and colorful command line resource monitor"},
+    }
+    if got := parseAptSearch(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseAptSearch() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseDnfSearch(t *testing.T) {
+    dnf4 := `Last metadata expiration check: 0:12:01 ago on Mon 01 Jan 2024.
+========================= Name Exactly Matched: htop =========================
+htop.x86_64 : Interactive process viewer
+======================== Name & Summary Matched: htop ========================
+htop-debuginfo.x86_64 : Debug information for package htop
+`
+    dnf5 := `Matched fields: name (exact)
+ htop.x86_64	Interactive process viewer
+Matched fields: name, summary
+ htop-debuginfo.x86_64	Debug information for package htop
+`
+    want := []SearchResult{
+        {Name: "htop", Summary: "Interactive process viewer"},
+        {Name: "htop-debuginfo", Summary: "Debug information for package htop"},
+    }
+    for _, out := range []string{dnf4, dnf5} {
+        if got := parseDnfSearch(out); !reflect.DeepEqual(got, want) {
+            t.Fatalf("parseDnfSearch() = %+v, want %+v", got, want)
+        }
+    }
+}
+
+func TestParsePacmanSearch(t *testing.T) {
+    out := `extra/htop 3.3.0-1 [installed]
+    Interactive process viewer
+extra/btop 1.3.0-1 [installed: 1.2.0-1]
+    A monitor of system resources
+extra/bpytop 1.0.68-3
+    Resource monitor that shows usage and stats
+`
+    want := []SearchResult{
+        {Name: "htop", Version: "3.3.0-1", Repository: "extra", Summary: "Interactive process viewer", Installed: true},
+        {Name: "btop", Version: "1.3.0-1", Repository: "extra", Summary: "A monitor of system resources", Installed: true},
+        {Name: "bpytop", Version: "1.0.68-3", Repository: "extra", Summary: "Resource monitor that shows usage and stats"},
+    }
+    if got := parsePacmanSearch(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parsePacmanSearch() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseApkSearch(t *testing.T) {
+    out := `htop-3.2.2-r1 - Interactive process viewer
+htop-doc-3.2.2-r1 - Interactive process viewer (documentation)
+`
+    want := []SearchResult{
+        {Name: "htop", Version: "3.2.2-r1", Summary: "Interactive process viewer"},
+        {Name: "htop-doc", Version: "3.2.2-r1", Summary: "Interactive process viewer (documentation)"},
+    }
+    if got := parseApkSearch(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseApkSearch() = %+v, want %+v", got, want)
+    }
+}
+
+// This test checks that zypper summaries come from the plain table and
+// versions from the --details one, where the installed row wins.
+func TestZypperSearch(t *testing.T) {
+    runner := &pkgmgrtest.Runner{Results: map[string]pkgmgrtest.Result{
+        "zypper --quiet --no-refresh search --type package -- htop": {Output: `
+S  | Name      | Summary                                   | Type
+---+-----------+-------------------------------------------+--------
+i+ | htop      | An interactive process viewer for Linux   | package
+   | bashtop   | Resource monitor that shows usage & stats | package
+`},
+        "zypper --quiet --no-refresh search --details --type package -- htop": {Output: `
+S  | Name    | Type    | Version   | Arch   | Repository
+---+---------+---------+-----------+--------+----------------------
+v  | htop    | package | 3.3.0-1.1 | x86_64 | Main Repository (OSS)
+i+ | htop    | package | 3.2.2-1.3 | x86_64 | Main Repository (OSS)
+v  | htop    | package | 3.2.2-1.3 | i586   | Main Repository (OSS)
+   | bashtop | package | 0.9.25-1  | noarch | Main Repository (OSS)
+`},
+    }}
+    mgr := NewWithEnv(&distro.Distro{Family: distro.FamilySUSE, ID: "opensuse-tumbleweed"}, Env{Runner: runner, Out: io.Discard})
+
+    got, err := mgr.Search("htop", Options{DryRun: true})
+    if err != nil {
+        t.Fatalf("Search error = %v", err)
+    }
+    want := []SearchResult{
+        {Name: "htop", Version: "3.2.2-1.3", Repository: "Main Repository (OSS)", Summary: "An interactive process viewer for Linux", Installed: true},
+        {Name: "bashtop", Version: "0.9.25-1", Repository: "Main Repository (OSS)", Summary: "Resource monitor that shows usage & stats"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("Search() = %+v, want %+v", got, want)
+    }
+}
+
+// This test checks that dnf results are marked installed from rpm,
+// and that searches run without a dry run prompt.
+func TestDnfSearchMarksInstalled(t *testing.T) {
+    mgr, runner, prompter := newTestManager(distro.FamilyRHEL, "fedora")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "dnf search -- htop": {Output: "htop.x86_64 : Interactive process viewer\nhtop-debuginfo.x86_64 : Debug info\n"},
+        `rpm -qa --queryformat %{NAME} %{VERSION}-%{RELEASE}\n`: {Output: "bash 5.2.26-3.fc40\nhtop 3.3.0-3.fc40\n"},
+    }
+
+    got, err := mgr.Search("htop", Options{DryRun: true})
+    if err != nil {
+        t.Fatalf("Search error = %v", err)
+    }
+    want := []SearchResult{
+        {Name: "htop", Version: "3.3.0-3.fc40", Summary: "Interactive process viewer", Installed: true},
+        {Name: "htop-debuginfo", Summary: "Debug info"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("Search() = %+v, want %+v", got, want)
+    }
+    if len(prompter.Questions) != 0 {
+        t.Fatalf("search asked %q, want no prompt for a read only query", prompter.Questions)
+    }
+}
+
+// This test checks that Search hands a hostile query to each backend
+// after "--", so none of its words can be read as an option.
+func TestSearchEndsOptions(t *testing.T) {
+    query := "foo; rm -rf ~"
+    tests := []struct {
+        family distro.Family
+        id     string
+        want   []string
+    }{
+        {distro.FamilyDebian, "debian", []string{"apt", "search", "--", "foo;", "rm", "-rf", "~"}},
+        {distro.FamilyRHEL, "fedora", []string{"dnf", "search", "--", "foo;", "rm", "-rf", "~"}},
+        {distro.FamilyArch, "arch", []string{"pacman", "-Ss", "--", "foo;", "rm", "-rf", "~"}},
+        {distro.FamilyAlpine, "alpine", []string{"apk", "search", "-v", "--", "foo;", "rm", "-rf", "~"}},
+        {distro.FamilySUSE, "opensuse-leap", []string{"zypper", "--quiet", "--no-refresh", "search", "--type", "package", "--", "foo;", "rm", "-rf", "~"}},
+    }
+    for _, tt := range tests {
+        mgr, runner, _ := newTestManager(tt.family, tt.id)
+        if _, err := mgr.Search(query, Options{}); err != nil {
+            t.Fatalf("%s: Search error = %v", tt.id, err)
+        }
+        if len(runner.Calls) == 0 || !reflect.DeepEqual(runner.Calls[0], tt.want) {
+            t.Errorf("%s: Search ran %q, want %q first", tt.id, runner.Calls, tt.want)
+        }
+    }
+
+    subfamilies := []struct {
+        subfamily distro.Subfamily
+        want      []string
+    }{
+        {distro.SubfamilyVoid, []string{"xbps-query", "-Rs", "--", query}},
+        {distro.SubfamilySolus, []string{"eopkg", "search", "--", "foo;", "rm", "-rf", "~"}},
+        {distro.SubfamilyGentoo, []string{"emerge", "--search", "--", "foo;", "rm", "-rf", "~"}},
+    }
+    for _, tt := range subfamilies {
+        runner := &pkgmgrtest.Runner{}
+        mgr := NewWithEnv(&distro.Distro{Family: distro.FamilyOther, Subfamily: tt.subfamily}, Env{Runner: runner, Out: io.Discard})
+        if _, err := mgr.Search(query, Options{}); err != nil {
+            t.Fatalf("%s: Search error = %v", tt.subfamily, err)
+        }
+        if len(runner.Calls) == 0 || !reflect.DeepEqual(runner.Calls[0], tt.want) {
+            t.Errorf("%s: Search ran %q, want %q first", tt.subfamily, runner.Calls, tt.want)
+        }
+    }
+
+    sources := []struct {
+        src  Source
+        want []string
+    }{
+        {SourceFlatpak, []string{"flatpak", "search", "--columns=application,version,remotes,description", "--", query}},
+        {SourceSnap, []string{"snap", "find", "--", query}},
+    }
+    for _, tt := range sources {
+        runner := &pkgmgrtest.Runner{Installed: []string{"flatpak", "snap"}}
+        mgr, err := NewSource(testDistro, tt.src, Env{Runner: runner, Out: io.Discard})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if _, err := mgr.Search(query, Options{}); err != nil {
+            t.Fatalf("%s: Search error = %v", tt.src, err)
+        }
+        if len(runner.Calls) == 0 || !reflect.DeepEqual(runner.Calls[0], tt.want) {
+            t.Errorf("%s: Search ran %q, want %q first", tt.src, runner.Calls, tt.want)
+        }
+    }
+
+    mgr, runner, _, _ := newAURTestManager()
+    if _, err := mgr.Search(query, Options{}); err != nil {
+        t.Fatalf("yay: Search error = %v", err)
+    }
+    want := []string{"yay", "-Ss", "--", "foo;", "rm", "-rf", "~"}
+    if len(runner.Calls) == 0 || !reflect.DeepEqual(runner.Calls[0], want) {
+        t.Errorf("yay: Search ran %q, want %q first", runner.Calls, want)
+    }
+}
+
+// This test checks that "nothing matched" exit codes are not errors.
+func TestSearchNoMatches(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "pacman -Ss -- nosuchpkg": {Err: &ExitError{Argv: []string{"pacman"}, Code: 1}},
+    }
+    got, err := mgr.Search("nosuchpkg", Options{})
+    if err != nil || len(got) != 0 {
+        t.Fatalf("Search() = %v, %v, want no results and no error", got, err)
+    }
+}
+
+func TestSplitNameVersion(t *testing.T) {
+    tests := []struct {
+        in, name, version string
+    }{
+        {"htop-3.2.2-r1", "htop", "3.2.2-r1"},
+        {"py3-requests-2.31.0-r1", "py3-requests", "2.31.0-r1"},
+        {"libxml2-2.11.5-r0", "libxml2", "2.11.5-r0"},
+        {"busybox", "busybox", ""},
+    }
+    for _, tc := range tests {
+        name, version := splitNameVersion(tc.in)
+        if name != tc.name || version != tc.version {
+            t.Fatalf("splitNameVersion(%q) = %q, %q, want %q, %q", tc.in, name, version, tc.name, tc.version)
+        }
+    }
+}
+
+func TestParseXbpsSearch(t *testing.T) {
+    out := `[*] htop-3.3.0_1                Interactive process viewer
+[-] btop-1.3.2_1                Monitor of resources
+`
+    want := []SearchResult{
+        {Name: "htop", Version: "3.3.0_1", Summary: "Interactive process viewer", Installed: true},
+        {Name: "btop", Version: "1.3.2_1", Summary: "Monitor of resources"},
+    }
+    if got := parseXbpsSearch(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseXbpsSearch() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseEmergeSearch(t *testing.T) {
+    out := `
+[ Results for search key : htop ]
+Searching...
+
+*  sys-process/htop
+      Latest version available: 3.3.0
+      Latest version installed: 3.2.2
+      Size of files: 1,234 KiB
+      Homepage:      https://htop.dev/
+      Description:   Interactive text-mode process viewer for Unix systems
+      License:       BSD GPL-2+
+
+*  sys-process/htop-dbg [ Masked ]
+      Latest version available: 1.0
+      Latest version installed: [ Not Installed ]
+      Size of files: 12 KiB
+      Homepage:      https://example.org/
+      Description:   Debug files
+      License:       GPL-2
+
+[ Applications found : 2 ]
+`
+    got := parseEmergeSearch(out)
+    want := []*PackageInfo{
+        {Name: "sys-process/htop", Version: "3.3.0", Repository: "gentoo", Size: 1234 * 1024, License: "BSD GPL-2+",
+            Homepage: "https://htop.dev/", Description: "Interactive text-mode process viewer for Unix systems", Installed: true},
+        {Name: "sys-process/htop-dbg", Version: "1.0", Repository: "gentoo", Size: 12 * 1024, License: "GPL-2",
+            Homepage: "https://example.org/", Description: "Debug files"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseEmergeSearch() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseNixSearch(t *testing.T) {
+    out := `{"legacyPackages.x86_64-linux.htop":{"description":"Interactive process viewer","pname":"htop","version":"3.3.0"},` +
+        `"legacyPackages.x86_64-linux.python3Packages.htop":{"description":"Python bindings","pname":"htop","version":"0.1"}}`
+    want := []SearchResult{
+        {Name: "htop", Version: "3.3.0", Repository: "nixpkgs", Summary: "Interactive process viewer"},
+        {Name: "python3Packages.htop", Version: "0.1", Repository: "nixpkgs", Summary: "Python bindings"},
+    }
+    got, err := parseNixSearch([]byte(out))
+    if err != nil || !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseNixSearch() = %+v, %v, want %+v", got, err, want)
+    }
+}
+
+func TestParseNixProfileList(t *testing.T) {
+    for _, out := range []string{
+        `{"elements":{"htop":{"attrPath":"legacyPackages.x86_64-linux.htop"}},"version":3}`,
+        `{"elements":[{"attrPath":"legacyPackages.x86_64-linux.htop"}],"version":2}`,
+    } {
+        got, err := parseNixProfileList([]byte(out))
+        if want := map[string]string{"htop": ""}; err != nil || !reflect.DeepEqual(got, want) {
+            t.Errorf("parseNixProfileList(%s) = %v, %v, want %v", out, got, err, want)
+        }
+    }
+    if name, version := splitNixName("python3-3.12.3"); name != "python3" || version != "3.12.3" {
+        t.Errorf("splitNixName() = %q, %q, want python3, 3.12.3", name, version)
+    }
+}
+
+func TestParseEopkgList(t *testing.T) {
+    out := `htop          - Interactive process viewer
+btop          - Monitor of resources
+`
+    want := []SearchResult{
+        {Name: "htop", Summary: "Interactive process viewer"},
+        {Name: "btop", Summary: "Monitor of resources"},
+    }
+    if got := parseEopkgList(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseEopkgList() = %+v, want %+v", got, want)
+    }
+}
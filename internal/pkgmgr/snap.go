@@ -0,0 +1,141 @@
+package pkgmgr
+
+import "strings"
+
+/********** Snap **********/
+
+// snapManager installs snaps from the Snap Store. Installing, removing,
+// and refreshing snaps changes the whole system, so those steps use sudo.
+type snapManager struct {
+    env Env
+}
+
+func (m *snapManager) UpdateAll(opts Options) error {
+    plan := newPlan("Refresh all snaps", rootStep("snap", "refresh")).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *snapManager) Install(pkgs []string, opts Options) error {
+    args := append([]string{"snap", "install"}, pkgs...)
+    plan := newPlan("Install snaps from the Snap Store", rootStep(args...)).forAction("install", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *snapManager) Remove(pkgs []string, opts Options) error {
+    args := append([]string{"snap", "remove"}, pkgs...)
+    plan := newPlan("Remove snaps", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *snapManager) Search(query string, opts Options) ([]SearchResult, error) {
+    // snap find exits with 1 when nothing matched.
+    step := userStep("snap", "find", "--", query).allowExit(1)
+    out, err := m.env.query(newPlan("Search the Snap Store", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    results := parseSnapFind(string(out))
+    if len(results) == 0 {
+        return nil, nil
+    }
+
+    installed, err := snapInstalled(m.env, opts)
+    if err != nil {
+        return nil, err
+    }
+    markInstalled(results, installed)
+    return results, nil
+}
+
+func (m *snapManager) Info(name string, opts Options) (*PackageInfo, error) {
+    // snap info exits with 1 when no snap has that name.
+    step := userStep("snap", "info", name).allowExit(1)
+    out, err := m.env.query(newPlan("Show snap details", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    info := parseSnapInfo(string(out))
+    if info.Name == "" {
+        return nil, ErrPackageNotFound
+    }
+    return info, nil
+}
+
+// snapInstalled returns installed snap names mapped to their versions.
+func snapInstalled(env Env, opts Options) (map[string]string, error) {
+    out, err := env.query(newPlan("List installed snaps", userStep("snap", "list")), opts)
+    if err != nil {
+        return nil, err
+    }
+    installed := make(map[string]string)
+    for i, line := range strings.Split(string(out), "\n") {
+        fields := strings.Fields(line)
+        if i == 0 || len(fields) < 2 {
+            continue
+        }
+        installed[fields[0]] = fields[1]
+    }
+    return installed, nil
+}
+
+// parseSnapFind parses snap find output. The summary is the only
+// column that can contain spaces, so it is whatever follows the notes.
+//
+//	Name  Version  Publisher   Notes  Summary
+//	vlc   3.0.18   videolan✓   -      The ultimate media player
+func parseSnapFind(out string) []SearchResult {
+    var results []SearchResult
+    for _, line := range strings.Split(out, "\n") {
+        fields := strings.Fields(line)
+        if len(fields) < 4 || fields[0] == "Name" {
+            continue
+        }
+        results = append(results, SearchResult{
+            Name:       fields[0],
+            Version:    fields[1],
+            Repository: "snapcraft",
+            Summary:    strings.Join(fields[4:], " "),
+        })
+    }
+    return results
+}
+
+// parseSnapInfo parses snap info output. The version comes from the
+// installed line when the snap is installed, and from the first
+// channel listed otherwise.
+func parseSnapInfo(out string) *PackageInfo {
+    fields := parseKeyValues(out)
+    info := &PackageInfo{
+        Name:       fields["name"],
+        Repository: "snapcraft",
+        License:    fields["license"],
+        Homepage:   fields["store-url"],
+        Raw:        out,
+    }
+    if info.License == "unset" {
+        info.License = ""
+    }
+
+    description := strings.TrimSpace(strings.TrimPrefix(fields["description"], "|"))
+    if description == "" {
+        description = fields["summary"]
+    }
+    info.Description = description
+
+    if installed := strings.Fields(fields["installed"]); len(installed) > 0 {
+        info.Installed = true
+        info.Version = installed[0]
+        if len(installed) > 2 {
+            info.InstalledSize = ParseSize(installed[2])
+        }
+        return info
+    }
+    for _, line := range strings.Split(fields["channels"], "\n") {
+        _, rest, ok := strings.Cut(line, ":")
+        if version := strings.Fields(rest); ok && len(version) > 0 && version[0] != "--" && version[0] != "^" {
+            info.Version = version[0]
+            break
+        }
+    }
+    return info
+}
@@ -0,0 +1,64 @@
+package pkgmgr
+
+import (
+    "fmt"
+
+    "penguinguide/internal/distro"
+)
+
+// Source names where packages come from. Native is the distro's own
+// package manager. Flatpak and Snap are installed alongside it and ship
+// desktop apps that bring their own libraries.
+type Source string
+
+const (
+    SourceNative  Source = "native"
+    SourceFlatpak Source = "flatpak"
+    SourceSnap    Source = "snap"
+)
+
+// ParseSource checks a source name given on the command line.
+func ParseSource(name string) (Source, error) {
+    switch src := Source(name); src {
+    case SourceNative, SourceFlatpak, SourceSnap:
+        return src, nil
+    }
+    return "", fmt.Errorf("unknown package source %q, use native, flatpak, or snap", name)
+}
+
+// Sources returns the sources available on this system, native first.
+// Flatpak and Snap only count when their commands are installed.
+func Sources(env Env) []Source {
+    env = env.withDefaults()
+    sources := []Source{SourceNative}
+    if env.has("flatpak") {
+        sources = append(sources, SourceFlatpak)
+    }
+    if env.has("snap") {
+        sources = append(sources, SourceSnap)
+    }
+    return sources
+}
+
+// NewSource returns the manager for src. The native source is the
+// same manager NewWithEnv returns for d. A MissingToolError is
+// returned when Flatpak or Snap is not installed.
+func NewSource(d *distro.Distro, src Source, env Env) (Manager, error) {
+    env = env.withDefaults()
+
+    switch src {
+    case SourceNative:
+        return NewWithEnv(d, env), nil
+    case SourceFlatpak:
+        if !env.has("flatpak") {
+            return nil, &MissingToolError{Tool: "flatpak", Install: "penguinguide install flatpak"}
+        }
+        return &flatpakManager{env: env}, nil
+    case SourceSnap:
+        if !env.has("snap") {
+            return nil, &MissingToolError{Tool: "snap", Install: "penguinguide install snapd"}
+        }
+        return &snapManager{env: env}, nil
+    }
+    return nil, fmt.Errorf("unknown package source %q", src)
+}
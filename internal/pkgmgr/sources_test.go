@@ -0,0 +1,152 @@
+package pkgmgr
+
+import (
+    "errors"
+    "io"
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+var testDistro = &distro.Distro{Family: distro.FamilyDebian, ID: "ubuntu"}
+
+// This test checks that Flatpak and Snap only show up when installed.
+func TestSources(t *testing.T) {
+    runner := &pkgmgrtest.Runner{Installed: []string{"snap"}}
+    got := Sources(Env{Runner: runner, Out: io.Discard})
+    if want := []Source{SourceNative, SourceSnap}; !reflect.DeepEqual(got, want) {
+        t.Fatalf("Sources() = %v, want %v", got, want)
+    }
+
+    _, err := NewSource(testDistro, SourceFlatpak, Env{Runner: runner, Out: io.Discard})
+    var missing *MissingToolError
+    if !errors.As(err, &missing) || missing.Tool != "flatpak" {
+        t.Fatalf("NewSource(flatpak) error = %v, want a MissingToolError", err)
+    }
+}
+
+func TestParseSource(t *testing.T) {
+    if src, err := ParseSource("flatpak"); err != nil || src != SourceFlatpak {
+        t.Fatalf("ParseSource(flatpak) = %q, %v", src, err)
+    }
+    if _, err := ParseSource("appimage"); err == nil {
+        t.Fatalf("ParseSource(appimage) did not fail")
+    }
+}
+
+// This test checks that Flatpak runs without sudo and Snap with it.
+func TestSourceCommands(t *testing.T) {
+    runner := &pkgmgrtest.Runner{Installed: []string{"flatpak", "snap"}}
+    env := Env{Runner: runner, Out: io.Discard}
+
+    for _, src := range []Source{SourceFlatpak, SourceSnap} {
+        mgr, err := NewSource(testDistro, src, env)
+        if err != nil {
+            t.Fatalf("NewSource(%s) error = %v", src, err)
+        }
+        if err := mgr.Install([]string{"org.gimp.GIMP"}, Options{AssumeYes: true}); err != nil {
+            t.Fatalf("%s Install error = %v", src, err)
+        }
+    }
+    want := []string{
+        "flatpak install -y org.gimp.GIMP",
+        "sudo snap install org.gimp.GIMP",
+    }
+    if got := runner.Commands(); !reflect.DeepEqual(got, want) {
+        t.Fatalf("commands = %q, want %q", got, want)
+    }
+}
+
+func TestFlatpakSearch(t *testing.T) {
+    runner := &pkgmgrtest.Runner{
+        Installed: []string{"flatpak"},
+        Results: map[string]pkgmgrtest.Result{
+            "flatpak search --columns=application,version,remotes,description -- gimp": {Output: "org.gimp.GIMP\t2.10.38\tflathub\tCreate images and edit photographs\n" +
+                "org.gimp.GIMP.Plugin.GMic\t3.3.5\tflathub\tG'MIC plugin for GIMP\n"},
+            "flatpak list --app --columns=application,version": {Output: "org.gimp.GIMP\t2.10.36\n"},
+        },
+    }
+    mgr, err := NewSource(testDistro, SourceFlatpak, Env{Runner: runner, Out: io.Discard})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    got, err := mgr.Search("gimp", Options{DryRun: true})
+    if err != nil {
+        t.Fatalf("Search error = %v", err)
+    }
+    want := []SearchResult{
+        {Name: "org.gimp.GIMP", Version: "2.10.38", Repository: "flathub", Summary: "Create images and edit photographs", Installed: true},
+        {Name: "org.gimp.GIMP.Plugin.GMic", Version: "3.3.5", Repository: "flathub", Summary: "G'MIC plugin for GIMP"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("Search() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseFlatpakInfo(t *testing.T) {
+    out := `
+GIMP - Create images and edit photographs
+
+          ID: org.gimp.GIMP
+         Ref: app/org.gimp.GIMP/x86_64/stable
+     Version: 2.10.38
+     License: GPL-3.0+
+      Origin: flathub
+   Installed: 338.1 MB
+`
+    info := parseFlatpakInfo(out)
+    if info.Name != "org.gimp.GIMP" || info.Version != "2.10.38" || info.Repository != "flathub" ||
+        info.Description != "Create images and edit photographs" || !info.Installed || info.InstalledSize == 0 {
+        t.Fatalf("parseFlatpakInfo() = %+v", info)
+    }
+}
+
+func TestParseSnapFind(t *testing.T) {
+    out := `Name  Version  Publisher   Notes    Summary
+vlc   3.0.20   videolan✓   -        The ultimate media player
+mpv   0.37.0   casept      classic  a free, open source, and cross-platform media player
+`
+    want := []SearchResult{
+        {Name: "vlc", Version: "3.0.20", Repository: "snapcraft", Summary: "The ultimate media player"},
+        {Name: "mpv", Version: "0.37.0", Repository: "snapcraft", Summary: "a free, open source, and cross-platform media player"},
+    }
+    if got := parseSnapFind(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseSnapFind() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseSnapInfo(t *testing.T) {
+    out := `name:      vlc
+summary:   The ultimate media player
+publisher: VideoLAN✓
+store-url: https://snapcraft.io/vlc
+license:   GPL-2.0+
+description: |
+  VLC is the VideoLAN project's media player.
+snap-id: RT9mcUhVsRYrDLG8qnvGiy26NKvv6Qkd
+channels:
+  latest/stable:    3.0.20                    2023-11-20 (3777) 341MB -
+  latest/candidate: 3.0.20                    2023-11-20 (3777) 341MB -
+`
+    info := parseSnapInfo(out)
+    want := &PackageInfo{
+        Name:        "vlc",
+        Version:     "3.0.20",
+        Repository:  "snapcraft",
+        License:     "GPL-2.0+",
+        Homepage:    "https://snapcraft.io/vlc",
+        Description: "VLC is the VideoLAN project's media player.",
+        Raw:         out,
+    }
+    if !reflect.DeepEqual(info, want) {
+        t.Fatalf("parseSnapInfo() = %+v, want %+v", info, want)
+    }
+
+    installed := parseSnapInfo(out + "installed:          3.0.19               (3721) 330MB -\n")
+    if !installed.Installed || installed.Version != "3.0.19" || installed.InstalledSize == 0 {
+        t.Fatalf("parseSnapInfo() for an installed snap = %+v", installed)
+    }
+}
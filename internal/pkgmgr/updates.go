@@ -0,0 +1,20 @@
+package pkgmgr
+
+// Upgrade is one pending package upgrade found by CheckUpdates.
+// DownloadSize is zero and Security is false when the backend
+// does not report them. Held is set by MarkHeld.
+type Upgrade struct {
+    Name         string `json:"name"`
+    OldVersion   string `json:"old_version,omitempty"`
+    NewVersion   string `json:"new_version"`
+    Repository   string `json:"repository,omitempty"`
+    DownloadSize int64  `json:"download_size,omitempty"`
+    Security     bool   `json:"security"`
+    Held         bool   `json:"held"`
+}
+
+// UpdateChecker is implemented by managers that can refresh package
+// metadata and list pending upgrades without installing them.
+type UpdateChecker interface {
+    CheckUpdates(opts Options) ([]Upgrade, error)
+}
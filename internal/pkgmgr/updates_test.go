@@ -0,0 +1,128 @@
+package pkgmgr
+
+import (
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func TestParseAptUpgradable(t *testing.T) {
+    out := `Listing...
+openssl/jammy-updates,jammy-security 3.0.2-0ubuntu1.15 amd64 [upgradable from: 3.0.2-0ubuntu1.14]
+htop/jammy-updates 3.0.5-7ubuntu1 amd64 [upgradable from: 3.0.5-7build2]
+`
+    want := []Upgrade{
+        {Name: "openssl", OldVersion: "3.0.2-0ubuntu1.14", NewVersion: "3.0.2-0ubuntu1.15", Repository: "jammy-updates", Security: true},
+        {Name: "htop", OldVersion: "3.0.5-7build2", NewVersion: "3.0.5-7ubuntu1", Repository: "jammy-updates"},
+    }
+    if got := parseAptUpgradable(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseAptUpgradable() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseAptPrintURIs(t *testing.T) {
+    out := `'http://archive.ubuntu.com/ubuntu/pool/main/h/htop/htop_3.0.5-7ubuntu1_amd64.deb' htop_3.0.5-7ubuntu1_amd64.deb 128000 SHA512:abc
+`
+    want := map[string]int64{"htop": 128000}
+    if got := parseAptPrintURIs(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseAptPrintURIs() = %v, want %v", got, want)
+    }
+}
+
+// This test checks that dnf's exit code 100 means "updates available"
+// and that old versions and security flags are filled in.
+func TestDnfCheckUpdates(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyRHEL, "fedora")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "dnf check-update": {
+            Output: "Last metadata expiration check: 0:00:01 ago.\n\n" +
+                "htop.x86_64                3.3.0-4.fc40           updates\n" +
+                "openssl.x86_64             1:3.2.1-3.fc40         updates\n" +
+                "Obsoleting Packages\n" +
+                "grub2-tools.x86_64         1:2.06-1.fc40          updates\n",
+            Err: &ExitError{Argv: []string{"dnf"}, Code: 100},
+        },
+        `rpm -qa --queryformat %{NAME} %{VERSION}-%{RELEASE}\n`: {Output: "htop 3.3.0-3.fc40\nopenssl 3.2.1-2.fc40\n"},
+        "dnf updateinfo list --security": {Output: "FEDORA-2024-1a2b3c important/Sec. openssl-1:3.2.1-3.fc40.x86_64\n"},
+    }
+
+    got, err := mgr.(UpdateChecker).CheckUpdates(Options{})
+    if err != nil {
+        t.Fatalf("CheckUpdates error = %v", err)
+    }
+    want := []Upgrade{
+        {Name: "htop", OldVersion: "3.3.0-3.fc40", NewVersion: "3.3.0-4.fc40", Repository: "updates"},
+        {Name: "openssl", OldVersion: "3.2.1-2.fc40", NewVersion: "1:3.2.1-3.fc40", Repository: "updates", Security: true},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("CheckUpdates() = %+v, want %+v", got, want)
+    }
+}
+
+// This test checks that pacman prefers checkupdates and falls back
+// to pacman -Qu when pacman-contrib is not installed.
+func TestPacmanCheckUpdates(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    runner.Installed = []string{"checkupdates"}
+    runner.Results = map[string]pkgmgrtest.Result{
+        "checkupdates": {Output: "htop 3.2.0-1 -> 3.3.0-1\n"},
+    }
+    got, err := mgr.(UpdateChecker).CheckUpdates(Options{})
+    if err != nil {
+        t.Fatalf("CheckUpdates error = %v", err)
+    }
+    want := []Upgrade{{Name: "htop", OldVersion: "3.2.0-1", NewVersion: "3.3.0-1"}}
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("CheckUpdates() = %+v, want %+v", got, want)
+    }
+
+    mgr, runner, _ = newTestManager(distro.FamilyArch, "arch")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "pacman -Qu": {Err: &ExitError{Argv: []string{"pacman"}, Code: 1}},
+    }
+    got, err = mgr.(UpdateChecker).CheckUpdates(Options{})
+    if err != nil || len(got) != 0 {
+        t.Fatalf("CheckUpdates() = %v, %v, want nothing pending", got, err)
+    }
+    if cmds := runner.Commands(); !reflect.DeepEqual(cmds, []string{"pacman -Qu"}) {
+        t.Fatalf("commands = %q, want the pacman -Qu fallback", cmds)
+    }
+}
+
+func TestParseApkVersion(t *testing.T) {
+    out := `Installed:                                Available:
+htop-3.2.1-r0                           < 3.2.2-r1
+musl-1.2.4-r1                           < 1.2.4-r2
+`
+    want := []Upgrade{
+        {Name: "htop", OldVersion: "3.2.1-r0", NewVersion: "3.2.2-r1"},
+        {Name: "musl", OldVersion: "1.2.4-r1", NewVersion: "1.2.4-r2"},
+    }
+    if got := parseApkVersion(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseApkVersion() = %+v, want %+v", got, want)
+    }
+}
+
+func TestZypperCheckUpdatesRolling(t *testing.T) {
+    mgr, runner, _ := newTestManager(distro.FamilySUSE, "opensuse-tumbleweed")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "zypper --quiet --no-refresh list-updates --dup": {Output: `
+S | Repository | Name | Current Version | Available Version | Arch
+--+------------+------+-----------------+-------------------+-------
+v | repo-oss   | htop | 3.2.2-1.3       | 3.3.0-1.1         | x86_64
+`},
+    }
+    got, err := mgr.(UpdateChecker).CheckUpdates(Options{})
+    if err != nil {
+        t.Fatalf("CheckUpdates error = %v", err)
+    }
+    want := []Upgrade{{Name: "htop", OldVersion: "3.2.2-1.3", NewVersion: "3.3.0-1.1", Repository: "repo-oss"}}
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("CheckUpdates() = %+v, want %+v", got, want)
+    }
+    if cmds := runner.Commands(); cmds[0] != "sudo zypper refresh" {
+        t.Fatalf("first command = %q, want the metadata refresh", cmds[0])
+    }
+}
@@ -0,0 +1,212 @@
+package pkgmgr
+
+import (
+    "cmp"
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+// PackageVersion is one version of a package that can be installed.
+// Repository is where it comes from, and Cached is true when the
+// package file is already in the local package cache.
+type PackageVersion struct {
+    Version    string `json:"version"`
+    Repository string `json:"repository,omitempty"`
+    Installed  bool   `json:"installed"`
+    Cached     bool   `json:"cached,omitempty"`
+
+    // file is the cached package file, for backends that install
+    // older versions from the cache.
+    file string
+}
+
+// Versioner is implemented by managers that can list the versions of a
+// package and go back to an older one.
+type Versioner interface {
+    // Versions returns every version of name the backend can install,
+    // newest first.
+    Versions(name string, opts Options) ([]PackageVersion, error)
+
+    // Downgrade replaces the installed version of name with version.
+    Downgrade(name, version string, opts Options) error
+}
+
+// ErrVersionNotFound is returned when a package version is neither in
+// the repositories nor in the package cache.
+var ErrVersionNotFound = errors.New("version not found")
+
+// SplitVersion splits a name@version argument. Only a version that
+// starts with a digit counts, so apk repository tags such as
+// nano@testing stay part of the name.
+func SplitVersion(arg string) (name, version string) {
+    i := strings.LastIndex(arg, "@")
+    if i <= 0 || i == len(arg)-1 || !unicode.IsDigit(rune(arg[i+1])) {
+        return arg, ""
+    }
+    return arg[:i], arg[i+1:]
+}
+
+// versionedArgs rewrites name@version arguments the way the backend
+// spells them, such as htop=3.3.0 for apt or htop-3.3.0 for dnf.
+func versionedArgs(pkgs []string, sep string) []string {
+    out := make([]string, 0, len(pkgs))
+    for _, p := range pkgs {
+        if name, version := SplitVersion(p); version != "" {
+            p = name + sep + version
+        }
+        out = append(out, p)
+    }
+    return out
+}
+
+// packageNames strips versions, so the history log records names that
+// remove and undo understand.
+func packageNames(pkgs []string) []string {
+    out := make([]string, 0, len(pkgs))
+    for _, p := range pkgs {
+        name, _ := SplitVersion(p)
+        out = append(out, name)
+    }
+    return out
+}
+
+// sortVersions orders versions newest first.
+func sortVersions(versions []PackageVersion) {
+    sort.SliceStable(versions, func(i, j int) bool {
+        return compareVersions(versions[i].Version, versions[j].Version) > 0
+    })
+}
+
+// mergeVersions combines entries for the same version, such as one
+// from a repository and one from the cache.
+func mergeVersions(versions []PackageVersion) []PackageVersion {
+    var out []PackageVersion
+    index := make(map[string]int)
+    for _, v := range versions {
+        i, ok := index[v.Version]
+        if !ok {
+            index[v.Version] = len(out)
+            out = append(out, v)
+            continue
+        }
+        out[i].Installed = out[i].Installed || v.Installed
+        out[i].Cached = out[i].Cached || v.Cached
+        if out[i].Repository == "" {
+            out[i].Repository = v.Repository
+        }
+        if out[i].file == "" {
+            out[i].file = v.file
+        }
+    }
+    sortVersions(out)
+    return out
+}
+
+// PreviousVersion returns the newest version older than the installed
+// one, which is what downgrade picks when no version is given.
+func PreviousVersion(versions []PackageVersion) (PackageVersion, error) {
+    installed := ""
+    for _, v := range versions {
+        if v.Installed {
+            installed = v.Version
+        }
+    }
+    if installed == "" {
+        return PackageVersion{}, errors.New("the package is not installed")
+    }
+    var older []PackageVersion
+    for _, v := range versions {
+        if compareVersions(v.Version, installed) < 0 {
+            older = append(older, v)
+        }
+    }
+    if len(older) == 0 {
+        return PackageVersion{}, fmt.Errorf("%w: no version older than %s is available", ErrVersionNotFound, installed)
+    }
+    sortVersions(older)
+    return older[0], nil
+}
+
+// compareVersions orders two versions of the same package. It follows
+// rpmvercmp, which also orders deb, pacman, and apk versions well
+// enough: an optional epoch, then runs of digits compared as numbers
+// and runs of letters compared as text, with ~ sorting before
+// everything, even the end of the string.
+func compareVersions(a, b string) int {
+    ea, a := splitEpoch(a)
+    eb, b := splitEpoch(b)
+    if c := cmp.Compare(ea, eb); c != 0 {
+        return c
+    }
+
+    for {
+        a = strings.TrimLeftFunc(a, isVersionSeparator)
+        b = strings.TrimLeftFunc(b, isVersionSeparator)
+
+        tildeA, tildeB := strings.HasPrefix(a, "~"), strings.HasPrefix(b, "~")
+        if tildeA || tildeB {
+            if !tildeA {
+                return 1
+            }
+            if !tildeB {
+                return -1
+            }
+            a, b = a[1:], b[1:]
+            continue
+        }
+        if a == "" || b == "" {
+            return cmp.Compare(len(a), len(b))
+        }
+
+        digits := isDigit(a[0])
+        if digits != isDigit(b[0]) {
+            // A number is newer than letters, so 1.0.1 > 1.0a.
+            if digits {
+                return 1
+            }
+            return -1
+        }
+        segA, restA := versionSegment(a, digits)
+        segB, restB := versionSegment(b, digits)
+        if digits {
+            segA = strings.TrimLeft(segA, "0")
+            segB = strings.TrimLeft(segB, "0")
+            if c := cmp.Compare(len(segA), len(segB)); c != 0 {
+                return c
+            }
+        }
+        if c := strings.Compare(segA, segB); c != 0 {
+            return c
+        }
+        a, b = restA, restB
+    }
+}
+
+func splitEpoch(v string) (int, string) {
+    if i := strings.Index(v, ":"); i > 0 {
+        if n, err := strconv.Atoi(v[:i]); err == nil {
+            return n, v[i+1:]
+        }
+    }
+    return 0, v
+}
+
+func versionSegment(s string, digits bool) (string, string) {
+    i := 0
+    for i < len(s) && isDigit(s[i]) == digits && !isVersionSeparator(rune(s[i])) && s[i] != '~' {
+        i++
+    }
+    return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+    return c >= '0' && c <= '9'
+}
+
+func isVersionSeparator(r rune) bool {
+    return r != '~' && !(r >= '0' && r <= '9') && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z')
+}
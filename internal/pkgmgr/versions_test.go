@@ -0,0 +1,261 @@
+package pkgmgr
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmgr/pkgmgrtest"
+)
+
+func TestSplitVersion(t *testing.T) {
+    tests := []struct {
+        arg, name, version string
+    }{
+        {"htop", "htop", ""},
+        {"htop@3.3.0", "htop", "3.3.0"},
+        {"nodejs@1:20.1.0-1", "nodejs", "1:20.1.0-1"},
+        {"nano@testing", "nano@testing", ""},
+        {"@3.3.0", "@3.3.0", ""},
+        {"htop@", "htop@", ""},
+    }
+    for _, tt := range tests {
+        if name, version := SplitVersion(tt.arg); name != tt.name || version != tt.version {
+            t.Errorf("SplitVersion(%q) = %q, %q, want %q, %q", tt.arg, name, version, tt.name, tt.version)
+        }
+    }
+}
+
+func TestCompareVersions(t *testing.T) {
+    tests := []struct {
+        a, b string
+        want int
+    }{
+        {"1.0", "1.0", 0},
+        {"1.10", "1.9", 1},
+        {"1.0", "1.0.1", -1},
+        {"3.3.0-3.fc40", "3.3.0-2.fc40", 1},
+        {"1:1.0", "2.0", 1},
+        {"1.0~rc1", "1.0", -1},
+        {"1.0.1", "1.0a", 1},
+        {"3.2.2-r1", "3.2.2-r10", -1},
+        {"010", "10", 0},
+    }
+    for _, tt := range tests {
+        if got := compareVersions(tt.a, tt.b); got != tt.want {
+            t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+        }
+    }
+}
+
+func TestPreviousVersion(t *testing.T) {
+    versions := []PackageVersion{
+        {Version: "3.3.0-3"},
+        {Version: "3.3.0-2", Installed: true},
+        {Version: "3.2.1-1"},
+        {Version: "3.0.5-1"},
+    }
+    got, err := PreviousVersion(versions)
+    if err != nil || got.Version != "3.2.1-1" {
+        t.Fatalf("PreviousVersion() = %q, %v, want 3.2.1-1", got.Version, err)
+    }
+    if _, err := PreviousVersion(versions[:2]); !errors.Is(err, ErrVersionNotFound) {
+        t.Fatalf("PreviousVersion() without an older version error = %v, want ErrVersionNotFound", err)
+    }
+    if _, err := PreviousVersion([]PackageVersion{{Version: "1.0"}}); err == nil {
+        t.Fatal("PreviousVersion() of a package that is not installed succeeded")
+    }
+}
+
+func TestParseAptPolicy(t *testing.T) {
+    out := `htop:
+  Installed: 3.2.2-2
+  Candidate: 3.2.2-2
+  Version table:
+ *** 3.2.2-2 500
+        500 http://deb.debian.org/debian bookworm/main amd64 Packages
+        100 /var/lib/dpkg/status
+     3.0.5-7 500
+        500 http://deb.debian.org/debian bullseye/main amd64 Packages
+`
+    want := []PackageVersion{
+        {Version: "3.2.2-2", Repository: "bookworm/main", Installed: true},
+        {Version: "3.0.5-7", Repository: "bullseye/main"},
+    }
+    if got := parseAptPolicy(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseAptPolicy() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParseDnfListDuplicates(t *testing.T) {
+    out := `Last metadata expiration check: 0:10:00 ago.
+Installed Packages
+htop.x86_64                  3.3.0-3.fc40                  @updates
+Available Packages
+htop.x86_64                  3.3.0-2.fc40                  fedora
+htop.x86_64                  3.3.0-3.fc40                  updates
+htop-debuginfo.x86_64        3.3.0-3.fc40                  updates-debuginfo
+`
+    want := []PackageVersion{
+        {Version: "3.3.0-3.fc40", Installed: true},
+        {Version: "3.3.0-2.fc40", Repository: "fedora"},
+        {Version: "3.3.0-3.fc40", Repository: "updates"},
+    }
+    if got := parseDnfListDuplicates("htop", out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseDnfListDuplicates() = %+v, want %+v", got, want)
+    }
+    merged := mergeVersions(want)
+    if len(merged) != 2 || !merged[0].Installed || merged[0].Repository != "updates" {
+        t.Fatalf("mergeVersions() = %+v, want the installed version merged with its repository", merged)
+    }
+}
+
+func TestParseApkPolicy(t *testing.T) {
+    out := `htop policy:
+  3.3.0-r0:
+    lib/apk/db/installed
+    https://dl-cdn.alpinelinux.org/alpine/v3.20/main
+  3.2.2-r1:
+    /etc/apk/cache
+    https://dl-cdn.alpinelinux.org/alpine/v3.19/main
+`
+    want := []PackageVersion{
+        {Version: "3.3.0-r0", Repository: "https://dl-cdn.alpinelinux.org/alpine/v3.20/main", Installed: true},
+        {Version: "3.2.2-r1", Repository: "https://dl-cdn.alpinelinux.org/alpine/v3.19/main", Cached: true},
+    }
+    if got := parseApkPolicy(out); !reflect.DeepEqual(got, want) {
+        t.Fatalf("parseApkPolicy() = %+v, want %+v", got, want)
+    }
+}
+
+func TestParsePacmanCacheFile(t *testing.T) {
+    tests := []struct {
+        name, file, version string
+        ok                  bool
+    }{
+        {"htop", "htop-3.3.0-1-x86_64.pkg.tar.zst", "3.3.0-1", true},
+        {"htop", "htop-3.3.0-1-x86_64.pkg.tar.zst.sig", "", false},
+        {"python", "python-3.12.3-1-x86_64.pkg.tar.zst", "3.12.3-1", true},
+        {"python", "python-requests-2.31.0-1-any.pkg.tar.zst", "", false},
+        {"vim", "vim-1:9.1.0-1-x86_64.pkg.tar.xz", "1:9.1.0-1", true},
+    }
+    for _, tt := range tests {
+        version, ok := parsePacmanCacheFile(tt.name, tt.file)
+        if version != tt.version || ok != tt.ok {
+            t.Errorf("parsePacmanCacheFile(%q, %q) = %q, %v, want %q, %v", tt.name, tt.file, version, ok, tt.version, tt.ok)
+        }
+    }
+}
+
+// This test checks how each backend spells an install with a version.
+func TestInstallWithVersion(t *testing.T) {
+    stubGlob(t, map[string][]string{
+        "/var/cache/pacman/pkg/htop-*.pkg.tar*": {
+            "/var/cache/pacman/pkg/htop-3.2.2-1-x86_64.pkg.tar.zst",
+            "/var/cache/pacman/pkg/htop-3.2.2-2-x86_64.pkg.tar.zst",
+            "/var/cache/pacman/pkg/htop-3.2.2-2-x86_64.pkg.tar.zst.sig",
+        },
+    })
+    pkgs := []string{"htop@3.2.2", "curl"}
+    tests := []struct {
+        family distro.Family
+        id     string
+        want   []string
+    }{
+        {distro.FamilyDebian, "debian", []string{"sudo apt install -- htop=3.2.2 curl"}},
+        {distro.FamilyRHEL, "fedora", []string{"sudo dnf install -- htop-3.2.2 curl"}},
+        {distro.FamilyAlpine, "alpine", []string{"sudo apk add -- htop=3.2.2 curl"}},
+        {distro.FamilySUSE, "opensuse-leap", []string{"sudo zypper install -- htop=3.2.2 curl"}},
+        {distro.FamilyArch, "arch", []string{
+            "sudo pacman -S -- curl",
+            "sudo pacman -U /var/cache/pacman/pkg/htop-3.2.2-2-x86_64.pkg.tar.zst",
+        }},
+    }
+    for _, tt := range tests {
+        mgr, runner, _ := newTestManager(tt.family, tt.id)
+        if err := mgr.Install(pkgs, Options{}); err != nil {
+            t.Fatalf("%s Install error = %v", tt.id, err)
+        }
+        if got := runner.Commands(); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s Install commands = %q, want %q", tt.id, got, tt.want)
+        }
+    }
+
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    if err := mgr.Install([]string{"htop@3.0.0"}, Options{}); !errors.Is(err, ErrVersionNotFound) {
+        t.Fatalf("Install of an uncached version error = %v, want ErrVersionNotFound", err)
+    }
+    if len(runner.Calls) != 0 {
+        t.Fatalf("Install of an uncached version ran %q", runner.Commands())
+    }
+}
+
+// This test checks that pinned packages on Arch skip the AUR check.
+func TestAURInstallWithVersion(t *testing.T) {
+    stubGlob(t, map[string][]string{
+        "/var/cache/pacman/pkg/htop-*.pkg.tar*": {"/var/cache/pacman/pkg/htop-3.2.2-1-x86_64.pkg.tar.zst"},
+    })
+    mgr, runner, _, _ := newAURTestManager()
+    if err := mgr.Install([]string{"htop@3.2.2"}, Options{AssumeYes: true}); err != nil {
+        t.Fatalf("Install error = %v", err)
+    }
+    want := []string{"sudo pacman -U --noconfirm /var/cache/pacman/pkg/htop-3.2.2-1-x86_64.pkg.tar.zst"}
+    if got := runner.Commands(); !reflect.DeepEqual(got, want) {
+        t.Fatalf("Install commands = %q, want %q", got, want)
+    }
+}
+
+func TestDowngradeCommands(t *testing.T) {
+    stubGlob(t, map[string][]string{
+        "/var/cache/pacman/pkg/htop-*.pkg.tar*": {"/var/cache/pacman/pkg/htop-3.2.2-1-x86_64.pkg.tar.zst"},
+    })
+    tests := []struct {
+        family distro.Family
+        id     string
+        want   string
+    }{
+        {distro.FamilyDebian, "debian", "sudo apt install --allow-downgrades -- htop=3.2.2-1"},
+        {distro.FamilyRHEL, "fedora", "sudo dnf downgrade -- htop-3.2.2-1"},
+        {distro.FamilyArch, "arch", "sudo pacman -U /var/cache/pacman/pkg/htop-3.2.2-1-x86_64.pkg.tar.zst"},
+        {distro.FamilyAlpine, "alpine", "sudo apk add -- htop=3.2.2-1"},
+        {distro.FamilySUSE, "opensuse-leap", "sudo zypper install --oldpackage -- htop=3.2.2-1"},
+    }
+    for _, tt := range tests {
+        mgr, runner, _ := newTestManager(tt.family, tt.id)
+        if err := mgr.(Versioner).Downgrade("htop", "3.2.2-1", Options{}); err != nil {
+            t.Fatalf("%s Downgrade error = %v", tt.id, err)
+        }
+        if got := runner.Commands(); !reflect.DeepEqual(got, []string{tt.want}) {
+            t.Errorf("%s Downgrade commands = %q, want %q", tt.id, got, tt.want)
+        }
+    }
+}
+
+// This test checks that pacman versions combine the local database,
+// the repos, and the cache.
+func TestPacmanVersions(t *testing.T) {
+    stubGlob(t, map[string][]string{
+        "/var/cache/pacman/pkg/htop-*.pkg.tar*": {
+            "/var/cache/pacman/pkg/htop-3.3.0-1-x86_64.pkg.tar.zst",
+            "/var/cache/pacman/pkg/htop-3.2.2-1-x86_64.pkg.tar.zst",
+        },
+    })
+    mgr, runner, _ := newTestManager(distro.FamilyArch, "arch")
+    runner.Results = map[string]pkgmgrtest.Result{
+        "pacman -Q -- htop":  {Output: "htop 3.3.0-1\n"},
+        "pacman -Si -- htop": {Output: "Repository      : extra\nName            : htop\nVersion         : 3.3.0-2\n"},
+    }
+    got, err := mgr.(Versioner).Versions("htop", Options{})
+    if err != nil {
+        t.Fatalf("Versions error = %v", err)
+    }
+    want := []PackageVersion{
+        {Version: "3.3.0-2", Repository: "extra"},
+        {Version: "3.3.0-1", Installed: true, Cached: true, file: "/var/cache/pacman/pkg/htop-3.3.0-1-x86_64.pkg.tar.zst"},
+        {Version: "3.2.2-1", Cached: true, file: "/var/cache/pacman/pkg/htop-3.2.2-1-x86_64.pkg.tar.zst"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("Versions() = %+v, want %+v", got, want)
+    }
+}
@@ -0,0 +1,121 @@
+package pkgmgr
+
+import "strings"
+
+/********** XBPS **********/
+
+type xbpsManager struct {
+    env Env
+}
+
+func (m *xbpsManager) UpdateAll(opts Options) error {
+    args := []string{"xbps-install", "-Su"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    plan := newPlan("Sync the repository index and update all packages with xbps-install", rootStep(args...)).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *xbpsManager) Install(pkgs []string, opts Options) error {
+    args := []string{"xbps-install", "-S"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(args, pkgs...)
+    plan := newPlan("Install packages with xbps-install", rootStep(args...)).forAction("install", pkgs...)
+    return notFound(m.env.runOrPrint(plan, opts), xbpsNotFound)
+}
+
+func (m *xbpsManager) Remove(pkgs []string, opts Options) error {
+    args := []string{"xbps-remove"}
+    if opts.AssumeYes {
+        args = append(args, "-y")
+    }
+    args = append(args, pkgs...)
+    plan := newPlan("Remove packages with xbps-remove", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *xbpsManager) Search(query string, opts Options) ([]SearchResult, error) {
+    // xbps-query takes a single pattern, so the query stays one word.
+    // It exits with 2 when nothing matched.
+    step := userStep("xbps-query", "-Rs", "--", query).allowExit(2)
+    out, err := m.env.query(newPlan("Search the repositories with xbps-query", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseXbpsSearch(string(out)), nil
+}
+
+func (m *xbpsManager) Info(name string, opts Options) (*PackageInfo, error) {
+    step := userStep("xbps-query", "-R", name).allowExit(2)
+    out, err := m.env.query(newPlan("Show package details with xbps-query", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    info := parseXbpsInfo(string(out))
+    if info.Name == "" {
+        return nil, ErrPackageNotFound
+    }
+
+    // Without -R, xbps-query only looks at installed packages.
+    local := userStep("xbps-query", name).allowExit(2)
+    localOut, err := m.env.query(newPlan("Check whether the package is installed with xbps-query", local), opts)
+    if err != nil {
+        return nil, err
+    }
+    info.Installed = strings.TrimSpace(string(localOut)) != ""
+    return info, nil
+}
+
+// parseXbpsSearch parses xbps-query -Rs output, which looks like:
+//
+//	[*] htop-3.3.0_1   Interactive process viewer
+//
+// where [*] marks installed packages and [-] the others.
+func parseXbpsSearch(out string) []SearchResult {
+    var results []SearchResult
+    for _, line := range strings.Split(out, "\n") {
+        line = strings.TrimSpace(line)
+        if len(line) < 4 || line[0] != '[' || line[2] != ']' {
+            continue
+        }
+        fields := strings.Fields(line[3:])
+        if len(fields) == 0 {
+            continue
+        }
+        name, version := splitNameVersion(fields[0])
+        results = append(results, SearchResult{
+            Name:      name,
+            Version:   version,
+            Summary:   strings.Join(fields[1:], " "),
+            Installed: line[1] == '*',
+        })
+    }
+    return results
+}
+
+// parseXbpsInfo parses the "key: value" lines of xbps-query -R.
+// Lists such as run_depends continue on indented lines.
+func parseXbpsInfo(out string) *PackageInfo {
+    fields := parseKeyValues(out)
+    info := &PackageInfo{Raw: out}
+    if fields["pkgver"] == "" {
+        return info
+    }
+    info.Name, info.Version = splitNameVersion(fields["pkgver"])
+    info.Repository = fields["repository"]
+    info.Size = ParseSize(fields["filename-size"])
+    info.InstalledSize = ParseSize(fields["installed_size"])
+    info.License = fields["license"]
+    info.Homepage = fields["homepage"]
+    info.Description = joinLines(fields["short_desc"])
+    for _, dep := range strings.Fields(fields["run_depends"]) {
+        if i := strings.IndexAny(dep, "<>="); i > 0 {
+            dep = dep[:i]
+        }
+        info.Dependencies = append(info.Dependencies, dep)
+    }
+    return info
+}
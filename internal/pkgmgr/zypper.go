@@ -0,0 +1,447 @@
+package pkgmgr
+
+import (
+    "path/filepath"
+    "strings"
+
+    "penguinguide/internal/distro"
+)
+
+/********** Zypper **********/
+
+// zypperManager covers openSUSE and SLES. Rolling releases such as
+// Tumbleweed must be upgraded with dist-upgrade, while Leap and SLES
+// use a plain update to stay within their release.
+type zypperManager struct {
+    env     Env
+    rolling bool
+}
+
+// zypperArgs returns a zypper argument vector with global options.
+// --non-interactive is a global option and must come before the subcommand.
+func zypperArgs(opts Options, args ...string) []string {
+    out := []string{"zypper"}
+    if opts.AssumeYes {
+        out = append(out, "--non-interactive")
+    }
+    return append(out, args...)
+}
+
+func (m *zypperManager) UpdateAll(opts Options) error {
+    refresh := rootStep(zypperArgs(opts, "refresh")...)
+    if m.rolling {
+        plan := newPlan("Refresh repositories and upgrade this rolling release with zypper dist-upgrade",
+            refresh,
+            rootStep(zypperArgs(opts, "dist-upgrade")...),
+        ).forAction("update")
+        return m.env.runOrPrint(plan, opts)
+    }
+    plan := newPlan("Refresh repositories and update all packages with zypper",
+        refresh,
+        rootStep(zypperArgs(opts, "update")...),
+    ).forAction("update")
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *zypperManager) Install(pkgs []string, opts Options) error {
+    args := zypperArgs(opts, append([]string{"install", "--"}, versionedArgs(pkgs, "=")...)...)
+    plan := newPlan("Install packages with zypper", rootStep(args...)).forAction("install", packageNames(pkgs)...)
+    return notFound(m.env.runOrPrint(plan, opts), zypperNotFound)
+}
+
+func (m *zypperManager) Remove(pkgs []string, opts Options) error {
+    args := zypperArgs(opts, append([]string{"remove", "--"}, pkgs...)...)
+    plan := newPlan("Remove packages with zypper", rootStep(args...)).forAction("remove", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// Search reads names and summaries from the plain search table, which
+// is the only one with a Summary column, and versions and repositories
+// from the --details table, which has one row per version and arch.
+func (m *zypperManager) Search(query string, opts Options) ([]SearchResult, error) {
+    args := append([]string{"zypper", "--quiet", "--no-refresh", "search", "--type", "package"}, searchTerms(query)...)
+    // zypper exits with 104 when nothing matched.
+    step := userStep(args...).allowExit(104)
+    out, err := m.env.query(newPlan("Search for packages with zypper", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    var results []SearchResult
+    for _, row := range parseZypperTable(string(out)) {
+        results = append(results, SearchResult{
+            Name:      row["Name"],
+            Summary:   row["Summary"],
+            Installed: strings.HasPrefix(row["S"], "i"),
+        })
+    }
+    if len(results) == 0 {
+        return nil, nil
+    }
+
+    args = append([]string{"zypper", "--quiet", "--no-refresh", "search", "--details", "--type", "package"}, searchTerms(query)...)
+    step = userStep(args...).allowExit(104)
+    out, err = m.env.query(newPlan("Look up versions and repositories with zypper", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    // The installed version wins, otherwise the first one listed.
+    details := make(map[string]map[string]string)
+    for _, row := range parseZypperTable(string(out)) {
+        if _, ok := details[row["Name"]]; !ok || strings.HasPrefix(row["S"], "i") {
+            details[row["Name"]] = row
+        }
+    }
+    for i := range results {
+        if row, ok := details[results[i].Name]; ok {
+            results[i].Version = row["Version"]
+            results[i].Repository = row["Repository"]
+        }
+    }
+    return results, nil
+}
+
+func (m *zypperManager) Info(name string, opts Options) (*PackageInfo, error) {
+    step := userStep("zypper", "--no-refresh", "info", "--requires", "--", name)
+    out, err := m.env.query(newPlan("Show package details with zypper", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    info := parseZypperInfo(string(out))
+    if info.Name == "" {
+        return nil, ErrPackageNotFound
+    }
+    return info, nil
+}
+
+// IsRollingSUSE reports whether d is a rolling SUSE release that must be
+// upgraded with zypper dist-upgrade instead of zypper update.
+func IsRollingSUSE(d *distro.Distro) bool {
+    id := strings.ToLower(d.ID)
+    return strings.Contains(id, "tumbleweed") || strings.Contains(id, "slowroll")
+}
+
+// parseZypperTable parses the pipe separated tables zypper prints,
+// returning one map per row keyed by the column headers:
+//
+//	S  | Name | Type    | Version | Arch   | Repository
+//	---+------+---------+---------+--------+-----------
+//	i+ | htop | package | 3.2.2   | x86_64 | repo-oss
+func parseZypperTable(out string) []map[string]string {
+    var header []string
+    var rows []map[string]string
+    for _, line := range strings.Split(out, "\n") {
+        if !strings.Contains(line, "|") {
+            continue
+        }
+        cells := strings.Split(line, "|")
+        for i := range cells {
+            cells[i] = strings.TrimSpace(cells[i])
+        }
+        if header == nil {
+            header = cells
+            continue
+        }
+        if strings.HasPrefix(cells[0], "--") {
+            continue
+        }
+        row := make(map[string]string, len(header))
+        for i, h := range header {
+            if i < len(cells) {
+                row[h] = cells[i]
+            }
+        }
+        rows = append(rows, row)
+    }
+    return rows
+}
+
+// parseZypperInfo parses zypper info --requires output.
+func parseZypperInfo(out string) *PackageInfo {
+    body := out
+    if i := strings.Index(body, "\nRepository"); i >= 0 {
+        body = body[i+1:]
+    }
+    fields := parseKeyValues(body)
+    info := &PackageInfo{
+        Name:          fields["name"],
+        Version:       fields["version"],
+        Repository:    fields["repository"],
+        InstalledSize: ParseSize(fields["installed size"]),
+        License:       fields["license"],
+        Homepage:      fields["upstream url"],
+        Description:   joinLines(fields["description"]),
+        Installed:     strings.HasPrefix(strings.ToLower(fields["installed"]), "yes"),
+        Raw:           out,
+    }
+    if info.Description == "" {
+        info.Description = fields["summary"]
+    }
+    for _, line := range strings.Split(fields["requires"], "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix(line, "[") {
+            continue
+        }
+        info.Dependencies = append(info.Dependencies, line)
+    }
+    return info
+}
+
+func (m *zypperManager) List(opts Options) ([]InstalledPackage, error) {
+    step := userStep("zypper", "--quiet", "--no-refresh", "packages", "--userinstalled")
+    out, err := m.env.query(newPlan("List packages you installed by name with zypper", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    explicit := make(map[string]bool)
+    for _, row := range parseZypperTable(string(out)) {
+        explicit[row["Name"]] = true
+    }
+    return rpmList(m.env, opts, explicit)
+}
+
+func (m *zypperManager) CheckUpdates(opts Options) ([]Upgrade, error) {
+    refresh := newPlan("Refresh repository metadata with zypper", rootStep(zypperArgs(opts, "refresh")...))
+    if err := m.env.runOrPrint(refresh.forAction("refresh"), opts); err != nil {
+        return nil, err
+    }
+
+    // Rolling releases are upgraded with dist-upgrade, so ask for
+    // the same set of packages that dist-upgrade would touch.
+    args := []string{"zypper", "--quiet", "--no-refresh", "list-updates"}
+    if m.rolling {
+        args = append(args, "--dup")
+    }
+    out, err := m.env.query(newPlan("List upgradable packages with zypper", userStep(args...)), opts)
+    if err != nil {
+        return nil, err
+    }
+
+    var upgrades []Upgrade
+    for _, row := range parseZypperTable(string(out)) {
+        upgrades = append(upgrades, Upgrade{
+            Name:       row["Name"],
+            OldVersion: row["Current Version"],
+            NewVersion: row["Available Version"],
+            Repository: row["Repository"],
+        })
+    }
+    return upgrades, nil
+}
+
+func (m *zypperManager) Owner(path string, opts Options) ([]Provider, error) {
+    return rpmOwner(m.env, path, opts)
+}
+
+func (m *zypperManager) Provides(command string, opts Options) ([]Provider, error) {
+    args := []string{"zypper", "--quiet", "--no-refresh", "search", "--provides", "--details", "--type", "package"}
+    args = append(args, binPaths(command)[:2]...)
+    // zypper exits with 104 when nothing matched.
+    step := userStep(args...).allowExit(104)
+    out, err := m.env.query(newPlan("Search every available package for this command with zypper", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    var providers []Provider
+    seen := make(map[string]bool)
+    for _, row := range parseZypperTable(string(out)) {
+        if seen[row["Name"]] {
+            continue
+        }
+        seen[row["Name"]] = true
+        providers = append(providers, Provider{Package: row["Name"], Repository: row["Repository"]})
+    }
+    return providers, nil
+}
+
+func (m *zypperManager) CleanupTasks(opts Options) ([]CleanupTask, error) {
+    var tasks []CleanupTask
+    clean := newPlan("Delete cached metadata and package files with zypper", rootStep(zypperArgs(opts, "clean", "--all")...)).forAction("cleanup")
+    if task, ok := m.env.cacheTask("/var/cache/zypp/packages", clean); ok {
+        tasks = append(tasks, task)
+    }
+
+    step := userStep("zypper", "--quiet", "--no-refresh", "packages", "--unneeded")
+    out, err := m.env.query(newPlan("List packages that no installed package needs with zypper", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    var orphans []string
+    for _, row := range parseZypperTable(string(out)) {
+        if name := row["Name"]; name != "" && strings.HasPrefix(row["S"], "i") {
+            orphans = append(orphans, name)
+        }
+    }
+    remove := newPlan("Remove unneeded packages with zypper", rootStep(zypperArgs(opts, append([]string{"remove"}, orphans...)...)...))
+    task, ok, err := m.env.orphanTask(m, orphans, remove, opts)
+    if err != nil {
+        return nil, err
+    }
+    if ok {
+        tasks = append(tasks, task)
+    }
+    return tasks, nil
+}
+
+func (m *zypperManager) Cleanup(task CleanupTask, opts Options) error {
+    return m.env.runOrPrint(task.plan, opts)
+}
+
+func (m *zypperManager) Hold(pkgs []string, opts Options) error {
+    args := zypperArgs(opts, append([]string{"addlock"}, pkgs...)...)
+    plan := newPlan("Lock packages so zypper does not change them", rootStep(args...)).forAction("hold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *zypperManager) Unhold(pkgs []string, opts Options) error {
+    args := zypperArgs(opts, append([]string{"removelock"}, pkgs...)...)
+    plan := newPlan("Remove package locks with zypper", rootStep(args...)).forAction("unhold", pkgs...)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *zypperManager) Holds(opts Options) ([]string, error) {
+    out, err := m.env.query(newPlan("List package locks with zypper", userStep("zypper", "--quiet", "locks")), opts)
+    if err != nil {
+        return nil, err
+    }
+    var names []string
+    for _, row := range parseZypperTable(string(out)) {
+        if name := row["Name"]; name != "" {
+            names = append(names, name)
+        }
+    }
+    return names, nil
+}
+
+const zyppReposDir = "/etc/zypp/repos.d"
+
+func (m *zypperManager) Repos(opts Options) ([]Repo, error) {
+    out, err := m.env.query(newPlan("List configured repositories with zypper", userStep("zypper", "--quiet", "repos", "--uri")), opts)
+    if err != nil {
+        return nil, err
+    }
+    return parseZypperRepos(string(out)), nil
+}
+
+// AddRepo imports the key with rpm first, so zypper does not stop to
+// ask whether to trust it on the first refresh.
+func (m *zypperManager) AddRepo(spec RepoSpec, opts Options) error {
+    if err := checkRepoName(spec.Name); err != nil {
+        return err
+    }
+    var steps []Step
+    explanation := "Add the repository with zypper, which writes " + filepath.Join(zyppReposDir, spec.Name+".repo")
+    if spec.Key != "" {
+        steps = append(steps, rootStep("rpm", "--import", spec.Key))
+        explanation = "Import the signing key with rpm, then add the repository with zypper, which writes " + filepath.Join(zyppReposDir, spec.Name+".repo")
+    }
+    steps = append(steps, rootStep(zypperArgs(opts, "addrepo", "--refresh", spec.URL, spec.Name)...))
+    plan := newPlan(explanation, steps...).forAction("repo-add", spec.Name)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *zypperManager) RemoveRepo(id string, opts Options) error {
+    plan := newPlan("Remove the repository with zypper, which deletes "+filepath.Join(zyppReposDir, id+".repo"),
+        rootStep(zypperArgs(opts, "removerepo", id)...)).forAction("repo-remove", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *zypperManager) EnableRepo(id string, opts Options) error {
+    plan := newPlan("Enable the repository with zypper", rootStep(zypperArgs(opts, "modifyrepo", "--enable", id)...)).forAction("repo-enable", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *zypperManager) DisableRepo(id string, opts Options) error {
+    plan := newPlan("Disable the repository with zypper", rootStep(zypperArgs(opts, "modifyrepo", "--disable", id)...)).forAction("repo-disable", id)
+    return m.env.runOrPrint(plan, opts)
+}
+
+// parseZypperRepos parses the table from zypper repos --uri, finding
+// columns by their header since the set of columns varies by version:
+//
+//	#  | Alias    | Name            | Enabled | GPG Check | Refresh | URI
+//	---+----------+-----------------+---------+-----------+---------+----------------------------
+//	 1 | repo-oss | Main Repository | Yes     | (r ) Yes  | Yes     | http://download.opensuse.org/...
+func parseZypperRepos(out string) []Repo {
+    var repos []Repo
+    columns := map[string]int{}
+    for _, line := range strings.Split(out, "\n") {
+        cells := strings.Split(line, "|")
+        for i := range cells {
+            cells[i] = strings.TrimSpace(cells[i])
+        }
+        if len(cells) < 2 || strings.HasPrefix(cells[0], "-") {
+            continue
+        }
+        if cells[0] == "#" {
+            for i, name := range cells {
+                columns[name] = i
+            }
+            continue
+        }
+        cell := func(name string) string {
+            i, ok := columns[name]
+            if !ok || i >= len(cells) {
+                return ""
+            }
+            return cells[i]
+        }
+        alias := cell("Alias")
+        if alias == "" {
+            continue
+        }
+        repos = append(repos, Repo{
+            ID:      alias,
+            Name:    cell("Name"),
+            URL:     cell("URI"),
+            Enabled: cell("Enabled") == "Yes",
+            File:    filepath.Join(zyppReposDir, alias+".repo"),
+        })
+    }
+    return repos
+}
+
+func (m *zypperManager) Versions(name string, opts Options) ([]PackageVersion, error) {
+    args := []string{"zypper", "--quiet", "--no-refresh", "search", "--details", "--match-exact", "--type", "package", "--", name}
+    // zypper exits with 104 when nothing matched.
+    out, err := m.env.query(newPlan("List every available version with zypper", userStep(args...).allowExit(104)), opts)
+    if err != nil {
+        return nil, err
+    }
+    var versions []PackageVersion
+    for _, row := range parseZypperTable(string(out)) {
+        if row["Name"] != name {
+            continue
+        }
+        versions = append(versions, PackageVersion{
+            Version:    row["Version"],
+            Repository: row["Repository"],
+            Installed:  strings.HasPrefix(row["S"], "i"),
+        })
+    }
+    if len(versions) == 0 {
+        return nil, ErrPackageNotFound
+    }
+    return mergeVersions(versions), nil
+}
+
+func (m *zypperManager) Downgrade(name, version string, opts Options) error {
+    args := zypperArgs(opts, "install", "--oldpackage", "--", name+"="+version)
+    plan := newPlan("Install "+name+" "+version+" with zypper, allowing it to replace a newer version. "+
+        "The next update replaces it again, unless you hold the package", rootStep(args...)).forAction("downgrade", name)
+    return m.env.runOrPrint(plan, opts)
+}
+
+func (m *zypperManager) PackageNames(opts Options) ([]string, error) {
+    step := userStep("zypper", "--quiet", "--no-refresh", "search", "--type", "package")
+    out, err := m.env.query(newPlan("List every package in the repositories with zypper", step), opts)
+    if err != nil {
+        return nil, err
+    }
+    set := make(map[string]bool)
+    for _, row := range parseZypperTable(string(out)) {
+        if row["Name"] != "" {
+            set[row["Name"]] = true
+        }
+    }
+    return sortedNames(set), nil
+}
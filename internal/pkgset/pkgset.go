@@ -0,0 +1,113 @@
+// Package pkgset reads and writes exported package sets: the packages
+// someone installed by name, with enough about the system they came
+// from to reinstall them on the same or a different distro family.
+package pkgset
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "time"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmap"
+    "penguinguide/internal/pkgmgr"
+)
+
+// FormatVersion is written to every export so future versions of
+// penguinguide can change the format without misreading old files.
+const FormatVersion = 1
+
+// Package is one exported package. The version is informational,
+// import always installs the current version.
+type Package struct {
+    Name    string `json:"name"`
+    Version string `json:"version,omitempty"`
+}
+
+// Set is an exported package set.
+type Set struct {
+    Format        int           `json:"format"`
+    Family        distro.Family `json:"family"`
+    DistroID      string        `json:"distro_id"`
+    DistroVersion string        `json:"distro_version,omitempty"`
+    Exported      time.Time     `json:"exported"`
+    Packages      []Package     `json:"packages"`
+}
+
+// New returns a set of the explicitly installed packages in pkgs.
+func New(d *distro.Distro, pkgs []pkgmgr.InstalledPackage, now time.Time) *Set {
+    s := &Set{
+        Format:        FormatVersion,
+        Family:        d.Family,
+        DistroID:      d.ID,
+        DistroVersion: d.VersionID,
+        Exported:      now.UTC(),
+        Packages:      []Package{},
+    }
+    for _, p := range pkgmgr.FilterInstalled(pkgs, pkgmgr.ListExplicit) {
+        s.Packages = append(s.Packages, Package{Name: p.Name, Version: p.Version})
+    }
+    return s
+}
+
+// Write writes the set as indented JSON so it is easy to read and edit.
+func (s *Set) Write(w io.Writer) error {
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    return enc.Encode(s)
+}
+
+// Read reads a set written by Write.
+func Read(r io.Reader) (*Set, error) {
+    var s Set
+    if err := json.NewDecoder(r).Decode(&s); err != nil {
+        return nil, err
+    }
+    if s.Format == 0 || s.Family == "" {
+        return nil, errors.New("not a penguinguide package export")
+    }
+    if s.Format > FormatVersion {
+        return nil, fmt.Errorf("export format %d is newer than this penguinguide understands, please upgrade", s.Format)
+    }
+    return &s, nil
+}
+
+// Resolution is what importing a set on a target family would do.
+// Install holds the names to install, without duplicates. Unverified
+// names are not in the translation table and were kept as they are,
+// so they may not exist on the target family. NoEquivalent names have
+// no package on the target family.
+type Resolution struct {
+    Install      []string
+    Translated   []pkgmap.Translation
+    Unverified   []string
+    NoEquivalent []string
+}
+
+// Resolve maps the set's packages to names used by target.
+func (s *Set) Resolve(table *pkgmap.Table, target distro.Family) Resolution {
+    var res Resolution
+    seen := make(map[string]bool)
+    for _, p := range s.Packages {
+        tr := table.TranslateFrom(p.Name, s.Family, target)
+        switch {
+        case tr.NoMatch && tr.Source != "":
+            res.NoEquivalent = append(res.NoEquivalent, p.Name)
+            continue
+        case tr.NoMatch:
+            res.Unverified = append(res.Unverified, p.Name)
+            continue
+        case tr.Changed:
+            res.Translated = append(res.Translated, tr)
+        }
+        for _, name := range tr.To {
+            if !seen[name] {
+                seen[name] = true
+                res.Install = append(res.Install, name)
+            }
+        }
+    }
+    return res
+}
@@ -0,0 +1,72 @@
+package pkgset
+
+import (
+    "bytes"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    "penguinguide/internal/distro"
+    "penguinguide/internal/pkgmap"
+    "penguinguide/internal/pkgmgr"
+)
+
+// This test checks that only explicit packages are exported and
+// that a set survives a round trip through Write and Read.
+func TestNewWriteRead(t *testing.T) {
+    d := &distro.Distro{Family: distro.FamilyArch, ID: "arch", VersionID: "rolling"}
+    installed := []pkgmgr.InstalledPackage{
+        {Name: "base-devel", Version: "1-1", Explicit: true},
+        {Name: "glibc", Version: "2.39-1"},
+        {Name: "htop", Version: "3.3.0-1", Explicit: true},
+    }
+    set := New(d, installed, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+    var buf bytes.Buffer
+    if err := set.Write(&buf); err != nil {
+        t.Fatalf("Write error = %v", err)
+    }
+    got, err := Read(&buf)
+    if err != nil {
+        t.Fatalf("Read error = %v", err)
+    }
+    if !reflect.DeepEqual(got, set) {
+        t.Fatalf("Read() = %+v, want %+v", got, set)
+    }
+    want := []Package{{Name: "base-devel", Version: "1-1"}, {Name: "htop", Version: "3.3.0-1"}}
+    if !reflect.DeepEqual(got.Packages, want) {
+        t.Fatalf("Packages = %+v, want %+v", got.Packages, want)
+    }
+}
+
+func TestReadRejectsOtherFiles(t *testing.T) {
+    for _, in := range []string{`{"name": "htop"}`, `{"format": 99, "family": "arch"}`, `not json`} {
+        if _, err := Read(strings.NewReader(in)); err == nil {
+            t.Errorf("Read(%q) did not fail", in)
+        }
+    }
+}
+
+// This test checks translation and reporting when moving between families.
+func TestResolve(t *testing.T) {
+    set := &Set{Format: FormatVersion, Family: distro.FamilyArch, Packages: []Package{
+        {Name: "base-devel"}, {Name: "python"}, {Name: "htop"}, {Name: "gcc"},
+    }}
+    res := set.Resolve(pkgmap.Builtin(), distro.FamilyDebian)
+
+    if want := []string{"build-essential", "python3", "gcc"}; !reflect.DeepEqual(res.Install, want) {
+        t.Fatalf("Install = %v, want %v", res.Install, want)
+    }
+    if want := []string{"htop"}; !reflect.DeepEqual(res.Unverified, want) {
+        t.Fatalf("Unverified = %v, want %v", res.Unverified, want)
+    }
+    if len(res.Translated) != 2 {
+        t.Fatalf("Translated = %+v, want base-devel and python", res.Translated)
+    }
+
+    same := set.Resolve(pkgmap.Builtin(), distro.FamilyArch)
+    if want := []string{"base-devel", "python", "htop", "gcc"}; !reflect.DeepEqual(same.Install, want) || len(same.Unverified) != 0 {
+        t.Fatalf("same family Resolve = %+v", same)
+    }
+}
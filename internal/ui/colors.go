@@ -1,5 +1,7 @@
 package ui
 
+import "strings"
+
 // Core ANSI color constants
 const (
     Reset  = "\033[0m"
@@ -49,3 +51,13 @@ func Value(text string) string { // value style
     return Bold + text + Reset
 }
 
+
+// PadRight pads plain text with spaces to width runes.
+// Pad before coloring so escape codes do not break alignment.
+func PadRight(text string, width int) string {
+    n := len([]rune(text))
+    if n >= width {
+        return text
+    }
+    return text + strings.Repeat(" ", width-n)
+}
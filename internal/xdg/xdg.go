@@ -0,0 +1,40 @@
+// Package xdg finds the per-user directories penguinguide keeps files in,
+// following the XDG Base Directory specification.
+package xdg
+
+import (
+    "errors"
+    "os"
+    "path/filepath"
+)
+
+const appName = "penguinguide"
+
+// StateDir returns $XDG_STATE_HOME/penguinguide, which defaults to
+// ~/.local/state/penguinguide. State is data that should survive a
+// restart but is not worth backing up, such as history.
+func StateDir() (string, error) {
+    return baseDir("XDG_STATE_HOME", ".local/state")
+}
+
+// ConfigDir returns $XDG_CONFIG_HOME/penguinguide, which defaults to
+// ~/.config/penguinguide. Files there are written by people, not by
+// penguinguide itself.
+func ConfigDir() (string, error) {
+    return baseDir("XDG_CONFIG_HOME", ".config")
+}
+
+func baseDir(env, fallback string) (string, error) {
+    // The spec says relative paths are invalid and must be ignored.
+    if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+        return filepath.Join(dir, appName), nil
+    }
+    home, err := os.UserHomeDir()
+    if err != nil {
+        return "", err
+    }
+    if home == "" {
+        return "", errors.New("could not find your home directory")
+    }
+    return filepath.Join(home, fallback, appName), nil
+}